@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/sha256" // Package for telling apart the attachments of lists with the same name
+	"encoding/hex"  // Package for formatting the data file hash
+	"fmt"           // Package for formatted I/O (e.g., error messages)
+	"io"            // Package for copying attachment contents
+	"net/url"       // Package for validating links
+	"os"            // Package for file system operations
+	"os/exec"       // Package for launching the system opener
+	"path/filepath" // Package for building attachment paths
+	"runtime"       // Package for choosing the opener of the current platform
+	"sort"          // Package for ordering attachments by name
+	"strconv"       // Package for converting todo IDs to directory names
+)
+
+// maxAttachmentSize is the largest file that can be attached to a todo, since attachments are meant to be small.
+const maxAttachmentSize = 10 << 20 // 10 MiB
+
+// Attachment describes a file or link attached to a todo.
+type Attachment struct {
+	Name string `json:"name"`          // The file name, as it was when attached, or the URL of a link.
+	Size int64  `json:"size"`          // The file size in bytes (0 for links).
+	Path string `json:"path"`          // The path of the stored copy (empty for links).
+	URL  string `json:"url,omitempty"` // The URL of a link.
+}
+
+// attachmentStore keeps copies of the files attached to the todos of one list,
+// in a subdirectory of dir named after each todo's ID (e.g., "attachments/work-3f2a9c1b/12/receipt.pdf").
+type attachmentStore struct {
+	dir    string // The attachments directory of the list.
+	dryRun bool   // If true, Attach validates the file but does not copy it.
+}
+
+// attachments is the store of the active list, used by the attach and attachments commands.
+// It is set from the attachments_dir config setting and the data file.
+var attachments = attachmentStore{dir: "attachments"}
+
+// loadedTodoList is a copy of the active list as it was loaded, which Prune compares the
+// saved list against. It is nil when no list was loaded (e.g., by the MCP server).
+var loadedTodoList *TodoList
+
+// listAttachmentsDir returns the directory in dir holding the attachments of the list stored in
+// dataFile. Todo IDs are only unique within a list, so each data file has its own directory, named
+// after the list and a hash of the data file's absolute path (e.g., "work-3f2a9c1b").
+func listAttachmentsDir(dir, dataFile string) string {
+	if abs, err := filepath.Abs(dataFile); err == nil {
+		dataFile = abs
+	}
+	sum := sha256.Sum256([]byte(dataFile))
+	return filepath.Join(dir, listNameOf(dataFile)+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// todoDir returns the directory holding the attachments of the todo with the given ID.
+func (s attachmentStore) todoDir(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id))
+}
+
+// Attach copies the file at path into the attachments of the todo with the given ID.
+// Only regular files up to maxAttachmentSize can be attached, and a todo cannot have
+// two attachments with the same name. Returns the stored attachment.
+func (s attachmentStore) Attach(todoList *TodoList, id int, path string) (Attachment, error) {
+	if _, err := todoList.Get(id); err != nil {
+		return Attachment{}, err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return Attachment{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return Attachment{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Size() > maxAttachmentSize {
+		return Attachment{}, fmt.Errorf("%s is too large to attach (%d bytes, the limit is %d)", path, info.Size(), maxAttachmentSize)
+	}
+
+	attachment := Attachment{
+		Name: info.Name(),
+		Size: info.Size(),
+		Path: filepath.Join(s.todoDir(id), info.Name()),
+	}
+	if _, err := os.Stat(attachment.Path); err == nil {
+		return Attachment{}, fmt.Errorf("todo with ID %d already has an attachment named %q", id, attachment.Name)
+	}
+	if s.dryRun {
+		return attachment, nil
+	}
+
+	if err := os.MkdirAll(s.todoDir(id), 0755); err != nil {
+		return Attachment{}, err
+	}
+	dst, err := os.OpenFile(attachment.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(attachment.Path) // Don't leave a partial copy behind.
+		return Attachment{}, err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(attachment.Path)
+		return Attachment{}, err
+	}
+	return attachment, nil
+}
+
+// List returns the attachments of the todo with the given ID, sorted by name.
+func (s attachmentStore) List(todoList *TodoList, id int) ([]Attachment, error) {
+	if _, err := todoList.Get(id); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.todoDir(id))
+	if os.IsNotExist(err) {
+		return []Attachment{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	list := []Attachment{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		list = append(list, Attachment{
+			Name: entry.Name(),
+			Size: info.Size(),
+			Path: filepath.Join(s.todoDir(id), entry.Name()),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Prune removes the attachments of todos that were in the list as loaded (before), but are no longer
+// in the saved list or its trash (after), e.g., purged from the trash or cleared completed todos.
+// It runs when the list is saved on exit, rather than on delete, so a delete that is undone in the
+// same session keeps its attachments. Only todos deleted since the list was loaded are considered,
+// so a run that changes nothing removes nothing. Returns the IDs of the todos whose attachments were removed.
+func (s attachmentStore) Prune(before, after *TodoList) ([]int, error) {
+	ids := []int{}
+	for _, todo := range before.Todos {
+		ids = append(ids, todo.ID)
+	}
+	for _, trashed := range before.Trash {
+		ids = append(ids, trashed.Todo.ID)
+		for _, subtask := range trashed.Subtasks {
+			ids = append(ids, subtask.ID)
+		}
+	}
+	sort.Ints(ids)
+
+	pruned := []int{}
+	for _, id := range ids {
+		if _, err := after.Get(id); err == nil || after.InTrash(id) {
+			continue // Still in the list, or may be restored from the trash.
+		}
+		if _, err := os.Stat(s.todoDir(id)); os.IsNotExist(err) {
+			continue // Nothing was attached.
+		}
+		if err := os.RemoveAll(s.todoDir(id)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, id)
+	}
+	return pruned, nil
+}
+
+// isURL reports whether target is a URL (e.g., "https://example.com/spec") rather than a file path.
+func isURL(target string) bool {
+	parsed, err := url.Parse(target)
+	if err != nil || len(parsed.Scheme) < 2 { // A one-letter scheme is a Windows drive letter.
+		return false
+	}
+	return parsed.Host != "" || parsed.Opaque != ""
+}
+
+// addLink attaches a URL to the todo of the list with the given ID. Returns an error if the todo
+// is not found, the URL is invalid, or it is already attached.
+func addLink(tl *TodoList, id int, link string) error {
+	if !isURL(link) {
+		return fmt.Errorf("invalid link %q: use a URL such as https://example.com", link)
+	}
+	for i := range tl.Todos {
+		if tl.Todos[i].ID != id {
+			continue
+		}
+		for _, existing := range tl.Todos[i].Links {
+			if existing == link {
+				return fmt.Errorf("todo with ID %d already has the link %s", id, link)
+			}
+		}
+		tl.Todos[i].Links = append(tl.Todos[i].Links, link)
+		return nil
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// linkAttachments returns the links of the todo as attachments, in the order they were attached.
+func linkAttachments(todo Todo) []Attachment {
+	list := []Attachment{}
+	for _, link := range todo.Links {
+		list = append(list, Attachment{Name: link, URL: link})
+	}
+	return list
+}
+
+// openTarget returns what the open command launches for the todo with the given ID:
+// its first link (attached, or found in its task text), or its first attached file if it has no links.
+func (s attachmentStore) openTarget(todoList *TodoList, id int) (string, error) {
+	todo, err := todoList.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if urls := todoURLs(todo); len(urls) > 0 {
+		return urls[0], nil
+	}
+	files, err := s.List(todoList, id)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("todo with ID %d has no links or attachments to open", id)
+	}
+	return files[0].Path, nil
+}
+
+// openWithSystem launches the system browser or opener for a URL or file, without waiting for it.
+func openWithSystem(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	go cmd.Wait() // Reap the opener once it exits.
+	return nil
+}
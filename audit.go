@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"         // Package for reading the audit log line by line
+	"encoding/json" // Package for encoding audit log entries
+	"flag"          // Package for parsing the options of the history command
+	"fmt"           // Package for formatted I/O (e.g., the history lines)
+	"io"            // Package for discarding flag parsing output
+	"os"            // Package for appending to and reading the audit log
+	"os/user"       // Package for recording who ran a command
+	"sort"          // Package for listing the affected todos in order
+	"strings"       // Package for joining the affected todos
+	"time"          // Package for timestamping audit log entries
+)
+
+// auditEnabled controls whether the commands run are recorded in the audit log of the data file.
+// It is set from the audit_log config setting.
+var auditEnabled bool
+
+// AuditEntry is a command as recorded in the audit log.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`    // Login name of the user who ran the command.
+	Command string    `json:"command"` // The command with its arguments (e.g., "delete 12").
+	IDs     []int     `json:"ids"`     // Todos the command added, deleted, or changed.
+	Result  string    `json:"result"`  // "ok", or "failed" if the command reported an error.
+}
+
+// auditLogPath returns the path of the audit log of dataFile, which is kept next to it, so that
+// each list (and profile) has its own.
+func auditLogPath(dataFile string) string {
+	return dataFile + ".audit"
+}
+
+// currentUser returns the login name of the user running the application, or "unknown".
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
+
+// changedIDs returns the IDs of the todos a diff added, deleted, or modified, sorted.
+func (d TodoListDiff) changedIDs() []int {
+	ids := []int{}
+	for _, todo := range d.Added {
+		ids = append(ids, todo.ID)
+	}
+	for _, todo := range d.Deleted {
+		ids = append(ids, todo.ID)
+	}
+	for _, change := range d.Modified {
+		ids = append(ids, change.After.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// auditCommand runs a command with run, and records it in the audit log along with the todos it
+// changed and whether it failed. Nothing is recorded in dry-run mode, which writes no files.
+func auditCommand(todoList *TodoList, command string, run func() bool) bool {
+	if !auditEnabled || dryRun || command == "" {
+		return run()
+	}
+	before := todoList.Clone()
+	failuresBefore := commandFailures
+	keepGoing := run()
+	entry := AuditEntry{Time: time.Now(), User: currentUser(), Command: command, IDs: DiffTodoLists(before, todoList).changedIDs(), Result: "ok"}
+	if commandFailures > failuresBefore {
+		entry.Result = "failed"
+	}
+	if err := appendAuditEntry(auditLogPath(activeDataFile), entry); err != nil {
+		logger.Error(err, "Failed to record the command in the audit log")
+	}
+	return keepGoing
+}
+
+// appendAuditEntry appends an entry to the audit log at path, as a line of JSON.
+func appendAuditEntry(path string, entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode the audit log entry: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open the audit log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	return nil
+}
+
+// readAuditLog returns the entries of the audit log at path, oldest first. Lines that are not
+// entries (e.g., cut off by a crash) are skipped. A missing audit log has no entries.
+func readAuditLog(path string) ([]AuditEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []AuditEntry{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %w", err)
+	}
+	defer file.Close()
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // Commands with long task texts.
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// historyOptions select the audit log entries the history command shows.
+type historyOptions struct {
+	count int    // Number of the most recent entries shown.
+	id    int    // Only entries that changed this todo, if not 0.
+	user  string // Only entries of this user, if not empty.
+}
+
+// parseHistoryArgs parses the options of the history command (e.g., "-id 12 -n 5").
+func parseHistoryArgs(args []string) (historyOptions, error) {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // Errors are returned and reported by the caller.
+	count := fs.Int("n", 20, "Number of the most recent commands shown")
+	id := fs.Int("id", 0, "Show only the commands that changed this todo")
+	userName := fs.String("user", "", "Show only the commands of this user")
+	if err := fs.Parse(args); err != nil {
+		return historyOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return historyOptions{}, fmt.Errorf("unexpected argument %q for history command", fs.Arg(0))
+	}
+	if *count <= 0 {
+		return historyOptions{}, fmt.Errorf("invalid history count %d: use a positive number", *count)
+	}
+	return historyOptions{count: *count, id: *id, user: *userName}, nil
+}
+
+// filterHistory returns the most recent entries that match the options, oldest first.
+func filterHistory(entries []AuditEntry, options historyOptions) []AuditEntry {
+	matching := []AuditEntry{}
+	for _, entry := range entries {
+		if options.user != "" && entry.User != options.user {
+			continue
+		}
+		if options.id != 0 && !containsID(entry.IDs, options.id) {
+			continue
+		}
+		matching = append(matching, entry)
+	}
+	if len(matching) > options.count {
+		matching = matching[len(matching)-options.count:]
+	}
+	return matching
+}
+
+// containsID reports whether ids contains id.
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// showHistory runs the history command: it lists the commands recorded in the audit log of the
+// active data file, in past sessions as well as this one.
+func showHistory(args []string) {
+	options, err := parseHistoryArgs(args)
+	if err != nil {
+		PrintUserMessage(err.Error())
+		PrintUserMessage("Usage: history [-n <count>] [-id <id>] [-user <name>]")
+		logInputError(err, "Interactive mode input error: invalid history options")
+		return
+	}
+	path := auditLogPath(activeDataFile)
+	entries, err := readAuditLog(path)
+	if err != nil {
+		logger.Error(err, "Failed to show the history")
+		printError(err)
+		return
+	}
+	entries = filterHistory(entries, options)
+	if outputJSON {
+		printJSON(entries)
+		return
+	}
+	if len(entries) == 0 {
+		message := "📜 No commands are recorded in " + path + "."
+		if !auditEnabled {
+			message += " Set audit_log to true to record them."
+		}
+		PrintUserMessage(message)
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("📜 Last %d commands:", len(entries)))
+	for _, entry := range entries {
+		line := fmt.Sprintf("  %s  %s  %s", entry.Time.Local().Format(dateFormat+" 15:04:05"), entry.User, entry.Command)
+		if len(entry.IDs) > 0 {
+			ids := make([]string, len(entry.IDs))
+			for i, id := range entry.IDs {
+				ids[i] = fmt.Sprintf("#%d", id)
+			}
+			line += " → " + strings.Join(ids, ", ")
+		}
+		if entry.Result != "ok" {
+			line += " ❌ " + entry.Result
+		}
+		PrintUserMessage(line)
+	}
+}
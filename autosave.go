@@ -1,33 +1,105 @@
 package main
 
 import (
-	"time" // Package for time-related operations, used for `time.After` and `time.Duration`
+	"context" // Package for stopping the auto-save goroutine on exit
+	"sync"    // Package for restarting the auto-save safely, and guarding the list it saves
+	"time"    // Package for time-related operations, used for `time.After` and `time.Duration`
 )
 
+// todoListMu guards the todo list of the running application, which auto-save and a shutdown signal
+// save while the commands change it. Commands change a working copy of the list (see runAtomically),
+// so it is only held while the list itself is replaced, changed, or saved.
+var todoListMu sync.Mutex
+
 // StartAutoSave Goroutine initiates a background process that periodically saves
 // the current state of the TodoList to a specified JSON file.
 // It takes a pointer to the TodoList, the filename for persistence, and the interval
-// at which to perform the auto-save operation.
-func StartAutoSave(todoList *TodoList, filename string, interval time.Duration) {
+// at which to perform the auto-save operation. It runs until ctx is cancelled; the returned
+// channel is closed once it has stopped, so that a save in progress can be waited for.
+func StartAutoSave(ctx context.Context, todoList *TodoList, filename string, interval time.Duration) <-chan struct{} {
+	stopped := make(chan struct{})
 	// The `go func()` syntax starts a new goroutine, allowing the auto-save logic
 	// to run concurrently with the main application flow without blocking it.
 	go func() {
-		// This infinite loop ensures the auto-save runs continuously until the application exits.
+		defer close(stopped)
+		// This loop ensures the auto-save runs continuously until the context is cancelled.
 		for {
-			// `<-time.After(interval)` blocks the goroutine for the specified `interval`.
-			// After the interval, it sends a value to the channel returned by `time.After`,
-			// unblocking the goroutine and allowing the next iteration to proceed.
-			<-time.After(interval)
+			// `time.After(interval)` sends a value after the specified `interval`, unblocking
+			// the goroutine and allowing the next save to proceed, unless it is stopped first.
+			select {
+			case <-ctx.Done():
+				logger.Info("Auto-save stopped.")
+				return
+			case <-time.After(interval):
+			}
+
+			// Changes made in an open transaction are saved on commit, all at once.
+			if inTransaction() {
+				logger.Info("Auto-save skipped: a transaction is open.")
+				continue
+			}
 
 			// Attempt to save the TodoList to the file.
-			err := todoList.SaveToFile(filename)
+			todoListMu.Lock()
+			err := saveTodoList(todoList, filename) // Also records a failure, so the prompt and status command report it.
+			todoListMu.Unlock()
 			if err != nil {
 				// If saving fails, log an error with a descriptive message.
-				LogError(err, "Auto-save failed")
+				logger.Error(err, "Auto-save failed")
 			} else {
 				// If saving is successful, log an informational message.
-				LogInfo("Auto-saved todo list.")
+				logger.Info("Auto-saved todo list.")
 			}
 		}
 	}()
+	return stopped
+}
+
+// autoSaver runs the auto-save of the todo list, and restarts it when a reload of the config
+// changes the data file or the interval.
+type autoSaver struct {
+	mu       sync.Mutex
+	todoList *TodoList
+	dataFile string             // The data file the list is auto-saved to.
+	stop     context.CancelFunc // Stops the running auto-save; nil once it is stopped.
+	stopped  <-chan struct{}    // Closed once the running auto-save has stopped.
+}
+
+// autoSave is the auto-save of the running application. It is nil in dry-run mode,
+// and in tests, where nothing is auto-saved.
+var autoSave *autoSaver
+
+// startAutoSaver starts auto-saving the todo list to dataFile every interval.
+func startAutoSaver(todoList *TodoList, dataFile string, interval time.Duration) *autoSaver {
+	a := &autoSaver{todoList: todoList}
+	a.Restart(dataFile, interval)
+	return a
+}
+
+// Restart stops the auto-save, waiting for a save in progress, and starts it again
+// with dataFile and interval.
+func (a *autoSaver) Restart(dataFile string, interval time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopLocked()
+	ctx, cancel := context.WithCancel(context.Background())
+	a.dataFile, a.stop, a.stopped = dataFile, cancel, StartAutoSave(ctx, a.todoList, dataFile, interval)
+}
+
+// Stop stops the auto-save, waiting for a save in progress, and returns the data file it saved to.
+func (a *autoSaver) Stop() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopLocked()
+	return a.dataFile
+}
+
+// stopLocked stops the running auto-save, if any. a.mu must be held.
+func (a *autoSaver) stopLocked() {
+	if a.stop == nil {
+		return
+	}
+	a.stop()
+	<-a.stopped
+	a.stop = nil
 }
@@ -1,13 +1,17 @@
 package main
 
 import (
-	"bufio"   // Package for buffered I/O operations (e.g., reading from stdin)
-	"flag"    // Package for parsing command-line flags
-	"fmt"     // Package for formatted I/O (e.g., printing to console)
-	"os"      // Package for operating system functionalities (e.g., exiting the program)
-	"strconv" // Package for converting strings to other data types
-	"strings" // Package for string manipulation
-	"time"    // Package for handling dates and times
+	"bufio"         // Package for buffered I/O operations (e.g., reading from stdin)
+	"errors"        // Package for creating and inspecting errors
+	"flag"          // Package for parsing command-line flags
+	"fmt"           // Package for formatted I/O (e.g., printing to console)
+	"io"            // Package for I/O primitives (e.g., reading bulk input)
+	"net/smtp"      // Package for sending the email digest
+	"os"            // Package for operating system functionalities (e.g., exiting the program)
+	"strconv"       // Package for converting strings to other data types
+	"strings"       // Package for string manipulation
+	"time"          // Package for handling dates and times
+	"todo/pkg/todo" // Package for parsing dates, statuses, queries, and sort expressions
 )
 
 // ActionType represents the type of action performed.
@@ -19,362 +23,2153 @@ const (
 	ActionComplete
 	ActionDelete
 	ActionUncomplete
+	ActionStatus
+	ActionChange // Any other change, undone by restoring the todos it changed.
+	ActionEdit
+	ActionClearCompleted
 )
 
-// lastAction stores information about the last performed action for undo functionality.
+// lastAction stores information about a performed action for undo functionality.
+// Actions are saved in the undo journal (see undojournal.go), hence the JSON tags.
 type lastAction struct {
-	Type ActionType
-	ID   int // ID of the todo affected by the action
-	// For delete, we need to store the entire Todo object to re-add it.
-	DeletedTodo *Todo
+	Type        ActionType `json:"type"`
+	ID          int        `json:"id,omitempty"` // ID of the todo affected by the action
+	Description string     `json:"description"`  // What the action did, e.g., "completing todo #3", shown when it is undone.
+	// For delete, we need to store the entire Todo object to re-add it,
+	// along with the subtasks that were deleted with it.
+	DeletedTodo     *Todo  `json:"deleted_todo,omitempty"`
+	DeletedSubtasks []Todo `json:"deleted_subtasks,omitempty"`
+	// Their positions in the list, the todo's first, so undo puts them back in their places.
+	DeletedPositions []int `json:"deleted_positions,omitempty"`
 	// For complete/uncomplete, we need to store the previous completed status.
-	PreviousCompletedStatus bool
+	PreviousCompletedStatus bool `json:"previous_completed_status,omitempty"`
+	// For complete and status changes, the status the todo had before.
+	PreviousStatus TodoStatus `json:"previous_status,omitempty"`
+	// For completing a recurring todo, the ID of the next occurrence that was added.
+	NextOccurrenceID int `json:"next_occurrence_id,omitempty"`
+	// For edit, the task text before the edit.
+	PreviousTask string `json:"previous_task,omitempty"`
+	// For clear-completed, the todos that were removed and their positions in the list.
+	ClearedTodos     []Todo `json:"cleared_todos,omitempty"`
+	ClearedPositions []int  `json:"cleared_positions,omitempty"`
+	// For other changes, the todos that changed, as they were before.
+	Change *listChange `json:"change,omitempty"`
 }
 
-// lastActionState tracks the most recent action for undo purposes.
-var lastActionState lastAction
+// maxUndoSteps is the number of actions that undo can walk back.
+const maxUndoSteps = 100
+
+// undoStack records the actions of the session that can be undone, the most recent last.
+var undoStack []lastAction
+
+// undoPushes counts the actions recorded on the undo stack, so that runAtomically can tell
+// whether a command recorded its own.
+var undoPushes int
+
+// pushUndo records an action on the undo stack, forgetting the oldest one if it is full.
+func pushUndo(action lastAction) {
+	if len(undoStack) == maxUndoSteps {
+		undoStack = undoStack[1:]
+	}
+	undoStack = append(undoStack, action)
+	undoPushes++
+}
+
+// popUndo removes the most recent action from the undo stack and returns it.
+// Returns false if there is nothing to undo.
+func popUndo() (lastAction, bool) {
+	if len(undoStack) == 0 {
+		return lastAction{}, false
+	}
+	action := undoStack[len(undoStack)-1]
+	undoStack = undoStack[:len(undoStack)-1]
+	return action, true
+}
+
+// commandAliases maps user-defined alias names to the command line they expand to
+// (e.g., "a" -> "add -p high"). It is set from the aliases config setting.
+var commandAliases map[string]string
+
+// expandAlias replaces the first field of a command with its alias expansion, if any.
+// Expansion is not recursive, so an alias cannot expand to another alias.
+func expandAlias(splitCommand []string) []string {
+	if len(splitCommand) == 0 {
+		return splitCommand
+	}
+	expansion, ok := commandAliases[splitCommand[0]]
+	if !ok {
+		return splitCommand
+	}
+	return append(strings.Fields(expansion), splitCommand[1:]...)
+}
+
+// console reads user input for interactive mode and interactive pickers, so that all
+// reads share one buffered reader on stdin. It is created by runInteractiveMode, or on
+// first use by getConsole in single-command mode.
+var console *lineEditor
+
+// getConsole returns the shared console, creating one without history if needed.
+func getConsole() *lineEditor {
+	if console == nil {
+		console = newLineEditor(os.Stdin, os.Stdout, "")
+	}
+	return console
+}
 
 // runInteractiveMode provides a continuous loop for user interaction,
 // prompting for commands and executing them until the user decides to exit.
 // It directly interacts with the TodoList and utilizes logging utilities.
-func runInteractiveMode(todoList *TodoList) {
-	PrintUserMessage("🚀 Entering interactive mode. Type 'help' for commands, 'exit' to quit.")
-	reader := bufio.NewReader(os.Stdin)
+// Input is read through a line editor, which provides history and editing keys on terminals,
+// and the prompt is rendered from the configured template before each command.
+// Commands are read from in, which is os.Stdin except when scripted (e.g., in tests).
+func runInteractiveMode(todoList *TodoList, config Config, in io.Reader) {
+	if !minimalOutput {
+		PrintUserMessage("🚀 Entering interactive mode. Type 'help' for commands, 'exit' to quit.")
+	}
+	console = newLineEditor(in, os.Stdout, config.HistoryFile)
+	prompt := newInteractivePrompt(config.Prompt, config.DataFile)
 	for {
-		fmt.Print("> ")                     // Keep prompt on stdout
-		input, _ := reader.ReadString('\n') // Read user input until a newline character.
-		command := strings.TrimSpace(input) // Remove leading/trailing whitespace.
-
-		splitCommand := strings.Fields(command) // Split the command string into fields.
+		input, err := console.ReadLine(prompt.Render(todoList, time.Now())) // Keep prompt on stdout
+		if err == io.EOF && input == "" {
+			PrintUserMessage("")
+			return // Stop when the input is closed (e.g., Ctrl-D or end of piped input).
+		}
+		// Split the command into fields, reading the following lines of multi-line commands.
+		splitCommand := expandAlias(readMultiLineCommand(console, input))
 		if len(splitCommand) == 0 {
 			continue // If input is empty, prompt again.
 		}
+		// Reloading the config also changes the prompt, so it is handled by the loop.
+		if strings.EqualFold(splitCommand[0], "reload") {
+			config = reloadConfig(todoList, config)
+			prompt = newInteractivePrompt(config.Prompt, config.DataFile)
+			continue
+		}
 
-		subCommand := strings.ToLower(splitCommand[0]) // Get the main command (e.g., "add", "list").
+		failuresBefore := commandFailures
+		keepGoing := auditCommand(todoList, strings.Join(splitCommand, " "), func() bool {
+			return executeCommand(todoList, splitCommand)
+		})
+		if !keepGoing {
+			return // Exit the interactive loop.
+		}
+		if stopOnFailure && commandFailures > failuresBefore {
+			PrintUserMessage(fmt.Sprintf("⛔ Stopped at the failed command: %s", strings.Join(splitCommand, " ")))
+			return
+		}
+	}
+}
 
-		switch subCommand {
-		case "add":
-			// Interactive add command needs to parse task, priority, due date, and tags from the input string.
-			// This is a simplified approach; a dedicated interactive parser would be more robust.
-			parts := splitCommand[1:]
-			task := ""
-			priority := ""
-			dueDateStr := ""
-			tags := []string{}
+// executeCommand runs a single interactive-style command (e.g., "add Buy milk -p high")
+// given as its whitespace-separated fields. It is shared by interactive mode and by
+// single-command mode when a command is passed as positional arguments.
+// Returns false if the command asks to exit interactive mode.
+//
+// Commands other than undo and the transaction commands run atomically (see runAtomically).
+func executeCommand(todoList *TodoList, splitCommand []string) bool {
+	// "/query" starts a live search with an initial query, like "/ query".
+	if len(splitCommand[0]) > 1 && strings.HasPrefix(splitCommand[0], "/") {
+		splitCommand = append([]string{"/", splitCommand[0][1:]}, splitCommand[1:]...)
+	}
 
-			for i := 0; i < len(parts); i++ {
-				if parts[i] == "-p" && i+1 < len(parts) {
-					priority = parts[i+1]
-					i++
-				} else if parts[i] == "-d" && i+1 < len(parts) {
-					dueDateStr = parts[i+1]
-					i++
-				} else if parts[i] == "-t" && i+1 < len(parts) {
-					tags = append(tags, strings.Split(parts[i+1], ",")...)
-					i++
-				} else {
-					if task == "" { // First unflagged part is the task
-						task = parts[i]
-					} else {
-						task += " " + parts[i]
-					}
-				}
-			}
+	subCommand := strings.ToLower(splitCommand[0]) // Get the main command (e.g., "add", "list").
+	logger.Debug(fmt.Sprintf("Running command: %s", strings.Join(splitCommand, " ")))
+	defer logger.Timing(fmt.Sprintf("Command %s", subCommand), time.Now())
+	if managesUndo(subCommand) {
+		todoListMu.Lock() // They change the list itself.
+		defer todoListMu.Unlock()
+		return runCommand(todoList, splitCommand, subCommand)
+	}
+	return runAtomically(todoList, strings.Join(splitCommand, " "), func(working *TodoList) bool {
+		return runCommand(working, splitCommand, subCommand)
+	})
+}
 
-			if task == "" {
-				PrintUserMessage("Usage: add <task> [-p <priority>] [-d <YYYY-MM-DD>] [-t <tag1,tag2>]")
-				LogError(fmt.Errorf("missing task for add command"), "Interactive mode input error")
-			} else {
-				var dueDate *time.Time
-				if dueDateStr != "" {
-					parsedDate, err := parseDueDate(dueDateStr)
-					if err != nil {
-						PrintUserMessage("Invalid due date format. Use YYYY-MM-DD.")
-						LogError(err, "Interactive mode input error: invalid due date")
-						continue
-					}
-					dueDate = &parsedDate
-				}
-				todoList.Add(task, toCanonicalPriority(PriorityLevel(priority)), dueDate, tags)
-				lastActionState = lastAction{Type: ActionAdd, ID: todoList.NextID - 1} // Store ID of newly added todo
-			}
-		case "edit":
-			if len(splitCommand) < 3 {
-				PrintUserMessage("Usage: edit <id> <new_task_description>")
-				LogError(fmt.Errorf("missing ID or new task for edit command"), "Interactive mode input error")
+// managesUndo reports whether a command is undo or a transaction command. These manage the undo
+// stack themselves, so they never run atomically: runAtomically would record what undo did as a
+// change of its own, and the next undo would revert the undo instead of going back further.
+func managesUndo(subCommand string) bool {
+	switch strings.ToLower(subCommand) {
+	case "undo", "begin", "commit", "rollback":
+		return true
+	}
+	return false
+}
+
+// runCommand runs a command for executeCommand. subCommand is its lowercase name.
+func runCommand(todoList *TodoList, splitCommand []string, subCommand string) bool {
+	switch subCommand {
+	case "add":
+		// Interactive add command needs to parse task, priority, due date, and tags from the input string.
+		todo, err := addTodoFromArgs(todoList, splitCommand[1:])
+		if errors.Is(err, errMissingTask) {
+			PrintUserMessage("Usage: add <task> [-p <priority>] [-d <date>] [-e <date>] [-s <date>] [-t <tag1,tag2>]")
+			logInputError(err, "Interactive mode input error")
+		} else if err != nil {
+			logger.InputError(err, "Interactive mode input error: invalid add arguments")
+			printError(err)
+		} else {
+			printAdded(todo)
+			pushUndo(lastAction{Type: ActionAdd, ID: todo.ID, Description: fmt.Sprintf("adding todo #%d", todo.ID)})
+			warnWIPLimit(todoList, todo)
+			offerDueDateSuggestion(todoList, todo)
+		}
+	case "clone":
+		cloneTodoCommand(todoList, splitCommand[1:])
+	case "begin":
+		if err := beginTransaction(todoList); err != nil {
+			printError(err)
+			break
+		}
+		PrintUserMessage("🧾 Started a transaction. Auto-save is paused until commit saves all changes at once, or rollback discards them.")
+	case "commit":
+		diff, err := commitTransaction(todoList, activeDataFile)
+		if err != nil {
+			logger.Error(err, "Failed to commit the transaction")
+			printError(err)
+			break
+		}
+		printTransactionEnded(diff, true)
+	case "rollback":
+		diff, err := rollbackTransaction(todoList)
+		if err != nil {
+			printError(err)
+			break
+		}
+		printTransactionEnded(diff, false)
+	case "edit":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: edit <id> <new_task_description>")
+			logInputError(fmt.Errorf("missing ID or new task for edit command"), "Interactive mode input error")
+		} else {
+			id, err := strconv.Atoi(splitCommand[1])
+			if err != nil {
+				PrintUserMessage("Invalid ID. Please provide a number.")
+				logInputError(err, "Interactive mode input error: invalid ID for edit")
 			} else {
-				id, err := strconv.Atoi(splitCommand[1])
+				newTask := joinText(splitCommand[2:]) // Keeps the line breaks of multi-line text.
+				todo, _ := todoList.Get(id)           // Fetched before editing to report the old task.
+				err = todoList.EditTask(id, newTask)
 				if err != nil {
-					PrintUserMessage("Invalid ID. Please provide a number.")
-					LogError(err, "Interactive mode input error: invalid ID for edit")
+					logger.Error(err, fmt.Sprintf("Failed to edit todo with ID %d in interactive mode", id))
+					printError(err)
 				} else {
-					newTask := strings.Join(splitCommand[2:], " ")
-					err = todoList.EditTask(id, newTask)
-					if err != nil {
-						LogError(err, fmt.Sprintf("Failed to edit todo with ID %d in interactive mode", id))
-						PrintUserMessage(err.Error())
-					}
+					oldTask := todo.Task
+					todo.Task = newTask
+					printResult(todo, fmt.Sprintf("✏️ Edited todo #%d. Old task: \"%s\", New task: \"%s\"", id, oldTask, newTask))
+					pushUndo(lastAction{Type: ActionEdit, ID: id, Description: fmt.Sprintf("editing todo #%d", id), PreviousTask: oldTask})
 				}
 			}
-		case "clear-completed":
-			if getConfirmation("Are you sure you want to clear all completed todos?") {
-				todoList.ClearCompleted()
+		}
+	case "clear-completed":
+		clearCompleted(todoList)
+	case "search":
+		words, fuzzy := []string{}, false
+		for _, word := range splitCommand[1:] {
+			if word == "-fuzzy" || word == "--fuzzy" {
+				fuzzy = true
 			} else {
-				PrintUserMessage("Clearing completed todos cancelled.")
+				words = append(words, word)
 			}
-		case "search":
-			if len(splitCommand) < 2 {
-				PrintUserMessage("Usage: search <query>")
-				LogError(fmt.Errorf("missing query for search command"), "Interactive mode input error")
+		}
+		if len(words) == 0 {
+			PrintUserMessage("Usage: search [-fuzzy] <query>")
+			logInputError(fmt.Errorf("missing query for search command"), "Interactive mode input error")
+		} else {
+			printSearchResults(todoList, strings.Join(words, " "), fuzzy)
+		}
+	case "complete":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: complete <id>")
+			logInputError(fmt.Errorf("missing ID for complete command"), "Interactive mode input error")
+		} else {
+			id, err := strconv.Atoi(splitCommand[1])
+			if err != nil {
+				PrintUserMessage("Invalid ID. Please provide a number.")
+				logInputError(err, "Interactive mode input error: invalid ID for complete")
 			} else {
-				query := strings.Join(splitCommand[1:], " ")
-				results := todoList.SearchTasks(query)
-				if len(results.Todos) == 0 {
-					PrintUserMessage(fmt.Sprintf("🔍 No tasks found matching \"%s\".", query))
+				previous, _ := todoList.Get(id)
+				nextID, err := completeTodo(todoList, id)
+				if err != nil {
+					logger.Error(err, fmt.Sprintf("Failed to complete todo with ID %d in interactive mode", id))
+					printError(err)
 				} else {
-					PrintUserMessage(fmt.Sprintf("🔍 Tasks matching \"%s\":", query))
-					results.List(ListOptions{}) // List with default options for search results
+					// Assuming completed status was false before completing.
+					pushUndo(lastAction{Type: ActionComplete, ID: id, Description: fmt.Sprintf("completing todo #%d", id), PreviousCompletedStatus: false, PreviousStatus: previous.Status, NextOccurrenceID: nextID})
 				}
 			}
-		case "complete":
-			if len(splitCommand) < 2 {
-				PrintUserMessage("Usage: complete <id>")
-				LogError(fmt.Errorf("missing ID for complete command"), "Interactive mode input error")
+		}
+	case "uncomplete": // New command for undo functionality
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: uncomplete <id>")
+			logInputError(fmt.Errorf("missing ID for uncomplete command"), "Interactive mode input error")
+		} else {
+			id, err := strconv.Atoi(splitCommand[1])
+			if err != nil {
+				PrintUserMessage("Invalid ID. Please provide a number.")
+				logInputError(err, "Interactive mode input error: invalid ID for uncomplete")
 			} else {
-				id, err := strconv.Atoi(splitCommand[1])
+				err = todoList.Uncomplete(id)
 				if err != nil {
-					PrintUserMessage("Invalid ID. Please provide a number.")
-					LogError(err, "Interactive mode input error: invalid ID for complete")
+					logger.Error(err, fmt.Sprintf("Failed to uncomplete todo with ID %d in interactive mode", id))
+					printError(err)
 				} else {
-					err = todoList.Complete(id)
-					if err != nil {
-						LogError(err, fmt.Sprintf("Failed to complete todo with ID %d in interactive mode", id))
-						PrintUserMessage(err.Error())
-					} else {
-						// Assuming completed status was false before completing.
-						lastActionState = lastAction{Type: ActionComplete, ID: id, PreviousCompletedStatus: false}
-					}
+					printUncompleted(todoList, id)
+					// Assuming completed status was true before uncompleting.
+					pushUndo(lastAction{Type: ActionUncomplete, ID: id, Description: fmt.Sprintf("uncompleting todo #%d", id), PreviousCompletedStatus: true})
 				}
 			}
-		case "uncomplete": // New command for undo functionality
-			if len(splitCommand) < 2 {
-				PrintUserMessage("Usage: uncomplete <id>")
-				LogError(fmt.Errorf("missing ID for uncomplete command"), "Interactive mode input error")
+		}
+	case "start":
+		changeStatus(todoList, splitCommand, StatusInProgress)
+	case "wait":
+		changeStatus(todoList, splitCommand, StatusWaiting)
+	case "block":
+		changeStatus(todoList, splitCommand, StatusBlocked)
+	case "cancel":
+		changeStatus(todoList, splitCommand, StatusCancelled)
+	case "delete":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: delete <id>")
+			logInputError(fmt.Errorf("missing ID for delete command"), "Interactive mode input error")
+		} else {
+			id, err := strconv.Atoi(splitCommand[1])
+			if err != nil {
+				PrintUserMessage("Invalid ID. Please provide a number.")
+				logInputError(err, "Interactive mode input error: invalid ID for delete")
 			} else {
-				id, err := strconv.Atoi(splitCommand[1])
-				if err != nil {
-					PrintUserMessage("Invalid ID. Please provide a number.")
-					LogError(err, "Interactive mode input error: invalid ID for uncomplete")
-				} else {
-					err = todoList.Uncomplete(id)
+				if getConfirmation(deleteConfirmationPrompt(todoList, id)) {
+					positions := todoList.Positions()
+					deletedTodo, deletedSubtasks, err := todoList.MoveToTrash(id, time.Now())
 					if err != nil {
-						LogError(err, fmt.Sprintf("Failed to uncomplete todo with ID %d in interactive mode", id))
-						PrintUserMessage(err.Error())
+						logger.Error(err, fmt.Sprintf("Failed to delete todo with ID %d in interactive mode", id))
+						printError(err)
 					} else {
-						// Assuming completed status was true before uncompleting.
-						lastActionState = lastAction{Type: ActionUncomplete, ID: id, PreviousCompletedStatus: true}
+						printDeleted(deletedTodo, deletedSubtasks)
+						deletedPositions := []int{positions[id]}
+						for _, subtask := range deletedSubtasks {
+							deletedPositions = append(deletedPositions, positions[subtask.ID])
+						}
+						pushUndo(lastAction{Type: ActionDelete, ID: id, Description: fmt.Sprintf("deleting todo #%d", id), DeletedTodo: &deletedTodo, DeletedSubtasks: deletedSubtasks, DeletedPositions: deletedPositions})
 					}
+				} else {
+					PrintUserMessage(fmt.Sprintf("Deletion of todo #%d cancelled.", id))
 				}
 			}
-		case "delete":
-			if len(splitCommand) < 2 {
-				PrintUserMessage("Usage: delete <id>")
-				LogError(fmt.Errorf("missing ID for delete command"), "Interactive mode input error")
+		}
+	case "list":
+		// Parse the same filter and sort flags as the -list flag in single-command mode.
+		options, err := parseListArgs(splitCommand[1:])
+		if err != nil {
+			PrintUserMessage(err.Error())
+			PrintUserMessage("Usage: list [-filter-status <all|completed|incomplete|expired|status>] [-filter-priority <high|medium|low>] [-filter-tags <tag1,tag2>] [-sort-by <field|expr:...>] [-sort-order <asc|desc>] [-ready] [-filter-project <name|none>] [-group-by project] [-include-deferred] [-verbose] [-query <query>] [-created-after <date>] [-created-before <date>] [-due-after <date>] [-due-before <date>] [-no-due-date] [-no-tags] [-no-priority]")
+			logInputError(err, "Interactive mode input error: invalid list options")
+		} else {
+			printTodos(todoList, options)
+		}
+	case "expire":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: expire <id> <date|none>")
+			logInputError(fmt.Errorf("missing ID or date for expire command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for expire")
+			break
+		}
+		var expiresAt *time.Time
+		if date := strings.Join(splitCommand[2:], " "); strings.ToLower(date) != "none" {
+			parsedDate, err := todo.ParseDate(date, time.Now())
+			if err != nil {
+				PrintUserMessage(invalidDateMessage)
+				logInputError(err, "Interactive mode input error: invalid expiry date")
+				break
+			}
+			expiresAt = &parsedDate
+		}
+		if err := todoList.SetExpiry(id, expiresAt); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set expiry of todo with ID %d", id))
+			printError(err)
+		} else {
+			todo, _ := todoList.Get(id)
+			printResult(todo, fmt.Sprintf("⌛ Todo #%d now expires: %s", id, formatOptionalDate(expiresAt)))
+			// Expire it right away if the new date has already passed.
+			reportExpired(todoList.ExpireOverdue(time.Now()))
+		}
+	case "priority":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: priority <id> <high|medium|low>")
+			logInputError(fmt.Errorf("missing ID or priority for priority command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for priority")
+			break
+		}
+		priority := (PriorityLevel(splitCommand[2])).Canonical()
+		if priority == "" {
+			PrintUserMessage("Invalid priority. Use high, medium, or low.")
+			logInputError(fmt.Errorf("invalid priority %q", splitCommand[2]), "Interactive mode input error")
+			break
+		}
+		if wipLimitMode == "block" {
+			if err := checkWIPLimit(todoList, priority, id); err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to set priority of todo with ID %d", id))
+				printError(err)
+				break
+			}
+		}
+		if err := todoList.SetPriority(id, priority); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set priority of todo with ID %d", id))
+			printError(err)
+			break
+		}
+		todo, _ := todoList.Get(id)
+		printResult(todo, fmt.Sprintf("🔺 Todo #%d now has %s priority.", id, priority))
+		warnWIPLimit(todoList, todo)
+	case "estimate":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: estimate <id> <duration|none>")
+			logInputError(fmt.Errorf("missing ID or duration for estimate command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for estimate")
+			break
+		}
+		var estimate time.Duration
+		if strings.ToLower(splitCommand[2]) != "none" {
+			estimate, err = parseEstimate(splitCommand[2])
+			if err != nil {
+				PrintUserMessage("Invalid duration. Use e.g. 45m, 2h, or 1h30m.")
+				logInputError(err, "Interactive mode input error: invalid estimate")
+				break
+			}
+		}
+		if err := todoList.SetEstimate(id, estimate); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set estimate of todo with ID %d", id))
+			printError(err)
+		} else {
+			todo, _ := todoList.Get(id)
+			printResult(todo, fmt.Sprintf("⏱️ Todo #%d is now estimated at %s", id, formatDuration(estimate)))
+		}
+	case "track":
+		trackTime(todoList, splitCommand[1:])
+	case "defer":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: defer <id> <date|none>")
+			logInputError(fmt.Errorf("missing ID or date for defer command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for defer")
+			break
+		}
+		var startDate *time.Time
+		if date := strings.Join(splitCommand[2:], " "); strings.ToLower(date) != "none" {
+			parsedDate, err := todo.ParseDate(date, time.Now())
+			if err != nil {
+				PrintUserMessage(invalidDateMessage)
+				logInputError(err, "Interactive mode input error: invalid start date")
+				break
+			}
+			startDate = &parsedDate
+		}
+		if err := todoList.SetStartDate(id, startDate); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set start date of todo with ID %d", id))
+			printError(err)
+		} else {
+			todo, _ := todoList.Get(id)
+			printResult(todo, fmt.Sprintf("📆 Todo #%d now starts: %s", id, formatOptionalDate(startDate)))
+		}
+	case "snooze":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: snooze <id> [<days>|<date>]")
+			logInputError(fmt.Errorf("missing ID for snooze command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for snooze")
+			break
+		}
+		current, err := todoList.Get(id)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to snooze todo with ID %d", id))
+			printError(err)
+			break
+		}
+		dueDate := current.SnoozeDate(1, time.Now()) // Snooze by one day by default.
+		if len(splitCommand) > 2 {
+			if days, err := strconv.Atoi(splitCommand[2]); err == nil && days > 0 {
+				dueDate = current.SnoozeDate(days, time.Now())
+			} else if dueDate, err = todo.ParseDueDate(strings.Join(splitCommand[2:], " ")); err != nil {
+				PrintUserMessage("Invalid snooze. Give a number of days or a date (e.g., 2024-05-10, friday, or next week).")
+				logInputError(err, "Interactive mode input error: invalid snooze")
+				break
+			}
+		}
+		snoozed, _ := todoList.SnoozeUntil(id, dueDate, time.Now())
+		printSnoozed(snoozed)
+	case "config":
+		manageConfig(splitCommand[1:])
+	case "init":
+		initProjectCommand()
+	case "profile":
+		manageProfiles(splitCommand[1:])
+	case "diff":
+		if len(splitCommand) < 2 || len(splitCommand) > 3 {
+			PrintUserMessage("Usage: diff <file> [<file>]")
+			logInputError(fmt.Errorf("invalid arguments for diff command"), "Interactive mode input error")
+			break
+		}
+		to := ""
+		if len(splitCommand) == 3 {
+			to = splitCommand[2]
+		}
+		diff, err := DiffSnapshots(todoList, splitCommand[1], to)
+		if err != nil {
+			logger.Error(err, "Failed to compare todo lists")
+			printError(err)
+			break
+		}
+		printSnapshotDiff(diff)
+	case "overdue":
+		printOverdue(todoList.Overdue(time.Now()), time.Now())
+	case "agenda":
+		printAgenda(todoList.Agenda(time.Now()))
+	case "email-digest":
+		digest, sent, err := sendDigest(todoList, smtpSettings, time.Now(), smtp.SendMail)
+		if err != nil {
+			logger.Error(err, "Failed to send the email digest")
+			printError(err)
+			break
+		}
+		printDigest(digest, sent, smtpSettings.To)
+	case "dashboard":
+		printDashboard(Dashboard(todoList, activeDataFile, dashboardLists, time.Now()))
+	case "stats":
+		printStats(todoList.Stats(time.Now(), chronicSnoozeThreshold))
+	case "depend", "undepend":
+		if len(splitCommand) < 3 {
+			PrintUserMessage(fmt.Sprintf("Usage: %s <id> <dependency_id1,dependency_id2>", subCommand))
+			logInputError(fmt.Errorf("missing ID or dependencies for %s command", subCommand), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, fmt.Sprintf("Interactive mode input error: invalid ID for %s", subCommand))
+			break
+		}
+		dependencyIDs, err := parseTodoIDs(strings.Join(splitCommand[2:], ""))
+		if err != nil {
+			PrintUserMessage(err.Error())
+			logInputError(err, fmt.Sprintf("Interactive mode input error: invalid dependencies for %s", subCommand))
+			break
+		}
+		for _, dependencyID := range dependencyIDs {
+			if subCommand == "depend" {
+				err = todoList.AddDependency(id, dependencyID)
+			} else {
+				err = todoList.RemoveDependency(id, dependencyID)
+			}
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to update the dependencies of todo with ID %d", id))
+				printError(err)
+				break
+			}
+		}
+		if err == nil {
+			todo, _ := todoList.Get(id)
+			printResult(todo, fmt.Sprintf("🔗 Todo #%d now depends on: [%s]", id, formatTodoIDs(todo.DependsOn)))
+		}
+	case "project":
+		manageProjects(todoList, splitCommand[1:])
+	case "move":
+		moveTodo(todoList, splitCommand[1:])
+	case "status":
+		printSaveStatus(activeDataFile)
+	case "trash":
+		manageTrash(todoList, splitCommand[1:])
+	case "restore":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: restore <id>")
+			logInputError(fmt.Errorf("missing ID for restore command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for restore")
+			break
+		}
+		if todoList.InTrash(id) && !getWordConfirmation(fmt.Sprintf("Are you sure you want to restore todo #%d from the trash?", id)) {
+			PrintUserMessage("Restoring the todo cancelled.")
+			break
+		}
+		restoredTodo, restoredSubtasks, err := todoList.Restore(id)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to restore todo with ID %d", id))
+			printError(err)
+			break
+		}
+		printRestored(restoredTodo, restoredSubtasks)
+	case "recur":
+		recurTodo(todoList, splitCommand[1:])
+	case "import":
+		if len(splitCommand) < 3 || strings.ToLower(splitCommand[1]) != "markdown" {
+			PrintUserMessage("Usage: import markdown <file.md>")
+			logInputError(fmt.Errorf("missing format or file for import command"), "Interactive mode input error")
+			break
+		}
+		importMarkdownFile(todoList, strings.Join(splitCommand[2:], " "))
+	case "history":
+		showHistory(splitCommand[1:])
+	case "plan":
+		day, format, err := parsePlanArgs(splitCommand[1:], time.Now())
+		if err != nil {
+			PrintUserMessage(err.Error())
+			PrintUserMessage("Usage: plan [today|tomorrow|<date>] [-format <text|markdown>]")
+			logInputError(err, "Interactive mode input error: invalid plan options")
+			break
+		}
+		printPlan(planDay(todoList, day), format)
+	case "attach":
+		if len(splitCommand) < 3 {
+			PrintUserMessage("Usage: attach <id> <path|url>")
+			logInputError(fmt.Errorf("missing ID or path for attach command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for attach")
+			break
+		}
+		target := strings.Join(splitCommand[2:], " ")
+		if isURL(target) {
+			if err := addLink(todoList, id, target); err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to attach link to todo with ID %d", id))
+				printError(err)
+				break
+			}
+			printAttached(id, Attachment{Name: target, URL: target})
+			break
+		}
+		attachment, err := attachments.Attach(todoList, id, target)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to attach file to todo with ID %d", id))
+			printError(err)
+			break
+		}
+		printAttached(id, attachment)
+	case "attachments":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: attachments <id>")
+			logInputError(fmt.Errorf("missing ID for attachments command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for attachments")
+			break
+		}
+		files, err := attachments.List(todoList, id)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to list attachments of todo with ID %d", id))
+			printError(err)
+			break
+		}
+		todo, _ := todoList.Get(id)
+		printAttachments(id, append(linkAttachments(todo), files...))
+	case "promote":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: promote <id>[.n]")
+			logInputError(fmt.Errorf("missing ID for promote command"), "Interactive mode input error")
+			break
+		}
+		id, err := todoList.ResolveSubtaskRef(splitCommand[1])
+		if err == nil {
+			_, err = todoList.Promote(id)
+		}
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to promote %s", splitCommand[1]))
+			printError(err)
+			break
+		}
+		todo, _ := todoList.Get(id)
+		printResult(todo, fmt.Sprintf("⬆️ Promoted todo #%d to a top-level todo (was a subtask of #%d).", id, todo.PromotedFrom))
+	case "split":
+		splitCommandTodo(todoList, splitCommand[1:])
+	case "show":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: show <id>")
+			logInputError(fmt.Errorf("missing ID for show command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for show")
+			break
+		}
+		todo, err := todoList.Get(id)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to show todo with ID %d", id))
+			printError(err)
+			break
+		}
+		printTodoDetails(todo)
+	case "open":
+		if len(splitCommand) < 2 {
+			PrintUserMessage("Usage: open <id>")
+			logInputError(fmt.Errorf("missing ID for open command"), "Interactive mode input error")
+			break
+		}
+		id, err := strconv.Atoi(splitCommand[1])
+		if err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for open")
+			break
+		}
+		target, err := attachments.openTarget(todoList, id)
+		if err == nil {
+			err = openWithSystem(target)
+		}
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to open the first link of todo with ID %d", id))
+			printError(err)
+			break
+		}
+		PrintUserMessage(fmt.Sprintf("🌐 Opened %s", target))
+	case "select":
+		selectTodos(todoList, splitCommand[1:])
+	case "/":
+		searchAndAct(todoList, strings.Join(splitCommand[1:], " "))
+	case "help":
+		// Print available commands for interactive mode.
+		PrintUserMessage("✨ Commands:")
+		PrintUserMessage("  ➕ add <task> [-p <high|medium|low>] [-d <date>] [-e <date>] [-t <tag1,tag2>] [-parent <id>] [-r <rule>] [-b <id1,id2>] [-project <name>] [-est <duration>]")
+		PrintUserMessage("                                                                    - Add a new todo task (-e sets an expiry date, -s defers it until a start date, -parent makes it a subtask, -r makes it repeat, -b marks it blocked by other todos)")
+		PrintUserMessage("  ✏️ edit <id> <new_task>                                            - Edit a task description")
+		PrintUserMessage("  📄 clone <id> [-d <date|none>]                                    - Add a copy of a todo, optionally with another due date")
+		PrintUserMessage("  🧹 clear-completed                                                 - Remove all completed todos")
+		PrintUserMessage("  🔍 search [-fuzzy] <query>                                         - Search tasks by description, tags, or subtasks (-fuzzy tolerates typos)")
+		PrintUserMessage("  🔎 / [query]                                                       - Live search as you type, then act on the chosen todo")
+		PrintUserMessage("  🔄 uncomplete <id>                                                - Mark a todo as incomplete by ID")
+		PrintUserMessage("  ▶️ start <id> | wait <id> | block <id> | cancel <id>               - Mark a todo as in progress, waiting, blocked, or cancelled")
+		PrintUserMessage("  ↩️ undo                                                             - Undo the last change; repeat to undo earlier ones")
+		PrintUserMessage("  🧾 begin | commit | rollback                                        - Group changes into a transaction that is saved at once, or discarded")
+		PrintUserMessage("  ✅ complete <id>                                                  - Mark a todo as complete by ID")
+		PrintUserMessage("  🗑️ delete <id>                                                    - Delete a todo by ID (it is kept in the trash)")
+		PrintUserMessage("  🗑️ trash [list|empty]                                              - List deleted todos, or delete them permanently")
+		PrintUserMessage("  ♻️ restore <id>                                                     - Restore a deleted todo from the trash")
+		PrintUserMessage("  ⌛ expire <id> <date|none>                                        - Set or clear the date after which an open todo expires")
+		PrintUserMessage("  🔺 priority <id> <high|medium|low>                                  - Change the priority of a todo")
+		PrintUserMessage("  ↕️ move <id> <up|down|top|bottom|after <id>>                        - Change the manual order of a todo among its siblings (see -sort-by order)")
+		PrintUserMessage("  ⏱️ estimate <id> <duration|none>                                   - Set or clear the time estimate of a todo (e.g., 1h30m)")
+		PrintUserMessage("  ⏱️ track start <id> | track stop <id> | track report [project|tag]")
+		PrintUserMessage("                                                                    - Track the time spent on a todo, or compare estimates to actual time")
+		PrintUserMessage("  📆 defer <id> <date|none>                                         - Hide a todo from the default list until a start date")
+		PrintUserMessage("  📎 attach <id> <path|url>                                           - Attach a link, or a copy of a small local file, to a todo")
+		PrintUserMessage("  🗂️ attachments <id>                                                 - List the links and files attached to a todo")
+		PrintUserMessage("  ⬆️ promote <id>[.n]                                                  - Turn a subtask (or the nth subtask of a todo) into a top-level todo")
+		PrintUserMessage("  ✂️ split <id> [-editor] [-distribute]                              - Split a todo into subtasks, entered line by line or in $EDITOR")
+		PrintUserMessage("  🔎 show <id>                                                        - Show a todo with its full task text and links")
+		PrintUserMessage("  🌐 open <id>                                                        - Open the first link (or attached file) of a todo")
+		PrintUserMessage("  😴 snooze <id> [<days>|<date>]                                    - Postpone a todo's due date (by one day by default)")
+		PrintUserMessage("  📊 stats                                                           - Show counts of todos and chronically snoozed todos")
+		PrintUserMessage("  🔍 diff <file> [<file>]                                            - Show the changes between two saved lists, or a saved list and this one")
+		PrintUserMessage("  ⚙️ config get <key> | config set <key> <value>                     - Show or change a setting of the config file (e.g., smtp.host)")
+		PrintUserMessage("  ⚙️ reload                                                          - Read the config file again, applying changed settings without restarting")
+		PrintUserMessage("  📁 init                                                            - Set up a separate todo list for the project in this directory (.todo)")
+		PrintUserMessage("  👤 profile [list] | profile use <name|none>                        - List the profiles of the config, or switch to another one")
+		PrintUserMessage("  ⏰ overdue                                                         - List the overdue todos, the latest first")
+		PrintUserMessage("  📆 agenda                                                          - Show the open todos by when they are due: overdue, today, tomorrow, this week, later")
+		PrintUserMessage("  📧 email-digest                                                    - Email the overdue, due today, and due this week todos (smtp config)")
+		PrintUserMessage("  🧭 dashboard                                                       - Show open, due, and overdue counts and the most urgent todo of each list")
+		PrintUserMessage("  📜 history [-n <count>] [-id <id>] [-user <name>]                   - Show the commands run on this list, also in past sessions, and the todos they changed")
+		PrintUserMessage("  💾 status                                                          - Show whether the last save of the data file succeeded")
+		PrintUserMessage("  🔗 depend <id> <id1,id2> / undepend <id> <id1,id2>                  - Add or remove todos that must be completed first")
+		PrintUserMessage("  📁 project list | project rename <old> <new> | project <id> <name|none>")
+		PrintUserMessage("                                                                    - Show project progress, rename a project, or move a todo to a project")
+		PrintUserMessage("  🔁 recur <id> [<rule|none>] [-from <schedule|completion|default>] [-overdue <pile-up|collapse|default>]")
+		PrintUserMessage("                                                                    - Repeat a todo (daily, weekly, monthly, every 2 weeks, every monday, ...), and set how it recurs when completed late")
+		PrintUserMessage("  📥 import markdown <file.md>                                        - Import - [ ] / - [x] checklist items as todos")
+		PrintUserMessage("  🗓️ plan [today|tomorrow|<date>] [-format <text|markdown>]          - Print a daily plan sheet of due, top-priority, and quick-win todos")
+		PrintUserMessage("  ☑️ select [complete|uncomplete|delete|retag <tag1,tag2>] [-ids <id1,id2>]")
+		PrintUserMessage("                                                                    - Pick several todos (or give their IDs) and apply an action to all of them")
+		PrintUserMessage("  📋 list [-filter-status <s>] [-filter-priority <p>] [-filter-tags <t1,t2>] [-sort-by <field>] [-sort-order <asc|desc>] [-ready]")
+		PrintUserMessage("       [-filter-project <name|none>] [-group-by project] [-include-deferred] [-verbose] [-query <query>]")
+		PrintUserMessage("       [-created-after <date>] [-created-before <date>] [-due-after <date>] [-due-before <date>] [-no-due-date] [-no-tags] [-no-priority]")
+		PrintUserMessage("                                                                    - List todos, optionally filtered and sorted")
+		PrintUserMessage("  📅 Dates are YYYY-MM-DD, words (today, tomorrow, friday, next week, in 3 days, end of month), or offsets (+3d, +2w, +1m, eow, eom); due dates may end with a time (e.g., friday 14:00)")
+		PrintUserMessage("  🚪 exit                                                           - Exit interactive mode")
+	case "exit":
+		PrintUserMessage("👋 Exiting interactive mode.")
+		return false // Exit the interactive loop.
+	case "undo": // New undo command
+		action, ok := popUndo()
+		if !ok {
+			PrintUserMessage("🤔 No action to undo.")
+			break
+		}
+		switch action.Type {
+		case ActionAdd:
+			deletedTodo, err := todoList.Delete(action.ID) // Undo add is a delete
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to undo add for todo ID %d", action.ID))
+				PrintUserMessage("❌ Undo failed: " + err.Error())
 			} else {
-				id, err := strconv.Atoi(splitCommand[1])
-				if err != nil {
-					PrintUserMessage("Invalid ID. Please provide a number.")
-					LogError(err, "Interactive mode input error: invalid ID for delete")
-				} else {
-					if getConfirmation("Are you sure you want to delete todo with ID " + strconv.Itoa(id) + "?") {
-						deletedTodo, err := todoList.Delete(id)
-						if err != nil {
-							LogError(err, fmt.Sprintf("Failed to delete todo with ID %d in interactive mode", id))
-							PrintUserMessage(err.Error())
-						} else {
-							lastActionState = lastAction{Type: ActionDelete, ID: id, DeletedTodo: &deletedTodo}
-						}
-					} else {
-						PrintUserMessage(fmt.Sprintf("Deletion of todo #%d cancelled.", id))
-					}
-				}
+				PrintUserMessage(fmt.Sprintf("↩️ Undid adding todo #%d (task: \"%s\").", action.ID, deletedTodo.Task))
 			}
-		case "list":
-			// For enhanced list, we'll need to parse additional flags here in interactive mode
-			// For now, just call simple list.
-			todoList.List(ListOptions{}) // Display all current todos with default options for now.
-		case "help":
-			// Print available commands for interactive mode.
-			PrintUserMessage("✨ Commands:")
-			PrintUserMessage("  ➕ add <task> [-p <high|medium|low>] [-d <YYYY-MM-DD>] [-t <tag1,tag2>]  - Add a new todo task")
-			PrintUserMessage("  ✏️ edit <id> <new_task>                                            - Edit a task description")
-			PrintUserMessage("  🧹 clear-completed                                                 - Remove all completed todos")
-			PrintUserMessage("  🔍 search <query>                                                  - Search tasks by description or tags")
-			PrintUserMessage("  🔄 uncomplete <id>                                                - Mark a todo as incomplete by ID")
-			PrintUserMessage("  ↩️ undo                                                             - Undo the last action")
-			PrintUserMessage("  ✅ complete <id>                                                  - Mark a todo as complete by ID")
-			PrintUserMessage("  🗑️ delete <id>                                                    - Delete a todo by ID")
-			PrintUserMessage("  📋 list                                                           - List all todos")
-			PrintUserMessage("  🚪 exit                                                           - Exit interactive mode")
-		case "exit":
-			PrintUserMessage("👋 Exiting interactive mode.")
-			return // Exit the interactive loop.
-		case "undo": // New undo command
-			switch lastActionState.Type {
-			case ActionAdd:
-				deletedTodo, err := todoList.Delete(lastActionState.ID) // Undo add is a delete
-				if err != nil {
-					LogError(err, fmt.Sprintf("Failed to undo add for todo ID %d", lastActionState.ID))
-					PrintUserMessage("❌ Undo failed: " + err.Error())
-				} else {
-					PrintUserMessage(fmt.Sprintf("↩️ Undid adding todo #%d (task: \"%s\").", lastActionState.ID, deletedTodo.Task))
-				}
-			case ActionComplete:
-				err := todoList.Uncomplete(lastActionState.ID)
-				if err != nil {
-					LogError(err, fmt.Sprintf("Failed to undo complete for todo ID %d", lastActionState.ID))
-					PrintUserMessage("❌ Undo failed: " + err.Error())
-				} else {
-					PrintUserMessage(fmt.Sprintf("↩️ Undid completing todo #%d.", lastActionState.ID))
+		case ActionComplete:
+			err := todoList.Uncomplete(action.ID)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to undo complete for todo ID %d", action.ID))
+				PrintUserMessage("❌ Undo failed: " + err.Error())
+			} else {
+				if action.PreviousStatus != "" {
+					todoList.SetStatus(action.ID, action.PreviousStatus) // E.g., back to in-progress.
 				}
-			case ActionDelete:
-				if lastActionState.DeletedTodo != nil {
-					// To undo delete, we re-add the todo with its original state.
-					// Note: This will assign a *new* ID if NextID has advanced. For true undo, we'd need to re-insert at original ID.
-					// For basic undo, re-adding is sufficient.
-					todoList.Todos = append(todoList.Todos, *lastActionState.DeletedTodo)
-					PrintUserMessage(fmt.Sprintf("↩️ Undid deleting todo #%d (re-added as #%d: \"%s\").", lastActionState.ID, lastActionState.DeletedTodo.ID, lastActionState.DeletedTodo.Task))
-				} else {
-					PrintUserMessage("❌ Cannot undo delete: no todo data stored.")
-					LogError(fmt.Errorf("attempted to undo delete without stored todo data"), "Undo error")
+				PrintUserMessage(fmt.Sprintf("↩️ Undid completing todo #%d.", action.ID))
+				if action.NextOccurrenceID != 0 {
+					// Remove the next occurrence that completing the recurring todo added.
+					todoList.Delete(action.NextOccurrenceID)
 				}
-			case ActionUncomplete:
-				err := todoList.Complete(lastActionState.ID)
-				if err != nil {
-					LogError(err, fmt.Sprintf("Failed to undo uncomplete for todo ID %d", lastActionState.ID))
-					PrintUserMessage("❌ Undo failed: " + err.Error())
+			}
+		case ActionDelete:
+			if action.DeletedTodo != nil {
+				// To undo delete, we re-insert the todo and its subtasks with their original state,
+				// IDs, and positions, so that references to them (e.g., dependencies) stay valid.
+				restored := append([]Todo{*action.DeletedTodo}, action.DeletedSubtasks...)
+				if len(action.DeletedPositions) == len(restored) {
+					todoList.InsertAt(restored, action.DeletedPositions)
 				} else {
-					PrintUserMessage(fmt.Sprintf("↩️ Undid uncompleting todo #%d.", lastActionState.ID))
+					todoList.Todos = append(todoList.Todos, restored...) // Recorded without positions.
 				}
-			case ActionNone:
-				PrintUserMessage("🤔 No action to undo.")
-			}
-			lastActionState.Type = ActionNone // Clear the last action after undo
-			// Note: clearing ID and DeletedTodo might also be good here depending on desired robustness.
-			lastActionState.ID = 0
-			lastActionState.DeletedTodo = nil
-			lastActionState.PreviousCompletedStatus = false
+				todoList.RemoveFromTrash(action.DeletedTodo.ID)
+				PrintUserMessage(fmt.Sprintf("↩️ Undid deleting todo #%d: \"%s\".", action.ID, action.DeletedTodo.Task))
+			} else {
+				PrintUserMessage("❌ Cannot undo delete: no todo data stored.")
+				logger.Error(fmt.Errorf("attempted to undo delete without stored todo data"), "Undo error")
+			}
+		case ActionUncomplete:
+			err := todoList.Complete(action.ID)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to undo uncomplete for todo ID %d", action.ID))
+				PrintUserMessage("❌ Undo failed: " + err.Error())
+			} else {
+				PrintUserMessage(fmt.Sprintf("↩️ Undid uncompleting todo #%d.", action.ID))
+			}
+		case ActionStatus:
+			err := todoList.SetStatus(action.ID, action.PreviousStatus)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to undo status change for todo ID %d", action.ID))
+				PrintUserMessage("❌ Undo failed: " + err.Error())
+			} else {
+				PrintUserMessage(fmt.Sprintf("↩️ Undid changing the status of todo #%d (back to %s).", action.ID, action.PreviousStatus))
+			}
+		case ActionEdit:
+			err := todoList.EditTask(action.ID, action.PreviousTask)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to undo edit for todo ID %d", action.ID))
+				PrintUserMessage("❌ Undo failed: " + err.Error())
+			} else {
+				PrintUserMessage(fmt.Sprintf("↩️ Undid editing todo #%d (task: \"%s\").", action.ID, action.PreviousTask))
+			}
+		case ActionClearCompleted:
+			todoList.InsertAt(action.ClearedTodos, action.ClearedPositions)
+			PrintUserMessage(fmt.Sprintf("↩️ Undid clearing %d completed todos.", len(action.ClearedTodos)))
+		case ActionChange:
+			if action.Change == nil {
+				PrintUserMessage("❌ Cannot undo " + action.Description + ": no changes stored.")
+				logger.Error(fmt.Errorf("attempted to undo a change without stored changes"), "Undo error")
+				break
+			}
+			action.Change.undo(todoList)
+			PrintUserMessage(fmt.Sprintf("↩️ Undid %s.", action.Description))
+		}
+		if len(undoStack) > 0 {
+			PrintUserMessage(fmt.Sprintf("   %d more to undo, next: %s.", len(undoStack), undoStack[len(undoStack)-1].Description))
+		}
+	default:
+		PrintUserMessage("❓ Unknown command. Type 'help' for a list of commands.")
+		logInputError(fmt.Errorf("unknown command: %s", subCommand), "Interactive mode input error")
+	}
+	return true
+}
+
+// importMarkdownFile imports the checklist items of a Markdown file as todos.
+func importMarkdownFile(todoList *TodoList, filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to open markdown file %s", filename))
+		printError(err)
+		return
+	}
+	defer file.Close()
+
+	imported, err := todoList.ImportMarkdown(file)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to import markdown file %s", filename))
+		printError(err)
+	}
+	printBulkAdded(imported)
+}
+
+// selectTodos lets the user pick several todos and applies one action to all of them.
+// The action (complete, uncomplete, delete, or retag <tag1,tag2>) can be given as
+// arguments; otherwise the user is asked for it after picking.
+func selectTodos(todoList *TodoList, args []string) {
+	if len(todoList.Todos) == 0 {
+		PrintUserMessage("✨ No todos to select.")
+		return
+	}
+
+	args, ids, err := parseSelectIDs(todoList, args)
+	if err != nil {
+		PrintUserMessage(err.Error())
+		PrintUserMessage("Usage: select [complete|uncomplete|delete|retag <tag1,tag2>] [-ids <id1,id2>]")
+		logInputError(err, "Interactive mode input error: invalid select IDs")
+		return
+	}
+	if ids == nil {
+		ids, err = getConsole().pickTodos(todoList.Todos)
+		if err != nil {
+			logger.Error(err, "Failed to read todo selection")
+		}
+	}
+	if len(ids) == 0 {
+		PrintUserMessage("No todos selected.")
+		return
+	}
+
+	if len(args) == 0 {
+		input, _ := getConsole().Ask(fmt.Sprintf("Apply to %d todos (complete, uncomplete, delete, retag <tag1,tag2>): ", len(ids)))
+		args = strings.Fields(input)
+	}
+	if len(args) == 0 {
+		PrintUserMessage("Selection cancelled.")
+		return
+	}
+
+	switch action := strings.ToLower(args[0]); action {
+	case "complete", "uncomplete":
+		for _, id := range ids {
+			if action == "complete" {
+				_, err = completeTodo(todoList, id)
+			} else if err = todoList.Uncomplete(id); err == nil {
+				printUncompleted(todoList, id)
+			}
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to %s todo with ID %d", action, id))
+				printError(err)
+			}
+		}
+	case "delete":
+		if !getConfirmation(fmt.Sprintf("Are you sure you want to delete %d todos?", len(ids))) {
+			PrintUserMessage("Deletion of selected todos cancelled.")
+			return
+		}
+		for _, id := range ids {
+			if _, err := todoList.Get(id); err != nil {
+				continue // Already deleted as a subtask of another selected todo.
+			}
+			deletedTodo, deletedSubtasks, err := todoList.MoveToTrash(id, time.Now())
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to delete todo with ID %d", id))
+				printError(err)
+			} else {
+				printDeleted(deletedTodo, deletedSubtasks)
+			}
+		}
+	case "retag":
+		tags := []string{}
+		if len(args) > 1 {
+			tags = strings.Split(args[1], ",")
+		}
+		for _, id := range ids {
+			if err := todoList.SetTags(id, tags); err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to retag todo with ID %d", id))
+				printError(err)
+				continue
+			}
+			todo, _ := todoList.Get(id)
+			printResult(todo, fmt.Sprintf("🏷️ Retagged todo #%d: [%s]", id, strings.Join(tags, ", ")))
+		}
+	default:
+		PrintUserMessage("Usage: select [complete|uncomplete|delete|retag <tag1,tag2>] [-ids <id1,id2>]")
+		logInputError(fmt.Errorf("unknown select action: %s", action), "Interactive mode input error")
+	}
+}
+
+// manageProjects runs the project command: "project list" shows the progress of every project,
+// "project rename <old> <new>" renames a project, and "project <id> <name|none>" moves a todo to a project.
+func manageProjects(todoList *TodoList, args []string) {
+	usage := "Usage: project list | project rename <old> <new> | project <id> <name|none>"
+	if len(args) == 0 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("missing arguments for project command"), "Interactive mode input error")
+		return
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		printProjects(todoList.Projects())
+	case "rename":
+		if len(args) != 3 {
+			PrintUserMessage(usage)
+			logInputError(fmt.Errorf("project rename needs the old and new project names"), "Interactive mode input error")
+			return
+		}
+		renamed, err := todoList.RenameProject(args[1], args[2])
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to rename project %q", args[1]))
+			printError(err)
+			return
+		}
+		printResult(map[string]any{"old_name": args[1], "new_name": args[2], "todos": renamed},
+			fmt.Sprintf("📁 Renamed project %q to %q (%d todos).", args[1], args[2], renamed))
+	default:
+		id, err := strconv.Atoi(args[0])
+		if err != nil || len(args) != 2 {
+			PrintUserMessage(usage)
+			logInputError(fmt.Errorf("invalid project command: %s", strings.Join(args, " ")), "Interactive mode input error")
+			return
+		}
+		project := args[1]
+		if strings.ToLower(project) == "none" {
+			project = ""
+		}
+		if err := todoList.SetProject(id, project); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set the project of todo with ID %d", id))
+			printError(err)
+			return
+		}
+		todo, _ := todoList.Get(id)
+		if project == "" {
+			printResult(todo, fmt.Sprintf("📁 Todo #%d is no longer in a project.", id))
+		} else {
+			printResult(todo, fmt.Sprintf("📁 Moved todo #%d to project %q.", id, project))
+		}
+	}
+}
+
+// cloneTodoCommand runs the clone command: "clone <id> [-d <date|none>]" adds a copy of a todo,
+// with the due date given by -d instead of the original's, if any.
+func cloneTodoCommand(todoList *TodoList, args []string) {
+	usage := "Usage: clone <id> [-d <date|none>]"
+	if len(args) != 1 && (len(args) < 3 || args[1] != "-d") {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("invalid arguments for clone command: %s", strings.Join(args, " ")), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, "Interactive mode input error: invalid ID for clone")
+		return
+	}
+	original, err := todoList.Get(id)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to clone todo with ID %d", id))
+		printError(err)
+		return
+	}
+	var dueDate *time.Time
+	if len(args) >= 3 {
+		if date := strings.Join(args[2:], " "); strings.ToLower(date) != "none" {
+			parsedDate, err := todo.ParseDueDate(date)
+			if err != nil {
+				PrintUserMessage(invalidDateMessage)
+				logInputError(err, "Interactive mode input error: invalid due date for clone")
+				return
+			}
+			dueDate = &parsedDate
+		}
+	}
+	if wipLimitMode == "block" {
+		if err := checkWIPLimit(todoList, original.Priority, 0); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to clone todo with ID %d", id))
+			printError(err)
+			return
+		}
+	}
+
+	todo, _ := todoList.Duplicate(id)
+	if len(args) == 3 {
+		todoList.SetDueDate(todo.ID, dueDate)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	printResult(todo, fmt.Sprintf("📄 Cloned todo #%d as #%d: \"%s\" (Due: %s)", id, todo.ID, todo.Task, formatOptionalDate(todo.DueDate)))
+	pushUndo(lastAction{Type: ActionAdd, ID: todo.ID, Description: fmt.Sprintf("cloning todo #%d as #%d", id, todo.ID)}) // Undo removes the copy.
+	warnWIPLimit(todoList, todo)
+}
+
+// moveTodo runs the move command: "move <id> up|down|top|bottom" or "move <id> after <other_id>".
+func moveTodo(todoList *TodoList, args []string) {
+	usage := "Usage: move <id> <up|down|top|bottom|after <id>>"
+	if len(args) < 2 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("missing ID or position for move command"), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, "Interactive mode input error: invalid ID for move")
+		return
+	}
+	position := strings.ToLower(args[1])
+	afterID := 0
+	if position == MoveAfter {
+		if len(args) < 3 {
+			PrintUserMessage(usage)
+			logInputError(fmt.Errorf("missing ID to move todo %d after", id), "Interactive mode input error")
+			return
+		}
+		if afterID, err = strconv.Atoi(args[2]); err != nil {
+			PrintUserMessage("Invalid ID. Please provide a number.")
+			logInputError(err, "Interactive mode input error: invalid ID for move after")
+			return
+		}
+	}
+	if err := todoList.Move(id, position, afterID); err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to move todo with ID %d", id))
+		printError(err)
+		return
+	}
+	todo, _ := todoList.Get(id)
+	printResult(todo, fmt.Sprintf("↕️ Moved todo #%d %s.", id, strings.Join(args[1:], " ")))
+}
+
+// manageTrash runs the trash command: "trash" or "trash list" shows the deleted todos,
+// and "trash empty" deletes them permanently once the user confirms with the confirmation word.
+func manageTrash(todoList *TodoList, args []string) {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		printTrash(todoList.Trash, time.Now())
+		return
+	}
+	if strings.ToLower(args[0]) != "empty" {
+		PrintUserMessage("Usage: trash [list|empty]")
+		logInputError(fmt.Errorf("invalid trash command: %s", strings.Join(args, " ")), "Interactive mode input error")
+		return
+	}
+	if len(todoList.Trash) == 0 {
+		printTrashEmptied([]TrashedTodo{}) // Nothing to confirm.
+		return
+	}
+	if getWordConfirmation(fmt.Sprintf("Are you sure you want to permanently delete the %d todos in the trash? This cannot be undone.", len(todoList.Trash))) {
+		printTrashEmptied(todoList.PurgeTrash(time.Now(), -1))
+	} else {
+		PrintUserMessage("Emptying the trash cancelled.")
+	}
+}
+
+// splitCommandTodo runs the split command: "split <id> [-editor] [-distribute]" asks for subtasks
+// line by line (or in the user's editor with -editor) and adds them to the todo, dividing its
+// estimate among them with -distribute.
+func splitCommandTodo(todoList *TodoList, args []string) {
+	usage := "Usage: split <id> [-editor] [-distribute]"
+	useEditor, distribute := false, false
+	positional := []string{}
+	for _, arg := range args {
+		switch strings.TrimLeft(arg, "-") {
+		case "editor":
+			useEditor = true
+		case "distribute":
+			distribute = true
 		default:
-			PrintUserMessage("❓ Unknown command. Type 'help' for a list of commands.")
-			LogError(fmt.Errorf("unknown command: %s", subCommand), "Interactive mode input error")
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 1 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("invalid split command: %s", strings.Join(args, " ")), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, "Interactive mode input error: invalid ID for split")
+		return
+	}
+	todo, err := todoList.Get(id)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to split todo with ID %d", id))
+		printError(err)
+		return
+	}
+
+	var lines []string
+	if useEditor {
+		lines, err = editSubtaskLines(todo)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to edit the subtasks of todo with ID %d", id))
+			printError(err)
+			return
+		}
+	} else {
+		lines = askSubtaskLines()
+	}
+	subtasks, err := SplitTodo(todoList, id, lines, distribute)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to split todo with ID %d", id))
+		printError(err)
+		return
+	}
+	printSplit(todo, subtasks)
+}
+
+// parseEstimate parses a positive time estimate such as "45m", "2h", or "1h30m".
+func parseEstimate(value string) (time.Duration, error) {
+	estimate, err := time.ParseDuration(value)
+	if err != nil || estimate <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q: use a duration such as 45m, 2h, or 1h30m", value)
+	}
+	return estimate, nil
+}
+
+// trackTime runs the track command: "track start <id>" and "track stop <id>" measure the time spent
+// on a todo, and "track report [project|tag]" compares estimates to the time spent.
+func trackTime(todoList *TodoList, args []string) {
+	usage := "Usage: track start <id> | track stop <id> | track report [project|tag]"
+	if len(args) == 0 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("missing arguments for track command"), "Interactive mode input error")
+		return
+	}
+	action := strings.ToLower(args[0])
+	if action == "report" {
+		by := "project"
+		if len(args) > 1 {
+			by = strings.ToLower(args[1])
+		}
+		report, err := todoList.TimeReport(by, time.Now())
+		if err != nil {
+			logger.Error(err, "Failed to build the time report")
+			printError(err)
+			return
+		}
+		printTimeReport(report, by)
+		return
+	}
+	if (action != "start" && action != "stop") || len(args) != 2 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("invalid track command: %s", strings.Join(args, " ")), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, fmt.Sprintf("Interactive mode input error: invalid ID for track %s", action))
+		return
+	}
+
+	if action == "start" {
+		if err := todoList.StartTracking(id, time.Now()); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to start tracking todo with ID %d", id))
+			printError(err)
+			return
+		}
+		todo, _ := todoList.Get(id)
+		printResult(todo, fmt.Sprintf("⏱️ Tracking time on todo #%d: \"%s\"", id, todo.Task))
+		return
+	}
+	tracked, err := todoList.StopTracking(id, time.Now())
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to stop tracking todo with ID %d", id))
+		printError(err)
+		return
+	}
+	todo, _ := todoList.Get(id)
+	printResult(todo, fmt.Sprintf("⏹️ Tracked %s on todo #%d (%s in total).", formatDuration(tracked), id, formatDuration(time.Duration(todo.TimeSpent))))
+}
+
+// changeStatus runs a status command (e.g., "start 3"), setting the status of the todo with the given ID.
+func changeStatus(todoList *TodoList, splitCommand []string, status TodoStatus) {
+	if len(splitCommand) < 2 {
+		PrintUserMessage(fmt.Sprintf("Usage: %s <id>", splitCommand[0]))
+		logInputError(fmt.Errorf("missing ID for %s command", splitCommand[0]), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(splitCommand[1])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, fmt.Sprintf("Interactive mode input error: invalid ID for %s", splitCommand[0]))
+		return
+	}
+	previous, _ := todoList.Get(id)
+	if err := todoList.SetStatus(id, status); err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to set the status of todo with ID %d to %s", id, status))
+		printError(err)
+		return
+	}
+	printStatusChanged(todoList, id)
+	pushUndo(lastAction{Type: ActionStatus, ID: id, Description: fmt.Sprintf("changing the status of todo #%d to %s", id, status), PreviousStatus: previous.Status})
+}
+
+// completeTodo marks the todo with the given ID as completed and reports it. Completing a todo
+// with open dependencies needs confirmation, and completing an open recurring todo also adds
+// and reports its next occurrence.
+// Returns the ID of the next occurrence, or 0 if none was added.
+func completeTodo(todoList *TodoList, id int) (int, error) {
+	todo, err := todoList.Get(id)
+	if err != nil {
+		return 0, err
+	}
+	if todoList.IsBlocked(todo) {
+		blockers := formatTodoIDs(todoList.OpenDependencies(todo))
+		if !getConfirmation(fmt.Sprintf("Todo #%d is blocked by open todos %s. Complete it anyway?", id, blockers)) {
+			return 0, fmt.Errorf("completion of todo #%d cancelled: it is blocked by open todos %s", id, blockers)
+		}
+	}
+	if err := todoList.Complete(id); err != nil {
+		return 0, err
+	}
+	printCompleted(todoList, id)
+	if todo.Completed || todo.Recurrence == "" {
+		return 0, nil
+	}
+
+	next, err := todoList.ScheduleNextOccurrence(id, time.Now())
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to schedule the next occurrence of todo with ID %d", id))
+		return 0, nil
+	}
+	reportNextOccurrence(next)
+	return next.ID, nil
+}
+
+// recurTodo runs the recur command: "recur <id> [<rule|none>] [-from <schedule|completion|default>]
+// [-overdue <pile-up|collapse|default>]" sets or clears the recurrence rule of a todo, and how its
+// next occurrence is scheduled when it is completed late. Options that are not given stay unchanged.
+func recurTodo(todoList *TodoList, args []string) {
+	usage := "Usage: recur <id> [<rule|none>] [-from <schedule|completion|default>] [-overdue <pile-up|collapse|default>] (e.g., recur 3 every monday -from completion)"
+	if len(args) < 2 {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("missing ID or rule for recur command"), "Interactive mode input error")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		PrintUserMessage("Invalid ID. Please provide a number.")
+		logInputError(err, "Interactive mode input error: invalid ID for recur")
+		return
+	}
+	current, err := todoList.Get(id)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to set recurrence of todo with ID %d", id))
+		printError(err)
+		return
+	}
+
+	from, overdue := current.RecurFrom, current.RecurOverdue
+	ruleParts := []string{}
+	for i := 1; i < len(args); i++ {
+		switch {
+		case (args[i] == "-from" || args[i] == "--from") && i+1 < len(args):
+			i++
+			from = strings.ToLower(args[i])
+		case (args[i] == "-overdue" || args[i] == "--overdue") && i+1 < len(args):
+			i++
+			overdue = strings.ToLower(args[i])
+		default:
+			ruleParts = append(ruleParts, args[i])
+		}
+	}
+	if from == "default" {
+		from = ""
+	}
+	if overdue == "default" {
+		overdue = ""
+	}
+	if err := todo.ValidateRecurrenceRules(from, overdue); err != nil {
+		logInputError(err, "Interactive mode input error: invalid recurrence rules")
+		printError(err)
+		return
+	}
+
+	if len(ruleParts) > 0 {
+		rule := strings.Join(ruleParts, " ")
+		if strings.ToLower(rule) == "none" {
+			rule = ""
+		}
+		if err := todoList.SetRecurrence(id, rule); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to set recurrence of todo with ID %d", id))
+			printError(err)
+			return
+		}
+	}
+	todoList.SetRecurrenceRules(id, from, overdue)
+	todo, _ := todoList.Get(id)
+	message := fmt.Sprintf("🔁 Todo #%d now repeats %s.", id, todo.Recurrence)
+	if todo.Recurrence == "" {
+		message = fmt.Sprintf("🔁 Todo #%d no longer repeats.", id)
+	} else if rules := describeRecurrenceRules(todo); rules != "" {
+		message = fmt.Sprintf("🔁 Todo #%d now repeats %s (%s).", id, todo.Recurrence, rules)
+	}
+	printResult(todo, message)
+}
+
+// parseSelectIDs removes the -ids option from the select command arguments, so todos can be
+// selected without the interactive picker (e.g., "select delete -ids 3,5"). Returns the remaining
+// arguments and the selected IDs, which are nil if the option was not given.
+func parseSelectIDs(todoList *TodoList, args []string) ([]string, []int, error) {
+	remaining := []string{}
+	var ids []int
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || name != "ids" {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, nil, fmt.Errorf("missing value for -ids")
+			}
+			i++
+			value = args[i]
+		}
+		parsed, err := parseTodoIDs(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w for -ids", err)
+		}
+		for _, id := range parsed {
+			if _, err := todoList.Get(id); err != nil {
+				return nil, nil, err
+			}
+		}
+		ids = parsed
+	}
+	return remaining, ids, nil
+}
+
+// searchAndAct runs a live search and then asks which command to run on the chosen todo,
+// e.g., "complete" or "edit New description". The command is run with the todo's ID inserted
+// as its first argument.
+func searchAndAct(todoList *TodoList, query string) {
+	id, ok, err := getConsole().liveSearch(todoList, query)
+	if err != nil {
+		logger.Error(err, "Failed to read live search input")
+	}
+	if !ok {
+		PrintUserMessage("Search cancelled.")
+		return
+	}
+
+	todo, _ := todoList.Get(id)
+	PrintUserMessage(formatTodo(todo))
+	input, _ := getConsole().Ask(fmt.Sprintf("Action for #%d (e.g., complete, uncomplete, delete, edit <task>): ", id))
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		PrintUserMessage("No action taken.")
+		return
+	}
+	executeCommand(todoList, append([]string{fields[0], strconv.Itoa(id)}, fields[1:]...))
+}
+
+// errMissingTask is returned when an add command does not contain a task description.
+var errMissingTask = errors.New("missing task for add command")
+
+// addArgs holds the values parsed from the arguments of an add command.
+type addArgs struct {
+	Task      string   // The task description (all arguments that are not options).
+	Priority  string   // Value of the -p option.
+	DueDate   string   // Value of the -d option.
+	ExpiresAt string   // Value of the -e option.
+	StartDate string   // Value of the -s option.
+	Estimate  string   // Value of the -est option (e.g., "1h30m").
+	Tags      []string // Values of the -t option, split on commas.
+	Parent    string   // Value of the -parent option.
+	Repeat    string   // Value of the -r option (e.g., "weekly" or "every 2 weeks").
+	DependsOn string   // Value of the -b (blocked by) option: comma-separated todo IDs.
+	Project   string   // Value of the -project option.
+}
+
+// parseAddArgs splits the arguments of an add command into the task description and
+// the values of the -p (priority), -d (due date), -e (expiry date), -s (start date), -t (comma-separated tags),
+// -parent (ID of the parent todo), -r (recurrence rule), -b (comma-separated IDs of the todos
+// it is blocked by), -project, and -est (time estimate) options.
+// This is a simplified approach; a dedicated parser would be more robust.
+func parseAddArgs(parts []string) addArgs {
+	args := addArgs{Tags: []string{}}
+	taskParts := []string{}
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "-p" && i+1 < len(parts) {
+			args.Priority = parts[i+1]
+			i++
+		} else if parts[i] == "-d" && i+1 < len(parts) {
+			args.DueDate, i = dateArg(parts, i+1) // Dates in words may span several parts (e.g., "in 3 days").
+		} else if parts[i] == "-e" && i+1 < len(parts) {
+			args.ExpiresAt, i = dateArg(parts, i+1)
+		} else if (parts[i] == "-est" || parts[i] == "--est") && i+1 < len(parts) {
+			args.Estimate = parts[i+1]
+			i++
+		} else if parts[i] == "-s" && i+1 < len(parts) {
+			args.StartDate, i = dateArg(parts, i+1)
+		} else if parts[i] == "-t" && i+1 < len(parts) {
+			args.Tags = append(args.Tags, strings.Split(parts[i+1], ",")...)
+			i++
+		} else if (parts[i] == "-parent" || parts[i] == "--parent") && i+1 < len(parts) {
+			args.Parent = parts[i+1]
+			i++
+		} else if (parts[i] == "-project" || parts[i] == "--project") && i+1 < len(parts) {
+			args.Project = parts[i+1]
+			i++
+		} else if parts[i] == "-b" && i+1 < len(parts) {
+			args.DependsOn = parts[i+1]
+			i++
+		} else if parts[i] == "-r" && i+1 < len(parts) {
+			i++
+			args.Repeat = parts[i]
+			if strings.EqualFold(args.Repeat, "every") {
+				// Rules starting with "every" span two or three words (e.g., "every monday" or "every 2 weeks").
+				for words := 1; words < 3 && i+1 < len(parts); words++ {
+					i++
+					args.Repeat += " " + parts[i]
+					if _, err := todo.ParseRecurrence(args.Repeat); err == nil {
+						break
+					}
+				}
+			}
+		} else {
+			taskParts = append(taskParts, parts[i]) // Unflagged parts make up the task.
+		}
+	}
+	args.Task = joinText(taskParts)
+	return args
+}
+
+// addTodoFromArgs parses the arguments of an add command and adds the resulting todo to the list.
+// Returns errMissingTask if no task description was given, or an error if a date, the parent,
+// the recurrence rule, or a dependency is invalid, or if a WIP limit blocks the todo.
+func addTodoFromArgs(todoList *TodoList, parts []string) (Todo, error) {
+	return addTodo(todoList, parseAddArgs(parts))
+}
+
+// addTodo adds a todo with the parsed arguments of an add command to the list. Options that
+// were not given take the configured defaults (see TodoDefaults).
+func addTodo(todoList *TodoList, args addArgs) (Todo, error) {
+	args = todoDefaults.fill(args)
+	if args.Task == "" {
+		return Todo{}, errMissingTask
+	}
+
+	var dueDate *time.Time
+	if args.DueDate != "" {
+		parsedDate, err := todo.ParseDueDate(args.DueDate)
+		if err != nil {
+			return Todo{}, fmt.Errorf("invalid due date %q: %w", args.DueDate, err)
+		}
+		dueDate = &parsedDate
+	}
+	var expiresAt *time.Time
+	if args.ExpiresAt != "" {
+		parsedDate, err := todo.ParseDate(args.ExpiresAt, time.Now()) // Todos expire after a whole day, so a time of day is not taken.
+		if err != nil {
+			return Todo{}, fmt.Errorf("invalid expiry date %q: %w", args.ExpiresAt, err)
+		}
+		expiresAt = &parsedDate
+	}
+	var startDate *time.Time
+	if args.StartDate != "" {
+		parsedDate, err := todo.ParseDate(args.StartDate, time.Now())
+		if err != nil {
+			return Todo{}, fmt.Errorf("invalid start date %q: %w", args.StartDate, err)
+		}
+		startDate = &parsedDate
+	}
+	var estimate time.Duration
+	if args.Estimate != "" {
+		parsed, err := parseEstimate(args.Estimate)
+		if err != nil {
+			return Todo{}, err
+		}
+		estimate = parsed
+	}
+
+	if args.Repeat != "" {
+		if _, err := todo.ParseRecurrence(args.Repeat); err != nil {
+			return Todo{}, err
+		}
+	}
+	dependencies := []int{}
+	if args.DependsOn != "" {
+		ids, err := parseTodoIDs(args.DependsOn)
+		if err != nil {
+			return Todo{}, err
+		}
+		for _, id := range ids {
+			if _, err := todoList.Get(id); err != nil {
+				return Todo{}, fmt.Errorf("invalid dependency: %w", err)
+			}
+		}
+		dependencies = ids
+	}
+	parentID := 0
+	if args.Parent != "" {
+		id, err := strconv.Atoi(args.Parent)
+		if err != nil {
+			return Todo{}, fmt.Errorf("invalid parent ID %q: %w", args.Parent, err)
+		}
+		if _, err := todoList.Get(id); err != nil {
+			return Todo{}, fmt.Errorf("invalid parent: %w", err)
+		}
+		parentID = id
+	}
+
+	priority := PriorityLevel(args.Priority).Canonical()
+	if wipLimitMode == "block" {
+		limited := priority
+		if limited == "" {
+			limited = PriorityMedium // The default priority of new todos.
 		}
+		if err := checkWIPLimit(todoList, limited, 0); err != nil {
+			return Todo{}, err
+		}
+	}
+
+	todo := todoList.Add(args.Task, priority, dueDate, args.Tags)
+	if parentID != 0 {
+		todoList.SetParent(todo.ID, parentID)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	if args.Repeat != "" {
+		todoList.SetRecurrence(todo.ID, args.Repeat)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	if args.Project != "" {
+		todoList.SetProject(todo.ID, args.Project)
+	}
+	for _, id := range dependencies {
+		todoList.AddDependency(todo.ID, id)
 	}
+	todo, _ = todoList.Get(todo.ID)
+	if expiresAt != nil {
+		todoList.SetExpiry(todo.ID, expiresAt)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	if startDate != nil {
+		todoList.SetStartDate(todo.ID, startDate)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	if estimate != 0 {
+		todoList.SetEstimate(todo.ID, estimate)
+		todo, _ = todoList.Get(todo.ID)
+	}
+	return todo, nil
 }
 
-// parseDueDate parses a date string in YYYY-MM-DD format into a time.Time object.
-func parseDueDate(dateStr string) (time.Time, error) {
-	return time.Parse("2006-01-02", dateStr)
+// addTodosFromReader adds one todo per line read from r, using the same inline syntax
+// as the interactive add command (e.g., "Write report -p high -d 2024-05-01 -t work").
+// Blank lines are ignored, and lines that cannot be parsed are reported and skipped.
+// Returns the todos that were added.
+func addTodosFromReader(todoList *TodoList, r io.Reader) []Todo {
+	added := []Todo{}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		todo, err := addTodoFromArgs(todoList, parts)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to add todo from line %d", lineNumber))
+			if warning := fmt.Sprintf("⚠️ Skipping line %d: %v", lineNumber, err); outputJSON {
+				fmt.Fprintln(os.Stderr, warning) // Keep standard output valid JSON.
+			} else {
+				PrintUserMessage(warning)
+			}
+			continue
+		}
+		added = append(added, todo)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Error(err, "Failed to read todos from input")
+	}
+	return added
 }
 
+// skipConfirmations makes getConfirmation answer yes without prompting.
+// It is set from the -yes/-force flag or the assume_yes config setting.
+var skipConfirmations bool
+
 // getConfirmation prompts the user for a yes/no confirmation and returns true if 'y' or 'Y' is entered.
+// If confirmations are skipped, it returns true without prompting.
 func getConfirmation(prompt string) bool {
-	fmt.Printf("%s (y/N): ", prompt)
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
+	if skipConfirmations {
+		return true
+	}
+	input, _ := getConsole().Ask(fmt.Sprintf("%s (y/N): ", prompt))
 	return strings.TrimSpace(strings.ToLower(input)) == "y"
 }
 
+// confirmationWord is the word that must be typed to confirm a bulk or irreversible operation.
+// It is set from the confirmation_word config setting, and defaults to the list name.
+var confirmationWord string
+
+// getWordConfirmation asks the user to type the confirmation word, so that bulk and irreversible operations
+// are not confirmed by reflex, and returns true if it was typed exactly.
+// If confirmations are skipped, it returns true without prompting.
+func getWordConfirmation(prompt string) bool {
+	if skipConfirmations {
+		return true
+	}
+	input, _ := getConsole().Ask(fmt.Sprintf("%s Type %q to confirm: ", prompt, confirmationWord))
+	return strings.TrimSpace(input) == confirmationWord
+}
+
+// clearCompleted removes all completed todos once the user confirms with the confirmation word.
+// Undo puts them back where they were.
+func clearCompleted(todoList *TodoList) {
+	positions := []int{}
+	for i, todo := range todoList.Todos {
+		if todo.Completed {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) == 0 {
+		printCleared([]Todo{}) // Nothing to confirm.
+		return
+	}
+	if getWordConfirmation(fmt.Sprintf("Are you sure you want to clear all %d completed todos?", len(positions))) {
+		cleared := todoList.ClearCompleted()
+		printCleared(cleared)
+		pushUndo(lastAction{Type: ActionClearCompleted, Description: fmt.Sprintf("clearing %d completed todos", len(cleared)), ClearedTodos: cleared, ClearedPositions: positions})
+	} else {
+		PrintUserMessage("Clearing completed todos cancelled.")
+	}
+}
+
+// listFlags holds pointers to the values of the filter and sort flags of the list command.
+type listFlags struct {
+	filterStatus    *string
+	filterPriority  *string
+	filterTags      *string
+	sortBy          *string
+	sortOrder       *string
+	ready           *bool
+	filterProject   *string
+	groupBy         *string
+	includeDeferred *bool
+	verbose         *bool
+	query           *string
+	createdAfter    *string
+	createdBefore   *string
+	dueAfter        *string
+	dueBefore       *string
+	noDueDate       *bool
+	noTags          *bool
+	noPriority      *bool
+	fs              *flag.FlagSet // The flag set they are defined on, which knows which of them were given.
+}
+
+// defineListFlags defines the list filter and sort flags on the given flag set.
+// They are shared by the -list flag in single-command mode and the interactive list command.
+func defineListFlags(fs *flag.FlagSet) listFlags {
+	return listFlags{
+		filterStatus:    fs.String("filter-status", "all", "Filter todos by status (all, completed, incomplete, expired, overdue, todo, in-progress, waiting, blocked, done, cancelled); expired todos are only shown with expired"),
+		filterPriority:  fs.String("filter-priority", "", "Filter todos by priority (high, medium, low)"),
+		filterTags:      fs.String("filter-tags", "", "Filter todos by tags (comma-separated, e.g., work,urgent)"),
+		sortBy:          fs.String("sort-by", "id", "Sort todos by field (id, task, created_at, due_date, priority, order, urgency) or by an expression (e.g., 'expr: len(Tags)')"),
+		sortOrder:       fs.String("sort-order", "asc", "Sort order (asc, desc)"),
+		ready:           fs.Bool("ready", false, "Only show incomplete todos that are not blocked by open dependencies"),
+		filterProject:   fs.String("filter-project", "", "Filter todos by project (or none for todos without a project)"),
+		groupBy:         fs.String("group-by", "", "Group todos in the list (project)"),
+		includeDeferred: fs.Bool("include-deferred", false, "Also show todos whose start date has not arrived yet"),
+		verbose:         fs.Bool("verbose", false, "Also show the urgency score of open todos"),
+		query:           fs.String("query", "", "Filter todos by a query (e.g., 'priority:high AND tag:work AND due<2025-01-01 AND NOT status:done')"),
+		createdAfter:    fs.String("created-after", "", "Only show todos created on or after a date (e.g., 2025-01-01, last week, or -7d)"),
+		createdBefore:   fs.String("created-before", "", "Only show todos created on or before a date"),
+		dueAfter:        fs.String("due-after", "", "Only show todos due on or after a date (e.g., today or +1w)"),
+		dueBefore:       fs.String("due-before", "", "Only show todos due on or before a date"),
+		noDueDate:       fs.Bool("no-due-date", false, "Only show todos without a due date"),
+		noTags:          fs.Bool("no-tags", false, "Only show todos without tags"),
+		noPriority:      fs.Bool("no-priority", false, "Only show todos without a priority"),
+		fs:              fs,
+	}
+}
+
+// options converts the parsed list flag values into ListOptions.
+// Returns an error if the status filter, sort expression, or grouping is invalid.
+func (f listFlags) options() (ListOptions, error) {
+	options := ListOptions{
+		FilterStatus:    *f.filterStatus,
+		FilterPriority:  PriorityLevel(*f.filterPriority),
+		FilterTags:      strings.Split(*f.filterTags, ","),
+		SortBy:          *f.sortBy,
+		SortOrder:       *f.sortOrder,
+		Ready:           *f.ready,
+		FilterProject:   *f.filterProject,
+		GroupBy:         *f.groupBy,
+		IncludeDeferred: *f.includeDeferred,
+		Verbose:         *f.verbose,
+		Query:           *f.query,
+	}
+	// Clean up empty tag strings from splitting
+	if len(options.FilterTags) == 1 && options.FilterTags[0] == "" {
+		options.FilterTags = []string{}
+	}
+	switch options.FilterStatus {
+	case "all", "completed", "incomplete", "expired", "overdue":
+	default:
+		status, err := todo.ParseStatus(options.FilterStatus)
+		if err != nil {
+			return options, fmt.Errorf("invalid filter-status value %q: use all, completed, incomplete, expired, overdue, or a status (todo, in-progress, waiting, blocked, done, cancelled)", options.FilterStatus)
+		}
+		options.FilterStatus = string(status)
+	}
+	if options.GroupBy != "" && options.GroupBy != "project" {
+		return options, fmt.Errorf("invalid group-by value %q: use project", options.GroupBy)
+	}
+	// The date range flags are shorthands for query terms (e.g., -due-before friday is due<=friday).
+	for _, dateRange := range []struct{ flag, value, term string }{
+		{"created-after", *f.createdAfter, "created>="},
+		{"created-before", *f.createdBefore, "created<="},
+		{"due-after", *f.dueAfter, "due>="},
+		{"due-before", *f.dueBefore, "due<="},
+	} {
+		if dateRange.value == "" {
+			continue
+		}
+		if _, err := todo.ParseDate(dateRange.value, time.Now()); err != nil {
+			return options, fmt.Errorf("invalid %s value %q: %w", dateRange.flag, dateRange.value, err)
+		}
+		options.Query = addQueryTerm(options.Query, dateRange.term+`"`+dateRange.value+`"`)
+	}
+	// So are the filters for missing fields, which help to triage under-specified todos.
+	for _, missing := range []struct {
+		set  bool
+		term string
+	}{{*f.noDueDate, "due:none"}, {*f.noTags, "tag:none"}, {*f.noPriority, "priority:none"}} {
+		if missing.set {
+			options.Query = addQueryTerm(options.Query, missing.term)
+		}
+	}
+	if options.Query != "" {
+		if _, err := todo.CompileQuery(options.Query, time.Now()); err != nil {
+			return options, err
+		}
+	}
+	// Report invalid sort expressions to the user instead of silently ignoring them.
+	if strings.HasPrefix(options.SortBy, todo.SortExprPrefix) {
+		if _, err := todo.CompileSortExpression(options.SortBy); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+// addQueryTerm returns a query that also requires term to match.
+func addQueryTerm(query, term string) string {
+	if query == "" {
+		return term
+	}
+	return "(" + query + ") AND " + term
+}
+
+// joinListArgs joins the words following -query, up to the next flag, and the words of dates
+// in words (e.g., "-created-after last week") into single arguments, since the interactive list
+// command receives them split into words.
+func joinListArgs(args []string) []string {
+	joined := []string{}
+	for i := 0; i < len(args); i++ {
+		joined = append(joined, args[i])
+		if i+1 == len(args) {
+			break
+		}
+		switch strings.TrimLeft(args[i], "-") {
+		case "query":
+			end := i + 1
+			for end+1 < len(args) && !strings.HasPrefix(args[end+1], "-") {
+				end++
+			}
+			joined = append(joined, strings.Join(args[i+1:end+1], " "))
+			i = end
+		case "created-after", "created-before", "due-after", "due-before":
+			var date string
+			date, i = dateArg(args, i+1)
+			joined = append(joined, date)
+		}
+	}
+	return joined
+}
+
+// parseListArgs parses the arguments of the interactive list command
+// (e.g., "-filter-status incomplete -sort-by due_date") into ListOptions.
+func parseListArgs(args []string) (ListOptions, error) {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // Errors are returned and reported by the caller.
+	flags := defineListFlags(fs)
+	if err := fs.Parse(joinListArgs(args)); err != nil {
+		return ListOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return ListOptions{}, fmt.Errorf("unexpected argument %q for list command", fs.Arg(0))
+	}
+	return listPreferences.apply(flags).options()
+}
+
+// commandFlags holds pointers to the values of all command-line flags,
+// as defined and parsed by parseFlags.
+type commandFlags struct {
+	listFlags
+	add            *string
+	complete       *int
+	delete         *int
+	list           *bool
+	search         *string
+	fuzzy          *bool
+	interactive    *bool
+	clearCompleted *bool
+	json           *bool
+	fields         *string
+	configFile     *string
+	dataFile       *string
+	yes            *bool
+	dryRun         *bool
+	noPager        *bool
+	minimal        *bool
+	noLogFile      *bool
+	transaction    *bool
+	theme          *string
+	dateFormat     *string
+	noEmoji        *bool
+	profile        *string
+	logLevel       *string
+	debug          *bool
+}
+
 // processSingleCommand handles the execution of a single command based on the provided flags.
-// It takes the TodoList and pointers to the parsed flag values.
-func processSingleCommand(todoList *TodoList, addPtr *string, completePtr *int, deletePtr *int, listPtr *bool, clearCompletedCmdPtr *bool, filterStatusPtr *string, filterPriorityPtr *string, filterTagsPtr *string, sortByPtr *string, sortOrderPtr *string) {
+// It takes the TodoList and the parsed flag values.
+func processSingleCommand(todoList *TodoList, flags commandFlags) {
 	switch {
+	case flag.NArg() > 0:
+		// Positional arguments are run as an interactive-style command (e.g., "add Buy milk -p high"),
+		// which also makes user-defined aliases available in single-command mode.
+		executeCommand(todoList, expandAlias(flag.Args()))
 	case flag.NFlag() == 0:
 		// If no flags are provided at all, print usage and suggest interactive mode.
-		PrintUserMessage("Usage: go run . [options]")
+		PrintUserMessage("Usage: go run . [options] [command [arguments]]")
 		PrintUserMessage("💡 Run with -interactive for interactive mode.")
 		flag.PrintDefaults() // Display default values and descriptions for all flags.
-	case *addPtr != "":
+	case *flags.add == "-":
+		// If the -add flag is "-", add one todo per line read from standard input.
+		printBulkAdded(addTodosFromReader(todoList, os.Stdin))
+	case *flags.add != "":
 		// If the -add flag is present, add a new todo with the provided task description.
-		// For single command mode, priority, due date, and tags are not yet supported via flags directly.
-		todoList.Add(*addPtr, toCanonicalPriority(PriorityMedium), nil, []string{}) // Default values for new fields
-	case *completePtr != 0:
+		// Its priority, due date, and tags are the configured defaults; the add command takes options for them.
+		if todo, err := addTodo(todoList, addArgs{Task: *flags.add, Tags: []string{}}); err != nil {
+			logger.Error(err, "Failed to add todo")
+			printError(err)
+		} else {
+			printAdded(todo)
+		}
+	case *flags.complete != 0:
 		// If the -complete flag is present, mark the todo with the given ID as complete.
-		err := todoList.Complete(*completePtr)
-		if err != nil {
+		if _, err := completeTodo(todoList, *flags.complete); err != nil {
 			// Log and print an error if the todo to complete is not found.
-			LogError(err, fmt.Sprintf("Failed to complete todo with ID %d", *completePtr))
-			PrintUserMessage(err.Error())
+			logger.Error(err, fmt.Sprintf("Failed to complete todo with ID %d", *flags.complete))
+			printError(err)
 		}
-	case *deletePtr != 0:
+	case *flags.delete != 0:
 		// If the -delete flag is present, remove the todo with the given ID.
-		if getConfirmation(fmt.Sprintf("Are you sure you want to delete todo with ID %d?", *deletePtr)) {
-			deletedTodo, err := todoList.Delete(*deletePtr)
+		if getConfirmation(deleteConfirmationPrompt(todoList, *flags.delete)) {
+			deletedTodo, deletedSubtasks, err := todoList.MoveToTrash(*flags.delete, time.Now())
 			if err != nil {
 				// Log and print an error if the todo to delete is not found.
-				LogError(err, fmt.Sprintf("Failed to delete todo with ID %d", *deletePtr))
-				PrintUserMessage(err.Error())
+				logger.Error(err, fmt.Sprintf("Failed to delete todo with ID %d", *flags.delete))
+				printError(err)
 			} else {
 				// No undo state stored for single commands for simplicity here.
-				_ = deletedTodo // Use deletedTodo to avoid unused variable error
+				printDeleted(deletedTodo, deletedSubtasks)
 			}
 		} else {
-			PrintUserMessage(fmt.Sprintf("Deletion of todo #%d cancelled.", *deletePtr))
+			PrintUserMessage(fmt.Sprintf("Deletion of todo #%d cancelled.", *flags.delete))
 		}
-	case *clearCompletedCmdPtr:
+	case *flags.clearCompleted:
 		// If the -clear-completed flag is present, clear all completed todos.
-		if getConfirmation("Are you sure you want to clear all completed todos?") {
-			todoList.ClearCompleted()
-		} else {
-			PrintUserMessage("Clearing completed todos cancelled.")
-		}
-	case *listPtr:
+		clearCompleted(todoList)
+	case *flags.search != "":
+		// If the -search flag is present, display todos matching the query.
+		printSearchResults(todoList, *flags.search, *flags.fuzzy)
+	case *flags.list:
 		// If the -list flag is present, display all current todos with applied filters and sorting.
-		options := ListOptions{
-			FilterStatus:   *filterStatusPtr,
-			FilterPriority: PriorityLevel(*filterPriorityPtr),
-			FilterTags:     strings.Split(*filterTagsPtr, ","),
-			SortBy:         *sortByPtr,
-			SortOrder:      *sortOrderPtr,
-		}
-		// Clean up empty tag strings from splitting
-		if len(options.FilterTags) == 1 && options.FilterTags[0] == "" {
-			options.FilterTags = []string{}
-		}
-		todoList.List(options)
+		options, err := listPreferences.apply(flags.listFlags).options()
+		if err != nil {
+			logger.Error(err, "Invalid list options")
+			printError(err)
+			return
+		}
+		printTodos(todoList, options)
 	default:
 		// This case catches any other combination of flags that don't match specific commands.
 		PrintUserMessage("❌ Unknown command or invalid flag combination. Type 'go run .' for usage.")
 	}
 }
 
-// HandleCommands parses command-line flags and manages the application flow,
-// either by executing a single command or entering an interactive mode.
-// It defines the CLI flags, parses them, and then dispatches control
-// to either `runInteractiveMode` or `processSingleCommand` based on user input.
-func HandleCommands(todoList *TodoList) {
+// parseFlags defines all command-line flags and parses the command-line arguments into them.
+// It must be called before the configuration is loaded, since some flags override config values.
+func parseFlags() commandFlags {
+	flags := defineFlags(flag.CommandLine)
+	flag.Parse() // Parse the command-line arguments into the defined flags.
+	return flags
+}
+
+// defineFlags defines all command-line flags on the given flag set.
+func defineFlags(fs *flag.FlagSet) commandFlags {
 	// Define command-line flags for various todo operations.
-	addPtr := flag.String("add", "", "Add a new todo task")
-	completePtr := flag.Int("complete", 0, "Mark a todo as complete by ID")
-	deletePtr := flag.Int("delete", 0, "Delete a todo by ID")
-	listPtr := flag.Bool("list", false, "List all todos")
-	interactivePtr := flag.Bool("interactive", false, "Run in interactive mode")
-	clearCompletedCmdPtr := flag.Bool("clear-completed", false, "Clear all completed todos") // New flag for single command
-
-	// New flags for enhanced list command
-	filterStatusPtr := flag.String("filter-status", "all", "Filter todos by status (all, completed, incomplete)")
-	filterPriorityPtr := flag.String("filter-priority", "", "Filter todos by priority (high, medium, low)")
-	filterTagsPtr := flag.String("filter-tags", "", "Filter todos by tags (comma-separated, e.g., work,urgent)")
-	sortByPtr := flag.String("sort-by", "id", "Sort todos by field (id, task, created_at, due_date, priority)")
-	sortOrderPtr := flag.String("sort-order", "asc", "Sort order (asc, desc)")
+	flags := commandFlags{
+		add:            fs.String("add", "", "Add a new todo task (use \"-\" to add one task per line from stdin)"),
+		complete:       fs.Int("complete", 0, "Mark a todo as complete by ID"),
+		delete:         fs.Int("delete", 0, "Delete a todo by ID"),
+		list:           fs.Bool("list", false, "List all todos"),
+		search:         fs.String("search", "", "Search todos by description or tags"),
+		fuzzy:          fs.Bool("fuzzy", false, "Make -search tolerate typos and partial words"),
+		interactive:    fs.Bool("interactive", false, "Run in interactive mode"),
+		clearCompleted: fs.Bool("clear-completed", false, "Clear all completed todos"),
 
-	flag.Parse() // Parse the command-line arguments into the defined flags.
+		// Flags for the enhanced list command
+		listFlags: defineListFlags(fs),
+
+		// Global flags
+		json:        fs.Bool("json", false, "Emit command results as JSON instead of text"),
+		fields:      fs.String("fields", "", "Comma-separated keys to keep in JSON output (e.g., id,task,due_date); implies -json"),
+		configFile:  fs.String("config", defaultConfigFile(), "Path to the configuration file; defaults to "+envConfigFile+", else the .todo/config of the project, else config.json"),
+		dataFile:    fs.String("data-file", "", "Path to the todo data file (overrides data_file from the config)"),
+		profile:     fs.String("profile", "", "Profile of the config to use, e.g., work, or none (overrides profile from the config)"),
+		yes:         fs.Bool("yes", false, "Answer yes to all confirmation prompts (e.g., for scripts and cron jobs)"),
+		dryRun:      fs.Bool("dry-run", false, "Show what a command would change without saving anything"),
+		noPager:     fs.Bool("no-pager", false, "Never pipe long list output through $PAGER"),
+		minimal:     fs.Bool("minimal", false, "Use the minimal output profile: no emoji, colors, counts, or banners"),
+		theme:       fs.String("theme", "", "Color theme to use (overrides theme from the config)"),
+		dateFormat:  fs.String("date-format", "", "Go layout to show dates in, e.g., 'Jan 2, 2006' (overrides date_format from the config)"),
+		noEmoji:     fs.Bool("no-emoji", false, "Leave emoji out of messages (overrides emoji from the config)"),
+		noLogFile:   fs.Bool("no-log-file", false, "Log to stderr only, ignoring log_file_path from the config"),
+		logLevel:    fs.String("log-level", "", "Lowest level of the logged messages: debug, info, warn, or error (overrides log_level from the config)"),
+		debug:       fs.Bool("debug", false, "Log debug messages, with how long loading, filtering, sorting, and saving take (like -log-level debug)"),
+		transaction: fs.Bool("transaction", false, "Run the commands read from stdin as one transaction: saved together if all succeed, rolled back otherwise"),
+	}
+	fs.BoolVar(flags.yes, "force", false, "Alias for -yes")
+	return flags
+}
+
+// HandleCommands manages the application flow based on the parsed command-line flags and config,
+// either by executing a single command or entering an interactive mode.
+// It dispatches control to either `runInteractiveMode` or `processSingleCommand`.
+func HandleCommands(todoList *TodoList, flags commandFlags, config Config) {
+	sessionFlags = flags
+	applySettings(config, flags)
+
+	// Expire open todos whose expiry date has passed, and purge todos kept in the trash
+	// for longer than the retention period, before running any command.
+	todoListMu.Lock()
+	expired := todoList.ExpireOverdue(time.Now())
+	purged := todoList.PurgeTrash(time.Now(), trashRetentionDays)
+	todoListMu.Unlock()
+	reportExpired(expired)
+	reportSaveFailure(activeDataFile)
+	if len(purged) > 0 {
+		logger.Info(fmt.Sprintf("Purged %d todos deleted more than %d days ago from the trash.", len(purged), trashRetentionDays))
+	}
+
+	// Changes of a transaction that was left open are discarded rather than saved on exit.
+	defer rollbackOpenTransaction(todoList)
+
+	// With -transaction, run the commands from stdin as a batch that is saved all at once, or not at all.
+	if *flags.transaction {
+		runTransactionBatch(todoList, config, os.Stdin)
+		return
+	}
 
 	// If interactive mode is enabled, run the interactive loop.
-	if *interactivePtr {
-		runInteractiveMode(todoList)
+	if *flags.interactive {
+		runInteractiveMode(todoList, config, os.Stdin)
 		return // Exit after interactive mode finishes
 	}
 
 	// If not in interactive mode, process a single command based on the provided flags.
-	processSingleCommand(todoList, addPtr, completePtr, deletePtr, listPtr, clearCompletedCmdPtr, filterStatusPtr, filterPriorityPtr, filterTagsPtr, sortByPtr, sortOrderPtr)
+	// It is recorded in the audit log as it was given.
+	auditCommand(todoList, strings.Join(os.Args[1:], " "), func() bool {
+		runSingleCommand(todoList, flags, "todo "+strings.Join(os.Args[1:], " "))
+		return true
+	})
+}
+
+// runSingleCommand processes the single command given by the flags and positional arguments.
+// It runs atomically, and its changes are recorded for undo, so that a later "todo undo" can revert
+// them. Positional undo and transaction commands run as they are (see managesUndo).
+// description names the command, e.g., "todo -complete 3".
+func runSingleCommand(todoList *TodoList, flags commandFlags, description string) {
+	if command := expandAlias(flag.Args()); len(command) > 0 && managesUndo(command[0]) {
+		processSingleCommand(todoList, flags)
+		return
+	}
+	runAtomically(todoList, description, func(working *TodoList) bool {
+		processSingleCommand(working, flags)
+		return true
+	})
+}
+
+// applySettings sets the package settings the commands read from the config and the flags.
+// It runs on startup and again when the config is reloaded.
+func applySettings(config Config, flags commandFlags) {
+	activeDataFile = config.DataFile
+	activeConfigFile = *flags.configFile
+	activeProfile = config.Profile
+	dryRun = *flags.dryRun
+	outputFields = parseFields(*flags.fields)
+	outputJSON = *flags.json || len(outputFields) > 0
+	skipConfirmations = *flags.yes || config.AssumeYes
+	confirmationWord = config.ConfirmationWord
+	if confirmationWord == "" {
+		confirmationWord = listNameOf(config.DataFile)
+	}
+	commandAliases = config.Aliases
+	usePager = !*flags.noPager
+	attachments = attachmentStore{dir: listAttachmentsDir(config.AttachmentsDir, config.DataFile), dryRun: *flags.dryRun}
+	minimalOutput = *flags.minimal || config.OutputProfile == "minimal"
+	showEmoji = config.Emoji && !*flags.noEmoji && !minimalOutput
+	if *flags.theme != "" {
+		config.Theme = *flags.theme
+	}
+	setupColors(config.Color, config.Theme, config.Themes)
+	if minimalOutput {
+		colorsEnabled = false
+	}
+	chronicSnoozeThreshold = config.ChronicSnoozeThreshold
+	suggestDueDates = config.SuggestDueDates
+	todoDefaults = config.Defaults
+	listPreferences = config.List
+	dateFormat = config.DateFormat
+	if *flags.dateFormat != "" {
+		dateFormat = *flags.dateFormat
+	}
+	if err := validateDateFormat(dateFormat); err != nil {
+		logger.Warn(fmt.Sprintf("%v. Using %s.", err, defaultDateFormat))
+		dateFormat = defaultDateFormat
+	}
+	setWIPLimits(config.WIPLimits, config.WIPLimitMode)
+	todo.SetUrgencyWeights(config.UrgencyWeights)
+	dashboardLists = config.Lists
+	smtpSettings = config.SMTP
+	setRecurrenceRules(config.RecurrenceFrom, config.RecurrenceOverdue)
+	trashRetentionDays = max(config.TrashRetentionDays, 0) // A negative value must not purge the whole trash.
+	auditEnabled = config.AuditLog
+	webhooks = config.Webhooks
 }
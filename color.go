@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., warning messages)
+	"os"      // Package for checking standard output and the environment
+	"strings" // Package for string manipulation
+)
+
+// Theme defines the colors of the list output as ANSI SGR parameters,
+// e.g., "31" for red or "1;33" for bold yellow. An empty value leaves the text uncolored.
+type Theme struct {
+	High      string `json:"high"`      // Color of the priority of high-priority todos.
+	Medium    string `json:"medium"`    // Color of the priority of medium-priority todos.
+	Low       string `json:"low"`       // Color of the priority of low-priority todos.
+	Completed string `json:"completed"` // Color of completed todos (the whole line).
+	Expired   string `json:"expired"`   // Color of expired todos (the whole line).
+	Overdue   string `json:"overdue"`   // Color of the due date of open todos that are overdue.
+}
+
+// builtinThemes are the themes that can be selected by name without defining them in the config.
+var builtinThemes = map[string]Theme{
+	"default": {High: "1;35", Medium: "33", Low: "36", Completed: "2", Expired: "2", Overdue: "1;31"},
+	"high-contrast": {
+		High: "1;97;41", Medium: "1;30;43", Low: "1;30;46", Completed: "2", Expired: "2;9", Overdue: "1;97;41",
+	},
+	"minimal": {Completed: "2", Expired: "2", Overdue: "31"},
+}
+
+// colorsEnabled controls whether the list output is colored using colorTheme.
+// Both are set from the color, theme, and themes config settings.
+var (
+	colorsEnabled bool
+	colorTheme    = builtinThemes["default"]
+)
+
+// setupColors enables colored output according to the color mode ("auto", "always", or "never")
+// and selects the named theme, looking it up in the custom themes before the built-in ones.
+// In "auto" mode, colors are used only when standard output is a terminal, JSON output is off,
+// and the NO_COLOR environment variable is not set.
+func setupColors(mode string, themeName string, customThemes map[string]Theme) {
+	switch strings.ToLower(mode) {
+	case "always":
+		colorsEnabled = true
+	case "never":
+		colorsEnabled = false
+	default:
+		if mode != "" && strings.ToLower(mode) != "auto" {
+			logger.Warn(fmt.Sprintf("Unknown color mode '%s'. Using auto.", mode))
+		}
+		colorsEnabled = !outputJSON && os.Getenv("NO_COLOR") == "" && isTerminal(int(os.Stdout.Fd()))
+	}
+
+	if theme, ok := customThemes[themeName]; ok {
+		colorTheme = theme
+	} else if theme, ok := builtinThemes[themeName]; ok {
+		colorTheme = theme
+	} else {
+		if themeName != "" {
+			logger.Warn(fmt.Sprintf("Unknown theme '%s'. Using the default theme.", themeName))
+		}
+		colorTheme = builtinThemes["default"]
+	}
+}
+
+// priorityColor returns the theme color for the given priority.
+func (t Theme) priorityColor(priority PriorityLevel) string {
+	switch priority {
+	case PriorityHigh:
+		return t.High
+	case PriorityMedium:
+		return t.Medium
+	case PriorityLow:
+		return t.Low
+	}
+	return ""
+}
+
+// paint wraps text in the given color, if colors are enabled.
+func paint(color string, text string) string {
+	if !colorsEnabled || color == "" || text == "" {
+		return text
+	}
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json" // Package for reading and writing config values by their JSON key
+	"fmt"           // Package for formatted I/O (e.g., error messages)
+	"strings"       // Package for splitting dotted keys
+	"time"          // Package for validating the digest time
+	"todo/pkg/todo" // Package for checking the recurrence and due time settings
+)
+
+// activeConfigFile is the config file the settings were loaded from, which the config command
+// reads and writes. It is set from the -config flag or TODO_CONFIG.
+var activeConfigFile string
+
+// configValues returns the settings of a config as generic JSON values by key (e.g., "data_file").
+func configValues(config Config) (map[string]any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	return values, json.Unmarshal(data, &values)
+}
+
+// lookupConfigValue returns the value of a key in config values. Keys of nested settings
+// are joined with dots (e.g., "smtp.host" or "wip_limits.high").
+func lookupConfigValue(values map[string]any, key string) (any, bool) {
+	var value any = values
+	for _, part := range strings.Split(key, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// getConfigValue returns the value of a config setting, formatted for display: text as it is,
+// and other values as JSON. Returns an error if there is no such setting.
+func getConfigValue(config Config, key string) (string, error) {
+	values, err := configValues(config)
+	if err != nil {
+		return "", err
+	}
+	value, ok := lookupConfigValue(values, key)
+	if !ok {
+		return "", fmt.Errorf("unknown config key %q", key)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	return string(data), err
+}
+
+// setConfigValue returns the config with a setting changed. The value is taken as text for text
+// settings, and as JSON otherwise (e.g., 5, true, or ["a@example.com"]). Returns an error if the
+// key is unknown, or the value has the wrong type or is invalid (see validateConfig).
+func setConfigValue(config Config, key string, input string) (Config, error) {
+	values, err := configValues(config)
+	if err != nil {
+		return config, err
+	}
+	parentKey, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		parentKey, name = key[:i], key[i+1:]
+	}
+	parent := values
+	if parentKey != "" {
+		object, ok := lookupConfigValue(values, parentKey)
+		if parent, ok = object.(map[string]any); !ok {
+			return config, fmt.Errorf("unknown config key %q", key)
+		}
+	}
+	current, exists := parent[name]
+	if !exists && parentKey == "" {
+		return config, fmt.Errorf("unknown config key %q", key)
+	}
+	var value any = input
+	if _, isText := current.(string); !isText {
+		if err := json.Unmarshal([]byte(input), &value); err != nil {
+			value = input // Not JSON, so it is text; settings that are not text reject it below.
+		}
+	}
+	parent[name] = value
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return config, err
+	}
+	updated := DefaultConfig()
+	if err := json.Unmarshal(data, &updated); err != nil {
+		return config, fmt.Errorf("invalid value %q for %s: %w", input, key, err)
+	}
+	// Keys of fixed settings (e.g., "smtp.hots") are dropped when decoding.
+	if updatedValues, err := configValues(updated); err != nil {
+		return config, err
+	} else if _, ok := lookupConfigValue(updatedValues, key); !ok {
+		return config, fmt.Errorf("unknown config key %q", key)
+	}
+	if err := validateConfig(updated); err != nil {
+		return config, fmt.Errorf("invalid value %q for %s: %w", input, key, err)
+	}
+	return updated, nil
+}
+
+// validateConfig reports the first setting of a config with an invalid value, if any.
+// Settings that are merely unusual (e.g., an empty data file name) are not checked.
+func validateConfig(config Config) error {
+	if config.AutoSaveInterval <= 0 {
+		return fmt.Errorf("auto_save_interval must be a positive duration, like 30s or 5m")
+	}
+	if _, err := parseLogLevel(config.LogLevel); err != nil {
+		return fmt.Errorf("log_level must be debug, info, warn, or error")
+	}
+	if err := config.LogRotation.validate(); err != nil {
+		return err
+	}
+	if config.DaemonLog != "" && config.DaemonLog != daemonLogFile && config.DaemonLog != daemonLogSyslog {
+		return fmt.Errorf("daemon_log must be file or syslog")
+	}
+	if config.DaemonInterval < 0 {
+		return fmt.Errorf("daemon_interval must not be negative")
+	}
+	switch strings.ToLower(config.Color) {
+	case "", "auto", "always", "never":
+	default:
+		return fmt.Errorf("color must be auto, always, or never")
+	}
+	if _, builtin := builtinThemes[config.Theme]; !builtin && config.Theme != "" {
+		if _, custom := config.Themes[config.Theme]; !custom {
+			return fmt.Errorf("theme %q is neither built in nor defined in themes", config.Theme)
+		}
+	}
+	if config.OutputProfile != "" && config.OutputProfile != "default" && config.OutputProfile != "minimal" {
+		return fmt.Errorf("output_profile must be default or minimal")
+	}
+	for name, limit := range config.WIPLimits {
+		if PriorityLevel(name).Canonical() == "" || limit < 0 {
+			return fmt.Errorf("wip_limits must map high, medium, or low to a limit of 0 or more")
+		}
+	}
+	if config.WIPLimitMode != "" && config.WIPLimitMode != "warn" && config.WIPLimitMode != "block" {
+		return fmt.Errorf("wip_limit_mode must be warn or block")
+	}
+	if config.TrashRetentionDays < 0 {
+		return fmt.Errorf("trash_retention_days must not be negative")
+	}
+	if err := todo.ValidateRecurrenceRules(config.RecurrenceFrom, config.RecurrenceOverdue); err != nil {
+		return err
+	}
+	if _, err := time.Parse(todo.DueTimeLayout, config.DigestTime); config.DigestTime != "" && err != nil {
+		return fmt.Errorf("digest_time must be a time of day like 07:30")
+	}
+	if err := config.Defaults.validate(); err != nil {
+		return err
+	}
+	if err := config.List.validate(); err != nil {
+		return err
+	}
+	if err := validateDateFormat(config.DateFormat); err != nil {
+		return err
+	}
+	for name, profile := range config.Profiles {
+		if name == "" || name == noProfile {
+			return fmt.Errorf("profiles must not be named %q", name)
+		}
+		if err := profile.Defaults.validate(); err != nil {
+			return fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	if err := validateProfile(config, config.Profile); err != nil {
+		return err
+	}
+	for _, hook := range config.Webhooks {
+		if err := hook.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manageConfig runs the config command: "config get <key>" shows a setting of the config file,
+// and "config set <key> <value>" changes it, once the value is validated. Changes take effect
+// the next time the application starts.
+func manageConfig(args []string) {
+	usage := "Usage: config get <key> | config set <key> <value>"
+	if len(args) < 2 || (args[0] != "get" && args[0] != "set") || (args[0] == "set" && len(args) < 3) {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("invalid arguments for config command"), "Interactive mode input error")
+		return
+	}
+	config, err := LoadConfig(activeConfigFile) // The file, without -data-file or environment overrides.
+	if err != nil {
+		logger.Error(err, "Failed to load the config")
+		printError(err)
+		return
+	}
+	key := args[1]
+	if args[0] == "get" {
+		value, err := getConfigValue(config, key)
+		if err != nil {
+			logger.Error(err, "Failed to get config value")
+			printError(err)
+			return
+		}
+		printResult(map[string]string{"key": key, "value": value}, value)
+		return
+	}
+
+	value := strings.Join(args[2:], " ")
+	updated, err := setConfigValue(config, key, value)
+	if err == nil && !dryRun {
+		err = SaveConfig(updated, activeConfigFile)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to set config value")
+		printError(err)
+		return
+	}
+	shown, _ := getConfigValue(updated, key)
+	message := fmt.Sprintf("⚙️ Set %s to %s in %s. It takes effect the next time todo starts.", key, shown, activeConfigFile)
+	if dryRun {
+		message = fmt.Sprintf("⚙️ Would set %s to %s in %s (dry run).", key, shown, activeConfigFile)
+	}
+	printResult(map[string]string{"key": key, "value": shown}, message)
+}
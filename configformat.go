@@ -0,0 +1,730 @@
+package main
+
+import (
+	"bytes"         // Package for building the encoded config
+	"encoding/json" // Package for converting between the config and generic values
+	"fmt"           // Package for formatted I/O (e.g., parse errors)
+	"path/filepath" // Package for detecting the format by the file extension
+	"strconv"       // Package for parsing numbers
+	"strings"       // Package for parsing lines of YAML and TOML
+)
+
+// Formats of the config file, detected by its extension.
+const (
+	configFormatJSON = "json"
+	configFormatYAML = "yaml" // .yaml or .yml
+	configFormatTOML = "toml"
+)
+
+// configFormat returns the format of a config file by its extension; JSON unless it is YAML or TOML.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return configFormatYAML
+	case ".toml":
+		return configFormatTOML
+	}
+	return configFormatJSON
+}
+
+// marshalConfig encodes a config in the given format. YAML and TOML settings are written in the
+// same order and with the same keys as in JSON.
+func marshalConfig(config Config, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil || format == configFormatJSON {
+		return data, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	values, err := decodeOrderedJSON(decoder)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if format == configFormatYAML {
+		writeYAML(&b, values.(orderedMap), "")
+	} else {
+		writeTOML(&b, values.(orderedMap), nil, false)
+	}
+	return b.Bytes(), nil
+}
+
+// unmarshalConfig decodes a config in the given format into config. Settings missing from data
+// keep their value in config.
+func unmarshalConfig(data []byte, format string, config *Config) error {
+	if format == configFormatJSON {
+		return json.Unmarshal(data, config)
+	}
+	var values map[string]any
+	var err error
+	if format == configFormatYAML {
+		values, err = parseYAML(string(data))
+	} else {
+		values, err = parseTOML(string(data))
+	}
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+// orderedMap is a JSON object whose keys keep their order, so that encoded configs follow the
+// order of the Config fields.
+type orderedMap []mapEntry
+
+// mapEntry is a key and its value in an orderedMap.
+type mapEntry struct {
+	Key   string
+	Value any
+}
+
+// MarshalJSON encodes the map as a JSON object with its keys in order, so that objects in
+// arrays (e.g., webhooks) are written as objects too.
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, entry := range m {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(jsonText(entry.Key) + ":" + jsonText(entry.Value))
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// decodeOrderedJSON decodes the next JSON value, with objects as orderedMaps, arrays as []any,
+// and numbers as json.Numbers.
+func decodeOrderedJSON(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := orderedMap{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, mapEntry{Key: key.(string), Value: value})
+		}
+		_, err = decoder.Token() // The closing brace.
+		return object, err
+	case json.Delim('['):
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token() // The closing bracket.
+		return array, err
+	}
+	return token, nil
+}
+
+// jsonText encodes a value as compact JSON, which is also valid as a YAML flow value and,
+// for strings, numbers, booleans, and arrays of them, as a TOML value.
+func jsonText(value any) string {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value) // Values decoded from JSON always encode.
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// configKey returns a key as it is written in YAML or TOML: as it is if it only contains letters,
+// digits, underscores, and dashes, and quoted otherwise.
+func configKey(key string) string {
+	if key == "" || strings.IndexFunc(key, func(r rune) bool {
+		return !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) >= 0 {
+		return jsonText(key)
+	}
+	return key
+}
+
+// writeYAML writes an object as a YAML block mapping, with nested objects indented below their key
+// and other values in JSON, which YAML reads as flow values.
+func writeYAML(b *bytes.Buffer, object orderedMap, indent string) {
+	for _, entry := range object {
+		if nested, ok := entry.Value.(orderedMap); ok && len(nested) > 0 {
+			fmt.Fprintf(b, "%s%s:\n", indent, configKey(entry.Key))
+			writeYAML(b, nested, indent+"  ")
+			continue
+		}
+		value := jsonText(entry.Value)
+		if _, ok := entry.Value.(orderedMap); ok {
+			value = "{}"
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", indent, configKey(entry.Key), value)
+	}
+}
+
+// writeTOML writes an object as the TOML table at path: its other values first, as key/value
+// pairs, then its nested objects as tables of their own, and its arrays of objects as arrays of
+// tables ([[path]]), whose items are written with arrayItem set. Empty objects are written inline as {}.
+func writeTOML(b *bytes.Buffer, object orderedMap, path []string, arrayItem bool) {
+	tables := orderedMap{}
+	pairs := []string{}
+	for _, entry := range object {
+		switch value := entry.Value.(type) {
+		case orderedMap:
+			if len(value) > 0 {
+				tables = append(tables, entry)
+			} else {
+				pairs = append(pairs, configKey(entry.Key)+" = {}")
+			}
+		case []any:
+			if isArrayOfObjects(value) {
+				tables = append(tables, entry)
+			} else {
+				pairs = append(pairs, configKey(entry.Key)+" = "+jsonText(value))
+			}
+		case nil:
+			// TOML has no null; the setting keeps its default when read back.
+		default:
+			pairs = append(pairs, configKey(entry.Key)+" = "+jsonText(value))
+		}
+	}
+	if (len(pairs) > 0 || arrayItem) && len(path) > 0 {
+		keys := make([]string, len(path))
+		for i, key := range path {
+			keys[i] = configKey(key)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if arrayItem {
+			fmt.Fprintf(b, "[[%s]]\n", strings.Join(keys, "."))
+		} else {
+			fmt.Fprintf(b, "[%s]\n", strings.Join(keys, "."))
+		}
+	}
+	for _, pair := range pairs {
+		b.WriteString(pair + "\n")
+	}
+	for _, table := range tables {
+		tablePath := append(append([]string{}, path...), table.Key)
+		if items, ok := table.Value.([]any); ok {
+			for _, item := range items {
+				writeTOML(b, item.(orderedMap), tablePath, true)
+			}
+			continue
+		}
+		writeTOML(b, table.Value.(orderedMap), tablePath, false)
+	}
+}
+
+// isArrayOfObjects reports whether an array is not empty and only holds objects, which TOML
+// writes as an array of tables.
+func isArrayOfObjects(array []any) bool {
+	for _, item := range array {
+		if _, ok := item.(orderedMap); !ok {
+			return false
+		}
+	}
+	return len(array) > 0
+}
+
+// configLine is a line of a YAML or TOML config, without its comment, and its indentation.
+type configLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// splitConfigLines splits a config into its lines that are not blank or comments, removing
+// comments after values (a # outside quotes).
+func splitConfigLines(source string) []configLine {
+	lines := []configLine{}
+	for i, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(stripConfigComment(line), " \t")
+		text := strings.TrimLeft(line, " ")
+		if text == "" {
+			continue
+		}
+		lines = append(lines, configLine{number: i + 1, indent: len(line) - len(text), text: text})
+	}
+	return lines
+}
+
+// stripConfigComment removes a comment starting with # from a line, unless the # is quoted.
+func stripConfigComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++ // Skip the escaped character.
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAML parses a YAML config: nested block mappings, block sequences of values (- item) and
+// of mappings (- key: value, with the other keys indented below the first), and values that are
+// plain, quoted, or flow sequences and mappings in JSON syntax (e.g., ["a", "b"]).
+// Anchors, multi-line strings, and multiple documents are not supported.
+func parseYAML(source string) (map[string]any, error) {
+	lines := []configLine{}
+	for _, line := range splitConfigLines(source) {
+		if line.text != "---" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	p := &yamlParser{lines: lines}
+	value, err := p.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, fmt.Errorf("invalid YAML on line %d: unexpected indentation", lines[p.pos].number)
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid YAML config: expected a mapping of settings")
+	}
+	return object, nil
+}
+
+// yamlParser parses the lines of a YAML config.
+type yamlParser struct {
+	lines []configLine
+	pos   int
+}
+
+// parseBlock parses the mapping or sequence whose lines start at indent.
+func (p *yamlParser) parseBlock(indent int) (any, error) {
+	if line := p.lines[p.pos]; line.text == "-" || strings.HasPrefix(line.text, "- ") {
+		return p.parseSequence(indent)
+	}
+	object := map[string]any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		key, rest, err := splitYAMLKey(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML on line %d: %w", line.number, err)
+		}
+		p.pos++
+		if rest != "" {
+			if object[key], err = parseYAMLValue(rest); err != nil {
+				return nil, fmt.Errorf("invalid YAML on line %d: %w", line.number, err)
+			}
+			continue
+		}
+		// A key without a value introduces a nested block, which may also be a sequence at the same indentation.
+		if next := p.peek(); next != nil && (next.indent > indent || next.indent == indent && strings.HasPrefix(next.text, "-")) {
+			if object[key], err = p.parseBlock(next.indent); err != nil {
+				return nil, err
+			}
+		} else {
+			object[key] = nil
+		}
+	}
+	return object, nil
+}
+
+// parseSequence parses a block sequence whose items start at indent.
+func (p *yamlParser) parseSequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && (p.lines[p.pos].text == "-" || strings.HasPrefix(p.lines[p.pos].text, "- ")) {
+		line := p.lines[p.pos]
+		item := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if _, _, err := splitYAMLKey(item); item != "" && item[0] != '[' && item[0] != '{' && err == nil {
+			// A mapping, which starts after the dash: parse the item as its first line.
+			p.lines[p.pos] = configLine{number: line.number, indent: line.indent + len(line.text) - len(item), text: item}
+			value, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+		p.pos++
+		if item == "" {
+			next := p.peek()
+			if next == nil || next.indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			value, err := p.parseBlock(next.indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+		value, err := parseYAMLValue(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML on line %d: %w", line.number, err)
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+// peek returns the next line, or nil at the end of the config.
+func (p *yamlParser) peek() *configLine {
+	if p.pos < len(p.lines) {
+		return &p.lines[p.pos]
+	}
+	return nil
+}
+
+// splitYAMLKey splits a "key: value" line into its key, which may be quoted, and its value.
+func splitYAMLKey(text string) (key, rest string, err error) {
+	end := -1
+	if text[0] == '"' || text[0] == '\'' {
+		quoted, after, err := cutQuoted(text)
+		if err != nil {
+			return "", "", err
+		}
+		if !strings.HasPrefix(after, ":") {
+			return "", "", fmt.Errorf("expected \":\" after key %s", text[:len(text)-len(after)])
+		}
+		return quoted, strings.TrimSpace(after[1:]), nil
+	}
+	if i := strings.Index(text, ": "); i >= 0 {
+		end = i
+	} else if strings.HasSuffix(text, ":") {
+		end = len(text) - 1
+	}
+	if end <= 0 {
+		return "", "", fmt.Errorf("expected \"key: value\", got %q", text)
+	}
+	return strings.TrimSpace(text[:end]), strings.TrimSpace(text[end+1:]), nil
+}
+
+// parseYAMLValue parses a YAML value written on one line.
+func parseYAMLValue(text string) (any, error) {
+	switch {
+	case text[0] == '"' || text[0] == '\'':
+		value, rest, err := cutQuoted(text)
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		return value, err
+	case text[0] == '[' || text[0] == '{':
+		var value any
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return nil, fmt.Errorf("unsupported flow value %s: write it in JSON syntax", text)
+		}
+		return value, nil
+	case text == "~" || text == "null":
+		return nil, nil
+	case text == "true" || text == "false":
+		return text == "true", nil
+	}
+	if number, ok := parseConfigNumber(text); ok {
+		return number, nil
+	}
+	return text, nil
+}
+
+// cutQuoted returns the string quoted at the start of text, in double quotes with JSON escapes
+// or in single quotes (where ” is a quote in YAML), and the text after it.
+func cutQuoted(text string) (string, string, error) {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			if quote == '\'' {
+				return strings.ReplaceAll(text[1:i], "''", "'"), text[i+1:], nil
+			}
+			var value string
+			if err := json.Unmarshal([]byte(text[:i+1]), &value); err != nil {
+				return "", "", fmt.Errorf("invalid string %s", text[:i+1])
+			}
+			return value, text[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", text)
+}
+
+// parseConfigNumber parses an integer or a floating-point number, which may contain underscores
+// between digits as in TOML. Returns false if text is not a number.
+func parseConfigNumber(text string) (any, bool) {
+	digits := strings.ReplaceAll(text, "_", "")
+	if integer, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return integer, true
+	}
+	if float, err := strconv.ParseFloat(digits, 64); err == nil && strings.IndexAny(digits, "0123456789") >= 0 {
+		return float, true
+	}
+	return nil, false
+}
+
+// parseTOML parses a TOML config: key/value pairs, [table] and [[array of tables]] headers, and
+// values that are strings, numbers, booleans, arrays, or inline tables. Arrays may span several
+// lines. Dates are not supported.
+func parseTOML(source string) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	lines := splitConfigLines(source)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		text := strings.TrimSpace(line.text)
+		if strings.HasPrefix(text, "[[") {
+			if !strings.HasSuffix(text, "]]") {
+				return nil, fmt.Errorf("invalid TOML on line %d: unterminated array of tables header", line.number)
+			}
+			keys, err := splitTOMLKey(text[2 : len(text)-2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+			}
+			if table, err = tomlArrayItem(root, keys); err != nil {
+				return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("invalid TOML on line %d: unterminated table header", line.number)
+			}
+			keys, err := splitTOMLKey(text[1 : len(text)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+			}
+			if table, err = tomlTable(root, keys); err != nil {
+				return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+			}
+			continue
+		}
+		keyText, valueText, found := strings.Cut(text, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid TOML on line %d: expected \"key = value\"", line.number)
+		}
+		// An array may continue on the following lines, until its brackets are balanced.
+		for strings.HasPrefix(strings.TrimSpace(valueText), "[") && !balancedBrackets(valueText) && i+1 < len(lines) {
+			i++
+			valueText += " " + lines[i].text
+		}
+		keys, err := splitTOMLKey(keyText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+		}
+		value, rest, err := parseTOMLValue(strings.TrimSpace(valueText))
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = fmt.Errorf("unexpected %q after value", strings.TrimSpace(rest))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+		}
+		parent, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOML on line %d: %w", line.number, err)
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+	return root, nil
+}
+
+// tomlTable returns the table at the dotted keys below root, creating the tables that are missing.
+// A key naming an array of tables refers to its last table.
+func tomlTable(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for _, key := range keys {
+		next, exists := table[key]
+		if !exists {
+			next = map[string]any{}
+			table[key] = next
+		}
+		if items, ok := next.([]any); ok && len(items) > 0 {
+			next = items[len(items)-1]
+		}
+		nested, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a table", key)
+		}
+		table = nested
+	}
+	return table, nil
+}
+
+// isArrayOfTables reports whether a parsed TOML array only holds tables.
+func isArrayOfTables(array []any) bool {
+	for _, item := range array {
+		if _, ok := item.(map[string]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlArrayItem appends a table to the array of tables at the dotted keys below root, creating
+// the array if it is missing, and returns the new table.
+func tomlArrayItem(root map[string]any, keys []string) (map[string]any, error) {
+	parent, err := tomlTable(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := keys[len(keys)-1]
+	items, ok := parent[key].([]any)
+	if _, exists := parent[key]; exists && !(ok && isArrayOfTables(items)) {
+		return nil, fmt.Errorf("%s is not an array of tables", key)
+	}
+	item := map[string]any{}
+	parent[key] = append(items, item)
+	return item, nil
+}
+
+// splitTOMLKey splits a key, which may be dotted and quoted (e.g., themes."my theme"), into its parts.
+func splitTOMLKey(text string) ([]string, error) {
+	keys := []string{}
+	text = strings.TrimSpace(text)
+	for text != "" {
+		var key string
+		if text[0] == '"' || text[0] == '\'' {
+			quoted, rest, err := cutQuoted(text)
+			if err != nil {
+				return nil, err
+			}
+			key, text = quoted, strings.TrimSpace(rest)
+		} else {
+			end := strings.IndexByte(text, '.')
+			if end < 0 {
+				end = len(text)
+			}
+			key, text = strings.TrimSpace(text[:end]), strings.TrimSpace(text[end:])
+			if key == "" {
+				return nil, fmt.Errorf("empty key")
+			}
+		}
+		keys = append(keys, key)
+		if text != "" {
+			if text[0] != '.' {
+				return nil, fmt.Errorf("unexpected %q in key", text)
+			}
+			text = strings.TrimSpace(text[1:])
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("missing key")
+	}
+	return keys, nil
+}
+
+// balancedBrackets reports whether every [ and { outside strings in text is closed.
+func balancedBrackets(text string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// parseTOMLValue parses the TOML value at the start of text, and returns it with the text after it.
+func parseTOMLValue(text string) (any, string, error) {
+	if text == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+	switch text[0] {
+	case '"', '\'':
+		if strings.HasPrefix(text, `"""`) || strings.HasPrefix(text, "'''") {
+			return nil, "", fmt.Errorf("multi-line strings are not supported")
+		}
+		value, rest, err := cutQuoted(text)
+		return value, rest, err
+	case '[':
+		array := []any{}
+		rest := strings.TrimSpace(text[1:])
+		for !strings.HasPrefix(rest, "]") {
+			value, after, err := parseTOMLValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			array = append(array, value)
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("expected , or ] in array")
+			}
+		}
+		return array, rest[1:], nil
+	case '{':
+		table := map[string]any{}
+		rest := strings.TrimSpace(text[1:])
+		for !strings.HasPrefix(rest, "}") {
+			keyText, after, found := strings.Cut(rest, "=")
+			if !found {
+				return nil, "", fmt.Errorf("expected key = value in inline table")
+			}
+			keys, err := splitTOMLKey(keyText)
+			if err != nil {
+				return nil, "", err
+			}
+			value, after, err := parseTOMLValue(strings.TrimSpace(after))
+			if err != nil {
+				return nil, "", err
+			}
+			parent, err := tomlTable(table, keys[:len(keys)-1])
+			if err != nil {
+				return nil, "", err
+			}
+			parent[keys[len(keys)-1]] = value
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "}") {
+				return nil, "", fmt.Errorf("expected , or } in inline table")
+			}
+		}
+		return table, rest[1:], nil
+	}
+	end := strings.IndexAny(text, ",]} \t")
+	if end < 0 {
+		end = len(text)
+	}
+	word, rest := text[:end], text[end:]
+	if word == "true" || word == "false" {
+		return word == "true", rest, nil
+	}
+	if number, ok := parseConfigNumber(word); ok {
+		return number, rest, nil
+	}
+	return nil, "", fmt.Errorf("unsupported value %q: quote strings", word)
+}
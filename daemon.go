@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"          // Package for recognizing the daemon command among the arguments
+	"fmt"           // Package for formatted I/O (e.g., notification texts)
+	"net/smtp"      // Package for sending the daily digest
+	"os"            // Package for receiving stop signals
+	"os/signal"     // Package for stopping the daemon on Ctrl-C or SIGTERM
+	"syscall"       // Package for the SIGTERM signal
+	"time"          // Package for scheduling reminders
+	"todo/pkg/todo" // Package for loading the todo list and reading due times
+)
+
+// dateOnlyDueHour is the hour of the day, in local time, at which a todo with a due date
+// but no time of day counts as due, for reminders.
+const dateOnlyDueHour = 9
+
+// Where the reminder daemon logs to, as set by the daemon_log config setting.
+const (
+	daemonLogFile   = "file"   // To stderr and log_file_path, like any other command.
+	daemonLogSyslog = "syslog" // To stderr and the system log (e.g., the systemd journal).
+)
+
+// isDaemonCommand reports whether the program was started as the reminder daemon ("todo daemon").
+func isDaemonCommand() bool {
+	return flag.NArg() == 1 && flag.Arg(0) == "daemon"
+}
+
+// reminderLeadTime returns how long before its due time a todo is reminded of: the lead time
+// configured for its priority, or the "default" one. Returns false if neither is configured.
+func reminderLeadTime(todo Todo, leadTimes map[string]Duration) (time.Duration, bool) {
+	if lead, ok := leadTimes[string(todo.Priority.Canonical())]; ok {
+		return time.Duration(lead), true
+	}
+	lead, ok := leadTimes["default"]
+	return time.Duration(lead), ok
+}
+
+// dueTime returns when a todo with a due date is due, in local time: at its due time, or
+// at dateOnlyDueHour on its due date if it has no time of day.
+func dueTime(t Todo) time.Time {
+	due := *t.DueDate
+	if todo.HasTimeOfDay(due) {
+		return time.Date(due.Year(), due.Month(), due.Day(), due.Hour(), due.Minute(), 0, 0, time.Local)
+	}
+	return time.Date(due.Year(), due.Month(), due.Day(), dateOnlyDueHour, 0, 0, 0, time.Local)
+}
+
+// reminderKey identifies a reminder, so that it is sent only once, but again if the due date or time changes.
+func reminderKey(t Todo) string {
+	return fmt.Sprintf("%d@%s", t.ID, t.DueDate.Format("2006-01-02 "+todo.DueTimeLayout))
+}
+
+// dueReminders returns the open todos of the list whose reminder time has come but that are not
+// due yet, leaving out those in sent. Deferred todos are not reminded of.
+func dueReminders(tl *TodoList, leadTimes map[string]Duration, sent map[string]bool, now time.Time) []Todo {
+	due := []Todo{}
+	for _, todo := range tl.Todos {
+		if !todo.IsOpen() || todo.DueDate == nil || todo.IsDeferred(now) || sent[reminderKey(todo)] {
+			continue
+		}
+		lead, ok := reminderLeadTime(todo, leadTimes)
+		if !ok {
+			continue
+		}
+		if at := dueTime(todo); !now.Before(at.Add(-lead)) && now.Before(at) {
+			due = append(due, todo)
+		}
+	}
+	return due
+}
+
+// checkReminders loads the todo list from dataFile and sends a notification for each todo whose
+// reminder time has come, recording it in sent. Returns the todos that were reminded of.
+func checkReminders(dataFile string, leadTimes map[string]Duration, sent map[string]bool, now time.Time, notify func(title, message string) error) ([]Todo, error) {
+	todoList, err := todo.LoadFromFile(dataFile) // Reloaded every time, since other instances change it.
+	if err != nil {
+		return nil, err
+	}
+	reminded := dueReminders(todoList, leadTimes, sent, now)
+	for _, todo := range reminded {
+		sent[reminderKey(todo)] = true
+		title := fmt.Sprintf("Todo #%d is due %s", todo.ID, dueTime(todo).Format("Mon Jan 2 15:04"))
+		if err := notify(title, todo.Task); err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to send the reminder for todo #%d", todo.ID))
+		}
+	}
+	return reminded, nil
+}
+
+// runDaemon runs the reminder daemon: it checks the data file every interval and sends a desktop
+// notification when an open todo is due within its reminder lead time, until it is stopped with
+// Ctrl-C or SIGTERM. If digest_time is set, it also emails the digest once a day after that time.
+// It never saves the list. Reminders and digests are remembered while it runs, so a restarted
+// daemon sends the reminders of todos that are not due yet, and the digest of the day, once more.
+func runDaemon(config Config) {
+	interval := time.Duration(config.DaemonInterval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if _, err := time.Parse(todo.DueTimeLayout, config.DigestTime); config.DigestTime != "" && err != nil {
+		logger.Warn(fmt.Sprintf("Invalid digest_time %q in the config: use HH:MM. No digest will be sent.", config.DigestTime))
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	PrintUserMessage(fmt.Sprintf("🔔 Watching %s for due todos every %s. Press Ctrl-C to stop.", config.DataFile, interval))
+	sent := map[string]bool{}
+	lastDigest := "" // Day the digest was last sent on.
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		reminded, err := checkReminders(config.DataFile, config.ReminderLeadTimes, sent, time.Now(), sendNotification)
+		if err != nil {
+			logger.Error(err, "Failed to check reminders")
+		}
+		for _, todo := range reminded {
+			PrintUserMessage(fmt.Sprintf("🔔 Reminded of todo #%d: \"%s\" (due %s)", todo.ID, todo.Task, formatDate(*todo.DueDate)))
+		}
+		if now := time.Now(); digestDue(config.DigestTime, lastDigest, now) {
+			lastDigest = now.Format("2006-01-02")
+			if digest, sent, err := sendDailyDigest(config.DataFile, config.SMTP, now, smtp.SendMail); err != nil {
+				logger.Error(err, "Failed to send the email digest")
+			} else if sent {
+				PrintUserMessage("📧 Sent the digest: " + digest.subject())
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			PrintUserMessage("👋 Reminder daemon stopped.")
+			return
+		}
+	}
+}
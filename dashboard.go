@@ -0,0 +1,77 @@
+package main
+
+import (
+	"path/filepath" // Package for recognizing the active list among the configured ones
+	"sort"          // Package for ordering the configured lists by name
+	"time"          // Package for determining which todos are due today or overdue
+	"todo/pkg/todo" // Package for loading the todo list
+)
+
+// dashboardLists are the other todo lists shown by the dashboard command, by name (e.g., "work": "work.json").
+// They are set from the lists config setting.
+var dashboardLists = map[string]string{}
+
+// ListSummary is one list's line on the dashboard.
+type ListSummary struct {
+	List     string  `json:"list"`              // Name of the list.
+	DataFile string  `json:"data_file"`         // File the list is stored in.
+	Open     int     `json:"open"`              // Number of open todos.
+	DueToday int     `json:"due_today"`         // Number of open todos due today.
+	Overdue  int     `json:"overdue"`           // Number of open todos whose due date has passed.
+	Top      *Todo   `json:"top,omitempty"`     // The most urgent open todo that is not deferred, if any.
+	Urgency  float64 `json:"urgency,omitempty"` // Urgency score of the top todo.
+	Error    string  `json:"error,omitempty"`   // Why the list could not be loaded, if it could not.
+}
+
+// summarizeList counts the open, due, and overdue todos of a list, and finds its most urgent todo.
+func summarizeList(name string, dataFile string, todoList *TodoList, now time.Time) ListSummary {
+	summary := ListSummary{List: name, DataFile: dataFile}
+	today := now.Format("2006-01-02")
+	for _, todo := range todoList.Todos {
+		if !todo.IsOpen() {
+			continue
+		}
+		summary.Open++
+		if todo.DueDate != nil {
+			if due := todo.DueDate.Format("2006-01-02"); due == today {
+				summary.DueToday++
+			} else if due < today {
+				summary.Overdue++
+			}
+		}
+		if todo.IsDeferred(now) {
+			continue
+		}
+		if score := todo.Urgency(now); summary.Top == nil || score > summary.Urgency {
+			top := todo
+			summary.Top, summary.Urgency = &top, score
+		}
+	}
+	return summary
+}
+
+// Dashboard summarizes the active list, followed by the other configured lists in order of
+// their names. A configured list stored in the active data file is not repeated, and one that
+// cannot be loaded is shown with its error.
+func Dashboard(todoList *TodoList, dataFile string, lists map[string]string, now time.Time) []ListSummary {
+	summaries := []ListSummary{summarizeList(listNameOf(dataFile), dataFile, todoList, now)}
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := lists[name]
+		if filepath.Clean(file) == filepath.Clean(dataFile) {
+			continue
+		}
+		other, err := todo.LoadFromFile(file)
+		if err != nil {
+			logger.Error(err, "Failed to load list "+name+" for the dashboard")
+			summaries = append(summaries, ListSummary{List: name, DataFile: file, Error: err.Error()})
+			continue
+		}
+		summaries = append(summaries, summarizeList(name, file, other, now))
+	}
+	return summaries
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"       // Package for string manipulation
+	"time"          // Package for working with dates
+	"todo/pkg/todo" // Package for parsing dates and due times
+)
+
+// invalidDateMessage tells the user which dates are accepted.
+const invalidDateMessage = "Invalid date. Use YYYY-MM-DD, words like today, tomorrow, friday, next week, in 3 days, or end of month, or an offset like +3d, +2w, or eom."
+
+// maxDateWords is the number of words in the longest date phrase (e.g., "in 3 days 14:00").
+const maxDateWords = 4
+
+// formatDate formats a date for display in the date format (YYYY-MM-DD by default), followed by its
+// time of day if it has one.
+func formatDate(date time.Time) string {
+	if todo.HasTimeOfDay(date) {
+		return date.Format(dateFormat + " " + todo.DueTimeLayout)
+	}
+	return date.Format(dateFormat)
+}
+
+// dateArg returns the date given at parts[i] of a command, which may span several words
+// (e.g., "in 3 days"), and the index of its last word. The longest phrase that is a valid
+// date is taken; if none is, the single word at parts[i] is returned, so that its error is reported.
+func dateArg(parts []string, i int) (string, int) {
+	for words := min(maxDateWords, len(parts)-i); words > 1; words-- {
+		phrase := strings.Join(parts[i:i+words], " ")
+		if _, err := todo.ParseDueDate(phrase); err == nil {
+			return phrase, i + words - 1
+		}
+	}
+	return parts[i], i
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strconv" // Package for parsing lists of todo IDs
+	"strings" // Package for string manipulation
+)
+
+// formatTodoIDs formats todo IDs as a comma-separated list (e.g., "#2, #5").
+func formatTodoIDs(ids []int) string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = "#" + strconv.Itoa(id)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// parseTodoIDs parses a comma-separated list of todo IDs (e.g., "2,5" or "#2, #5").
+func parseTodoIDs(value string) ([]int, error) {
+	ids := []int{}
+	for _, field := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(field), "#"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid todo ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
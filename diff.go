@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"           // Package for formatted I/O (e.g., describing field changes)
+	"os"            // Package for checking that snapshot files exist
+	"reflect"       // Package for reflection, used for deep comparison of todos
+	"strings"       // Package for string manipulation
+	"time"          // Package for formatting estimates and time spent
+	"todo/pkg/todo" // Package for loading snapshots of the todo list
+)
+
+// TodoChange describes a todo item that exists in both lists but differs between them.
+type TodoChange struct {
+	Before  Todo     `json:"before"`  // The todo as it was in the original list.
+	After   Todo     `json:"after"`   // The todo as it is in the changed list.
+	Changes []string `json:"changes"` // Human-readable descriptions of the changed fields.
+}
+
+// TodoListDiff holds the differences between two states of a TodoList, matched by todo ID.
+type TodoListDiff struct {
+	Added    []Todo       `json:"added"`    // Todos only present in the changed list.
+	Deleted  []Todo       `json:"deleted"`  // Todos only present in the original list.
+	Modified []TodoChange `json:"modified"` // Todos present in both lists with different contents.
+}
+
+// IsEmpty reports whether the diff contains no changes at all.
+func (d TodoListDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Deleted) == 0 && len(d.Modified) == 0
+}
+
+// changeCount returns the number of todos added, deleted, or modified.
+func (d TodoListDiff) changeCount() int {
+	return len(d.Added) + len(d.Deleted) + len(d.Modified)
+}
+
+// Completed returns the modified todos that were completed between the two states.
+func (d TodoListDiff) Completed() []Todo {
+	completed := []Todo{}
+	for _, change := range d.Modified {
+		if !change.Before.Completed && change.After.Completed {
+			completed = append(completed, change.After)
+		}
+	}
+	return completed
+}
+
+// SnapshotDiff holds the differences between two saved states of a todo list, e.g., a backup
+// and the current list, with the todos completed in between listed separately.
+type SnapshotDiff struct {
+	From         string `json:"from"` // File of the earlier state.
+	To           string `json:"to"`   // File of the later state, or "current list".
+	TodoListDiff        // Added, deleted, and modified todos.
+	Completed    []Todo `json:"completed"` // Modified todos that were completed.
+}
+
+// DiffSnapshots compares the todo list saved in the file from with the one saved in the file to,
+// or with the current todo list if to is empty. Returns an error if a file does not exist or
+// cannot be loaded.
+func DiffSnapshots(todoList *TodoList, from, to string) (SnapshotDiff, error) {
+	before, err := loadSnapshot(from)
+	if err != nil {
+		return SnapshotDiff{}, err
+	}
+	after, label := todoList, "current list"
+	if to != "" {
+		if after, err = loadSnapshot(to); err != nil {
+			return SnapshotDiff{}, err
+		}
+		label = to
+	}
+	diff := DiffTodoLists(before, after)
+	return SnapshotDiff{From: from, To: label, TodoListDiff: diff, Completed: diff.Completed()}, nil
+}
+
+// loadSnapshot loads a saved todo list. Unlike LoadFromFile, it fails if the file does not exist,
+// since comparing with an empty list would report every todo as added or deleted.
+func loadSnapshot(file string) (*TodoList, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", file, err)
+	}
+	return todo.LoadFromFile(file)
+}
+
+// DiffTodoLists compares two states of a TodoList and returns the added, deleted,
+// and modified todos, matched by their IDs. The results follow the order of the todos
+// in the list they were found in.
+func DiffTodoLists(before, after *TodoList) TodoListDiff {
+	diff := TodoListDiff{Added: []Todo{}, Deleted: []Todo{}, Modified: []TodoChange{}}
+
+	afterByID := make(map[int]Todo, len(after.Todos))
+	for _, todo := range after.Todos {
+		afterByID[todo.ID] = todo
+	}
+	beforeIDs := make(map[int]bool, len(before.Todos))
+
+	for _, old := range before.Todos {
+		beforeIDs[old.ID] = true
+		updated, ok := afterByID[old.ID]
+		if !ok {
+			diff.Deleted = append(diff.Deleted, old)
+			continue
+		}
+		if changes := describeTodoChanges(old, updated); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, TodoChange{Before: old, After: updated, Changes: changes})
+		}
+	}
+	for _, todo := range after.Todos {
+		if !beforeIDs[todo.ID] {
+			diff.Added = append(diff.Added, todo)
+		}
+	}
+	return diff
+}
+
+// describeTodoChanges returns a human-readable description of each field that differs
+// between two versions of the same todo.
+func describeTodoChanges(before, after Todo) []string {
+	changes := []string{}
+	if before.Task != after.Task {
+		changes = append(changes, fmt.Sprintf("task: %q -> %q", before.Task, after.Task))
+	}
+	// Completing or uncompleting a todo is reported as a change of completed alone.
+	if before.Status != after.Status && before.Completed == after.Completed {
+		changes = append(changes, fmt.Sprintf("status: %s -> %s", before.Status, after.Status))
+	}
+	if before.Completed != after.Completed {
+		changes = append(changes, fmt.Sprintf("completed: %t -> %t", before.Completed, after.Completed))
+	}
+	if before.Priority != after.Priority {
+		changes = append(changes, fmt.Sprintf("priority: %s -> %s", before.Priority, after.Priority))
+	}
+	if !reflect.DeepEqual(before.DueDate, after.DueDate) {
+		changes = append(changes, fmt.Sprintf("due date: %s -> %s", formatOptionalDate(before.DueDate), formatOptionalDate(after.DueDate)))
+	}
+	if !reflect.DeepEqual(before.Links, after.Links) {
+		changes = append(changes, fmt.Sprintf("links: %v -> %v", before.Links, after.Links))
+	}
+	if before.Estimate != after.Estimate {
+		changes = append(changes, fmt.Sprintf("estimate: %s -> %s", formatDuration(time.Duration(before.Estimate)), formatDuration(time.Duration(after.Estimate))))
+	}
+	if before.TimeSpent != after.TimeSpent {
+		changes = append(changes, fmt.Sprintf("time spent: %s -> %s", formatDuration(time.Duration(before.TimeSpent)), formatDuration(time.Duration(after.TimeSpent))))
+	}
+	if !reflect.DeepEqual(before.StartDate, after.StartDate) {
+		changes = append(changes, fmt.Sprintf("starts: %s -> %s", formatOptionalDate(before.StartDate), formatOptionalDate(after.StartDate)))
+	}
+	if !reflect.DeepEqual(before.ExpiresAt, after.ExpiresAt) {
+		changes = append(changes, fmt.Sprintf("expires: %s -> %s", formatOptionalDate(before.ExpiresAt), formatOptionalDate(after.ExpiresAt)))
+	}
+	if before.Expired != after.Expired {
+		changes = append(changes, fmt.Sprintf("expired: %t -> %t", before.Expired, after.Expired))
+	}
+	if len(before.Snoozes) != len(after.Snoozes) {
+		changes = append(changes, fmt.Sprintf("snoozed: %d -> %d times", len(before.Snoozes), len(after.Snoozes)))
+	}
+	if formatTodoIDs(before.DependsOn) != formatTodoIDs(after.DependsOn) {
+		changes = append(changes, fmt.Sprintf("depends on: [%s] -> [%s]", formatTodoIDs(before.DependsOn), formatTodoIDs(after.DependsOn)))
+	}
+	if before.ParentID != after.ParentID {
+		changes = append(changes, fmt.Sprintf("parent: #%d -> #%d", before.ParentID, after.ParentID))
+	}
+	if before.Order != after.Order {
+		changes = append(changes, fmt.Sprintf("order: %d -> %d", before.Order, after.Order))
+	}
+	if before.Project != after.Project {
+		changes = append(changes, fmt.Sprintf("project: %q -> %q", before.Project, after.Project))
+	}
+	if before.Recurrence != after.Recurrence {
+		changes = append(changes, fmt.Sprintf("recurrence: %q -> %q", before.Recurrence, after.Recurrence))
+	}
+	if before.RecurFrom != after.RecurFrom || before.RecurOverdue != after.RecurOverdue {
+		changes = append(changes, fmt.Sprintf("recurrence rules: %q/%q -> %q/%q", before.RecurFrom, before.RecurOverdue, after.RecurFrom, after.RecurOverdue))
+	}
+	if strings.Join(before.Tags, ",") != strings.Join(after.Tags, ",") {
+		changes = append(changes, fmt.Sprintf("tags: [%s] -> [%s]", strings.Join(before.Tags, ", "), strings.Join(after.Tags, ", ")))
+	}
+	return changes
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"        // Package for reporting incomplete SMTP settings
+	"fmt"           // Package for formatted I/O (e.g., the digest text)
+	"net"           // Package for joining the SMTP host and port
+	"net/smtp"      // Package for sending the digest by email
+	"strconv"       // Package for formatting the SMTP port
+	"strings"       // Package for building the email message
+	"time"          // Package for determining which todos are due
+	"todo/pkg/todo" // Package for loading the todo list and parsing the digest time
+)
+
+// SMTPConfig holds the settings for sending the email digest.
+// They are set from the smtp config setting.
+type SMTPConfig struct {
+	Host     string   `json:"host"`     // SMTP server (e.g., "smtp.example.com").
+	Port     int      `json:"port"`     // SMTP port; 587 is used for STARTTLS submission.
+	Username string   `json:"username"` // User to log in as; no login if empty.
+	Password string   `json:"password"` // Password to log in with.
+	From     string   `json:"from"`     // Sender address of the digest.
+	To       []string `json:"to"`       // Recipient addresses of the digest.
+}
+
+// smtpSettings are the SMTP settings used by the email-digest command.
+var smtpSettings SMTPConfig
+
+// validate reports which required SMTP setting is missing, if any.
+func (c SMTPConfig) validate() error {
+	switch {
+	case c.Host == "":
+		return errors.New("smtp.host is not set in the config")
+	case c.From == "":
+		return errors.New("smtp.from is not set in the config")
+	case len(c.To) == 0:
+		return errors.New("smtp.to is not set in the config")
+	}
+	return nil
+}
+
+// Digest summarizes the open todos that need attention: those that are overdue, due today,
+// and due later this week (until Sunday).
+type Digest struct {
+	Date        time.Time `json:"date"`          // Day the digest is for.
+	Overdue     []Todo    `json:"overdue"`       // Open todos whose due date has passed.
+	DueToday    []Todo    `json:"due_today"`     // Open todos due today.
+	DueThisWeek []Todo    `json:"due_this_week"` // Open todos due after today, until Sunday.
+}
+
+// buildDigest returns the digest of the todo list for the day of now, built from its agenda.
+func buildDigest(tl *TodoList, now time.Time) Digest {
+	digest := Digest{Date: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+	for _, group := range tl.Agenda(now) {
+		switch group.Name {
+		case AgendaOverdue:
+			digest.Overdue = group.Todos
+		case AgendaToday:
+			digest.DueToday = group.Todos
+		case AgendaTomorrow, AgendaThisWeek:
+			digest.DueThisWeek = append(digest.DueThisWeek, group.Todos...)
+		}
+	}
+	if digest.DueThisWeek == nil {
+		digest.DueThisWeek = []Todo{}
+	}
+	return digest
+}
+
+// isEmpty reports whether nothing is overdue or due this week.
+func (d Digest) isEmpty() bool {
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.DueThisWeek) == 0
+}
+
+// subject returns the subject line of the digest email, with the number of todos in each section.
+func (d Digest) subject() string {
+	return fmt.Sprintf("Todo digest for %s: %d overdue, %d due today, %d due this week",
+		d.Date.Format("Mon Jan 2"), len(d.Overdue), len(d.DueToday), len(d.DueThisWeek))
+}
+
+// text returns the plain text body of the digest email.
+func (d Digest) text() string {
+	var b strings.Builder
+	for _, section := range []struct {
+		title string
+		todos []Todo
+	}{{"Overdue", d.Overdue}, {"Due today", d.DueToday}, {"Due this week", d.DueThisWeek}} {
+		if len(section.todos) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s (%d):\n", section.title, len(section.todos))
+		for _, todo := range section.todos {
+			fmt.Fprintf(&b, "  #%d %s (%s, due %s)\n", todo.ID, todo.Task, todo.Priority, formatDate(*todo.DueDate))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// digestMessage builds the email carrying the digest, with CRLF line endings as SMTP requires.
+func digestMessage(settings SMTPConfig, digest Digest, now time.Time) []byte {
+	headers := []string{
+		"From: " + settings.From,
+		"To: " + strings.Join(settings.To, ", "),
+		"Subject: " + digest.subject(),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.ReplaceAll(digest.text(), "\n", "\r\n")
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
+
+// sendDigest emails the digest of the todo list for the day of now to the configured recipients
+// with send (smtp.SendMail). Nothing is sent if no todo is overdue or due this week; the returned
+// bool reports whether it was sent.
+func sendDigest(todoList *TodoList, settings SMTPConfig, now time.Time, send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error) (Digest, bool, error) {
+	if err := settings.validate(); err != nil {
+		return Digest{}, false, err
+	}
+	digest := buildDigest(todoList, now)
+	if digest.isEmpty() {
+		return digest, false, nil
+	}
+	port := settings.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(port))
+	if err := send(addr, auth, settings.From, settings.To, digestMessage(settings, digest, now)); err != nil {
+		return digest, false, fmt.Errorf("failed to send the digest through %s: %w", addr, err)
+	}
+	return digest, true, nil
+}
+
+// digestDue reports whether the daily digest should be sent at now: once the digest time
+// ("HH:MM", local time) has passed today, unless it was already sent today (lastSent, YYYY-MM-DD).
+func digestDue(digestTime string, lastSent string, now time.Time) bool {
+	clock, err := time.Parse(todo.DueTimeLayout, digestTime)
+	if err != nil {
+		return false
+	}
+	today := now.Format("2006-01-02")
+	at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	return lastSent != today && !now.Before(at)
+}
+
+// sendDailyDigest loads the todo list from dataFile and emails its digest, for the reminder daemon.
+func sendDailyDigest(dataFile string, settings SMTPConfig, now time.Time, send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error) (Digest, bool, error) {
+	todoList, err := todo.LoadFromFile(dataFile) // Reloaded every time, since other instances change it.
+	if err != nil {
+		return Digest{}, false, err
+	}
+	return sendDigest(todoList, settings, now, send)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"   // Package for buffered I/O operations (e.g., reading keystrokes)
+	"fmt"     // Package for formatted I/O (e.g., redrawing the input line)
+	"io"      // Package for I/O primitives
+	"os"      // Package for operating system functionalities (e.g., history file access)
+	"strings" // Package for string manipulation
+	"unicode" // Package for classifying runes (e.g., printable characters)
+)
+
+// maxHistoryEntries limits how many history entries are kept in memory and loaded from the history file.
+const maxHistoryEntries = 1000
+
+// Control keys understood by the line editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyCtrlK     = 11
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// lineEditor reads command lines for interactive mode. When the input is a terminal,
+// it supports cursor movement, Emacs-style editing keys (Ctrl-A/E/B/F/K/U/W),
+// and arrow-key history that is persisted to a file. Otherwise, it falls back to
+// plain line-by-line reading, so piped input keeps working.
+type lineEditor struct {
+	reader      *bufio.Reader
+	out         io.Writer
+	fd          int
+	terminal    bool
+	history     []string
+	historyFile string
+}
+
+// newLineEditor creates a line editor reading from in and echoing to out.
+// If historyFile is not empty, previous history is loaded from it and new entries are appended to it.
+func newLineEditor(in io.Reader, out io.Writer, historyFile string) *lineEditor {
+	editor := &lineEditor{
+		reader:      bufio.NewReader(in),
+		out:         out,
+		history:     []string{},
+		historyFile: historyFile,
+	}
+	if file, ok := in.(*os.File); ok && isTerminal(int(file.Fd())) {
+		editor.terminal = true
+		editor.fd = int(file.Fd())
+	}
+	editor.loadHistory()
+	return editor
+}
+
+// ReadLine prints the prompt and reads one line of input without the trailing newline,
+// recording it in the history. It returns io.EOF when the input is closed
+// (or Ctrl-D is pressed on an empty line).
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	return e.readLine(prompt, true)
+}
+
+// Ask works like ReadLine, but does not record the answer in the history.
+// It is meant for follow-up questions asked by commands.
+func (e *lineEditor) Ask(prompt string) (string, error) {
+	return e.readLine(prompt, false)
+}
+
+// readLine implements ReadLine and Ask.
+func (e *lineEditor) readLine(prompt string, recordHistory bool) (string, error) {
+	if e.terminal {
+		state, err := makeRaw(e.fd)
+		if err == nil {
+			defer restoreTerminal(e.fd, state)
+			line, err := e.editLine(prompt)
+			if err == nil && recordHistory {
+				e.addHistory(line)
+			}
+			return line, err
+		}
+		logger.Error(err, "Failed to enable line editing, falling back to plain input")
+		e.terminal = false
+	}
+
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// editLine reads keystrokes in raw mode, applying editing keys until Enter is pressed.
+func (e *lineEditor) editLine(prompt string) (string, error) {
+	buf := []rune{}
+	pos := 0
+	historyIndex := len(e.history)
+	draft := "" // The line being typed before browsing the history.
+
+	e.refresh(prompt, buf, pos)
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return string(buf), err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+		case keyCtrlC:
+			// Like a shell, Ctrl-C discards the current line instead of exiting.
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", nil
+		case keyCtrlD:
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlB:
+			if pos > 0 {
+				pos--
+			}
+		case keyCtrlF:
+			if pos < len(buf) {
+				pos++
+			}
+		case keyCtrlK:
+			buf = buf[:pos]
+		case keyCtrlU:
+			buf = buf[pos:]
+			pos = 0
+		case keyCtrlW:
+			start := pos
+			for start > 0 && buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && buf[start-1] != ' ' {
+				start--
+			}
+			buf = append(buf[:start], buf[pos:]...)
+			pos = start
+		case keyBackspace, keyCtrlH:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyCtrlP, keyCtrlN, keyEscape:
+			key := r
+			if r == keyEscape {
+				key = e.readEscapeSequence()
+			}
+			switch key {
+			case keyCtrlP: // Previous history entry (also the up arrow).
+				if historyIndex > 0 {
+					if historyIndex == len(e.history) {
+						draft = string(buf)
+					}
+					historyIndex--
+					buf = []rune(e.history[historyIndex])
+					pos = len(buf)
+				}
+			case keyCtrlN: // Next history entry (also the down arrow).
+				if historyIndex < len(e.history) {
+					historyIndex++
+					if historyIndex == len(e.history) {
+						buf = []rune(draft)
+					} else {
+						buf = []rune(e.history[historyIndex])
+					}
+					pos = len(buf)
+				}
+			case keyCtrlF:
+				if pos < len(buf) {
+					pos++
+				}
+			case keyCtrlB:
+				if pos > 0 {
+					pos--
+				}
+			case keyCtrlA:
+				pos = 0
+			case keyCtrlE:
+				pos = len(buf)
+			case keyCtrlD:
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if unicode.IsPrint(r) {
+				buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+				pos++
+			}
+		}
+		e.refresh(prompt, buf, pos)
+	}
+}
+
+// readEscapeSequence reads the rest of an ANSI escape sequence (e.g., an arrow key)
+// and translates it into the equivalent control key. Unknown sequences map to 0.
+func (e *lineEditor) readEscapeSequence() rune {
+	r, _, err := e.reader.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+
+	params := ""
+	for {
+		r, _, err = e.reader.ReadRune()
+		if err != nil {
+			return 0
+		}
+		if r < '0' || r > '9' {
+			break
+		}
+		params += string(r)
+	}
+
+	switch r {
+	case 'A':
+		return keyCtrlP
+	case 'B':
+		return keyCtrlN
+	case 'C':
+		return keyCtrlF
+	case 'D':
+		return keyCtrlB
+	case 'H':
+		return keyCtrlA
+	case 'F':
+		return keyCtrlE
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyCtrlA
+		case "4", "8":
+			return keyCtrlE
+		case "3":
+			return keyCtrlD
+		}
+	}
+	return 0
+}
+
+// refresh redraws the prompt and the current line, placing the cursor at pos.
+func (e *lineEditor) refresh(prompt string, buf []rune, pos int) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+	// The cursor moves by columns, and wide characters such as CJK ideographs take up two.
+	if width := textWidth(string(buf[pos:])); width > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", width)
+	}
+}
+
+// loadHistory reads the most recent entries from the history file, if one is configured.
+func (e *lineEditor) loadHistory() {
+	if e.historyFile == "" {
+		return
+	}
+	data, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Error(err, fmt.Sprintf("Failed to read history file %s", e.historyFile))
+		}
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistoryEntries {
+		e.history = e.history[len(e.history)-maxHistoryEntries:]
+	}
+}
+
+// addHistory records a non-empty line in the history, skipping immediate repeats,
+// and appends it to the history file if one is configured.
+func (e *lineEditor) addHistory(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistoryEntries {
+		e.history = e.history[1:]
+	}
+
+	if e.historyFile == "" {
+		return
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to open history file %s", e.historyFile))
+		return
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, line); err != nil {
+		logger.Error(err, fmt.Sprintf("Failed to write history file %s", e.historyFile))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"regexp"  // Package for finding URLs in task text
+	"strings" // Package for string manipulation
+)
+
+// linkMarker replaces URLs found in task text in list views, to keep list lines readable.
+const linkMarker = "[link]"
+
+// taskURLPattern matches web URLs in task text, e.g., "https://example.com/spec?id=3".
+var taskURLPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// taskURLs returns the URLs found in a task's text, without trailing punctuation
+// (e.g., the period in "see https://example.com.").
+func taskURLs(task string) []string {
+	urls := []string{}
+	for _, match := range taskURLPattern.FindAllString(task, -1) {
+		if match = strings.TrimRight(match, ".,;:!?)]'"); isURL(match) {
+			urls = append(urls, match)
+		}
+	}
+	return urls
+}
+
+// shortenLinks replaces the URLs in a task's text with the link marker, for list views.
+// The full URLs are shown by the show command and launched by open.
+func shortenLinks(task string) string {
+	for _, url := range taskURLs(task) {
+		task = strings.Replace(task, url, linkMarker, 1)
+	}
+	return task
+}
+
+// todoURLs returns all URLs of a todo: its attached links, then the URLs in its task text.
+func todoURLs(todo Todo) []string {
+	urls := append([]string{}, todo.Links...)
+	seen := map[string]bool{}
+	for _, link := range todo.Links {
+		seen[link] = true
+	}
+	for _, url := range taskURLs(todo.Task) {
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
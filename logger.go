@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json" // Package for recognizing data that cannot be parsed
+	"errors"        // Package for categorizing logged errors
+	"fmt"           // Package for formatted I/O (e.g., log file errors)
+	"io"            // Package for writing to stderr and the log file at once
+	"io/fs"         // Package for recognizing file system errors
+	"log"           // Package for formatting log entries with the time and source file
+	"os"            // Package for opening the log file and writing to stderr
+	"path/filepath" // Package for resolving the log file path reported to the user
+	"strings"       // Package for parsing level names
+	"sync"          // Package for changing the output and level of a logger in use
+	"time"          // Package for timing operations
+	"todo/pkg/todo" // Package for making the todo engine log to the application's logger
+)
+
+// LogLevel is the severity of a log entry. A logger writes the entries at or above its level.
+type LogLevel int
+
+// The log levels, from the most verbose to the least.
+const (
+	LevelDebug LogLevel = iota // Details for tracking down problems (e.g., which files were read).
+	LevelInfo                  // Normal operation (e.g., the list was saved).
+	LevelWarn                  // Something unexpected that the application worked around.
+	LevelError                 // An operation that failed.
+)
+
+// logLevelNames are the names of the log levels in the log_level config setting and the -log-level flag.
+var logLevelNames = map[string]LogLevel{
+	"debug":   LevelDebug,
+	"info":    LevelInfo,
+	"warn":    LevelWarn,
+	"warning": LevelWarn,
+	"error":   LevelError,
+}
+
+// parseLogLevel parses the name of a log level (debug, info, warn, or error), ignoring case.
+func parseLogLevel(name string) (LogLevel, error) {
+	level, ok := logLevelNames[strings.ToLower(name)]
+	if !ok {
+		return LevelInfo, fmt.Errorf("invalid log level %q: use debug, info, warn, or error", name)
+	}
+	return level, nil
+}
+
+// Logger writes leveled log entries, each with the time and the source file that logged it.
+// It is safe for concurrent use (e.g., by auto-save and a command at the same time).
+type Logger struct {
+	mu     sync.Mutex
+	level  LogLevel
+	output *log.Logger
+	file   *rotatingFile // The log file written besides stderr, opened by SetFile.
+	sink   logSink       // The system log written besides stderr, opened by SetSyslog.
+	held   []heldEntry   // The entries logged since Hold, or nil if the logger is not holding them.
+}
+
+// heldEntry is an entry logged while the logger holds its entries.
+type heldEntry struct {
+	level   LogLevel
+	line    string // The entry as written to the output, with the time and source file.
+	message string // The entry as written to the sink.
+}
+
+// logSink is a destination of log entries that records their level itself, like syslog,
+// and so takes each entry without the time and source file.
+type logSink interface {
+	WriteEntry(level LogLevel, message string) error
+	Close() error
+}
+
+// NewLogger returns a logger that writes the entries at or above level to w.
+// Tests use it to capture what the code under test logs.
+func NewLogger(w io.Writer, level LogLevel) *Logger {
+	return &Logger{level: level, output: log.New(w, "", log.Ldate|log.Ltime|log.Lshortfile)}
+}
+
+// logger is the logger of the application, which writes to stderr and the configured log file.
+// TodoLists without a logger of their own (see TodoList.SetLogger) use it as well.
+var logger = NewLogger(os.Stderr, LevelInfo)
+
+func init() {
+	todo.SetDefaultLogger(logger)
+}
+
+// SetLevel changes the lowest level of the entries the logger writes.
+func (l *Logger) SetLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// Hold makes the logger hold the entries logged from now on, until Release writes them.
+// The application holds its startup entries (e.g., that the config was loaded) until the
+// configured level and log file are known.
+func (l *Logger) Hold() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held == nil {
+		l.held = []heldEntry{}
+	}
+}
+
+// Release writes the entries held since Hold that are at or above the level of the logger
+// by now, and stops holding entries.
+func (l *Logger) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	held := l.held
+	l.held = nil
+	for _, entry := range held {
+		if entry.level < l.level {
+			continue
+		}
+		io.WriteString(l.output.Writer(), entry.line)
+		if l.sink != nil {
+			l.sink.WriteEntry(entry.level, entry.message)
+		}
+	}
+}
+
+// Writer returns where the logger writes its entries.
+func (l *Logger) Writer() io.Writer {
+	return l.output.Writer()
+}
+
+// write writes an entry at level, attributed to the caller of the Logger method that called it.
+func (l *Logger) write(level LogLevel, prefix string, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held != nil {
+		var line strings.Builder
+		log.New(&line, "", l.output.Flags()).Output(3, prefix+message+"\n")
+		l.held = append(l.held, heldEntry{level: level, line: line.String(), message: prefix + message})
+		return
+	}
+	if level < l.level {
+		return
+	}
+	l.output.Output(3, prefix+message+"\n")
+	if l.sink != nil {
+		l.sink.WriteEntry(level, prefix+message)
+	}
+}
+
+// Debug logs a message that is only of interest when tracking down a problem.
+func (l *Logger) Debug(message string) {
+	l.write(LevelDebug, "DEBUG: ", message)
+}
+
+// Timing logs how long an operation that started at start took, at the debug level, so that
+// slow operations on large lists can be tracked down (e.g., with -debug).
+func (l *Logger) Timing(operation string, start time.Time) {
+	l.write(LevelDebug, "DEBUG: ", fmt.Sprintf("%s took %s", operation, time.Since(start).Round(time.Microsecond)))
+}
+
+// Info logs an informational message.
+func (l *Logger) Info(message string) {
+	l.write(LevelInfo, "INFO: ", message)
+}
+
+// Warn logs a warning message.
+func (l *Logger) Warn(message string) {
+	l.write(LevelWarn, "WARNING: ", message)
+}
+
+// Categories of logged errors, shown in brackets after the level (e.g., "ERROR [io]: ...").
+const (
+	categoryInput   = "input"   // A command given with missing or invalid arguments.
+	categoryIO      = "io"      // A file that cannot be read or written.
+	categoryData    = "data"    // A file whose contents cannot be parsed.
+	categoryCommand = "command" // Any other failure of a command (e.g., a todo that does not exist).
+)
+
+// errorCategory returns the category of an error, from the errors it wraps.
+func errorCategory(err error) string {
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return categoryData
+	case errors.As(err, &pathErr), errors.As(err, &linkErr):
+		return categoryIO
+	}
+	return categoryCommand
+}
+
+// Error logs an error with a message describing what failed, and the category of the error.
+// Only call it for an actual failure: expected conditions, like a data file that does not exist
+// yet, are logged with Info. Nothing is logged if err is nil.
+func (l *Logger) Error(err error, message string) {
+	if err != nil {
+		l.write(LevelError, "ERROR ["+errorCategory(err)+"]: ", fmt.Sprintf("%s: %v", message, err))
+	}
+}
+
+// InputError logs a command given with missing or invalid arguments. It is logged at the info
+// level, since the user was shown the usage and nothing failed.
+func (l *Logger) InputError(err error, message string) {
+	if err != nil {
+		l.write(LevelInfo, "INFO ["+categoryInput+"]: ", fmt.Sprintf("%s: %v", message, err))
+	}
+}
+
+// SetFile makes the logger write to the log file at path besides stderr, or to stderr only
+// if path is empty, rotating the log file as set by rotation. A log file opened before is closed.
+// If the log file cannot be opened, the logger writes to stderr only, and the returned error
+// names the resolved path that was tried.
+func (l *Logger) SetFile(path string, rotation LogRotation) error {
+	l.Close()
+	if path == "" {
+		return nil
+	}
+	file, err := openRotatingFile(path, rotation)
+	if err != nil {
+		resolvedPath, absErr := filepath.Abs(path)
+		if absErr != nil {
+			resolvedPath = path
+		}
+		err = fmt.Errorf("cannot write log file %s: %w", resolvedPath, err)
+		l.write(LevelError, "ERROR ["+categoryIO+"]: ", err.Error())
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file = file
+	l.output.SetOutput(io.MultiWriter(os.Stderr, file))
+	return nil
+}
+
+// SetSyslog makes the logger write to the system log besides stderr, with entries tagged tag,
+// instead of to a log file. A log file opened before is closed. If the system log is not
+// available, the logger writes to stderr only, and the error is returned.
+func (l *Logger) SetSyslog(tag string) error {
+	l.Close()
+	sink, err := openSyslog(tag)
+	if err != nil {
+		err = fmt.Errorf("cannot write to syslog: %w", err)
+		l.write(LevelError, "ERROR ["+categoryIO+"]: ", err.Error())
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sink = sink
+	return nil
+}
+
+// Close flushes and closes the log file, and disconnects from the system log, if either is open.
+// Later entries only go to stderr.
+func (l *Logger) Close() {
+	l.mu.Lock()
+	file, sink := l.file, l.sink
+	l.sink = nil
+	if file != nil {
+		l.file = nil
+		l.output.SetOutput(os.Stderr)
+	}
+	l.mu.Unlock()
+	if sink != nil {
+		sink.Close()
+	}
+	if file == nil {
+		return
+	}
+	if err := file.Sync(); err != nil {
+		l.Error(err, "Failed to flush the log file")
+	}
+	file.Close()
+}
+
+// SetupLogger configures the logger of the application: the lowest level it writes, and
+// the log file it writes to besides stderr, if any, and when that is rotated.
+// See Logger.SetFile for the returned error.
+func SetupLogger(logFilePath string, level LogLevel, rotation LogRotation) error {
+	logger.SetLevel(level)
+	return logger.SetFile(logFilePath, rotation)
+}
+
+// CloseLogger flushes and closes the log file of the application's logger, if any.
+func CloseLogger() {
+	logger.Close()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"  // Package for reading the first entry of a log file
+	"errors" // Package for reporting a log file that failed to reopen
+	"fmt"    // Package for formatted I/O (e.g., rotated file names)
+	"os"     // Package for renaming, removing, and reopening log files
+	"time"   // Package for the age of a log file
+)
+
+// LogRotation limits how large and how old the log file grows before it is rotated: renamed to
+// <log file>.1, with older rotated files shifted to .2, .3, and so on, and a new log file started.
+type LogRotation struct {
+	MaxSizeMB  int `json:"max_size_mb"`  // Size in megabytes at which the log file is rotated; 0 for no limit.
+	MaxAgeDays int `json:"max_age_days"` // Age in days of the first entry at which the log file is rotated; 0 for no limit.
+	MaxFiles   int `json:"max_files"`    // Number of rotated files kept; older ones are deleted.
+}
+
+// validate reports a rotation setting with an invalid value.
+func (r LogRotation) validate() error {
+	if r.MaxSizeMB < 0 || r.MaxAgeDays < 0 || r.MaxFiles < 0 {
+		return fmt.Errorf("log_rotation settings must not be negative")
+	}
+	return nil
+}
+
+// logEntryTimeLayout is how the logger writes the time at the start of each entry (log.Ldate|log.Ltime).
+const logEntryTimeLayout = "2006/01/02 15:04:05"
+
+// rotatingFile is a log file that rotates itself before a write would make it larger than maxSize,
+// or once its first entry is older than maxAge. Either limit is off if zero. It is not safe for
+// concurrent use on its own; the Logger writing to it serializes the writes.
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxAge   time.Duration
+	maxFiles int
+	file     *os.File
+	size     int64     // Size of the log file, including what was written before it was opened.
+	started  time.Time // Time of the first entry of the log file.
+	failed   bool      // Whether rotating failed, after which the log file just grows.
+}
+
+// openRotatingFile opens the log file at path for appending, creating it if needed.
+func openRotatingFile(path string, rotation LogRotation) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:     path,
+		maxSize:  int64(rotation.MaxSizeMB) << 20,
+		maxAge:   time.Duration(rotation.MaxAgeDays) * 24 * time.Hour,
+		maxFiles: rotation.MaxFiles,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the log file and finds out its size and when it was started: at its first entry,
+// at its last change if that cannot be read, or now if it is empty.
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.size = 0
+	r.started = time.Now()
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return nil
+	}
+	r.size = info.Size()
+	r.started = info.ModTime()
+	if existing, err := os.Open(r.path); err == nil {
+		defer existing.Close()
+		line, _ := bufio.NewReader(existing).ReadString('\n')
+		if len(line) >= len(logEntryTimeLayout) {
+			if first, err := time.ParseInLocation(logEntryTimeLayout, line[:len(logEntryTimeLayout)], time.Local); err == nil {
+				r.started = first
+			}
+		}
+	}
+	return nil
+}
+
+// rotatedName returns the name of the nth most recently rotated file (e.g., app.log.1).
+func (r *rotatingFile) rotatedName(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+// due reports whether the log file is to be rotated before n more bytes are written to it.
+// An empty file is never rotated, so that an entry larger than maxSize is still written.
+func (r *rotatingFile) due(n int) bool {
+	if r.failed || r.size == 0 {
+		return false
+	}
+	return (r.maxSize > 0 && r.size+int64(n) > r.maxSize) || (r.maxAge > 0 && time.Since(r.started) >= r.maxAge)
+}
+
+// rotate closes the log file, shifts it and the rotated files by one, deleting the oldest one
+// beyond maxFiles, and starts a new log file. The log file is reopened even if shifting failed.
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	oldest := r.path
+	if r.maxFiles > 0 {
+		oldest = r.rotatedName(r.maxFiles)
+	}
+	err := os.Remove(oldest)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	for n := r.maxFiles - 1; n >= 0 && err == nil; n-- {
+		from := r.path
+		if n > 0 {
+			from = r.rotatedName(n)
+		}
+		if renameErr := os.Rename(from, r.rotatedName(n+1)); renameErr != nil && !os.IsNotExist(renameErr) {
+			err = renameErr
+		}
+	}
+	if openErr := r.open(); openErr != nil {
+		r.file = nil
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// Write writes p to the log file, rotating it first if it is due. If rotating fails, the failure
+// is reported on stderr once, and the log file is no longer rotated.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.due(len(p)) {
+		if err := r.rotate(); err != nil {
+			r.failed = true
+			fmt.Fprintf(os.Stderr, "WARNING: Failed to rotate the log file %s: %v\n", r.path, err)
+		}
+	}
+	if r.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", r.path)
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the log file to disk.
+func (r *rotatingFile) Sync() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the log file.
+func (r *rotatingFile) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
@@ -1,60 +1,201 @@
 package main
 
 import (
-	// "flag" // No longer needed if config manages log-file
-	"fmt" // Package for formatted I/O (e.g., printing to console)
-	"os"  // Package for operating system functionalities (e.g., exiting the program)
-
-	// "strconv" // No longer needed in main.go
-	// "strings" // No longer needed in main.go
-	// Package for time-related functions (e.g., auto-save interval)
-	"time"
+	"fmt"  // Package for formatted I/O (e.g., printing to console)
+	"os"   // Package for operating system functionalities (e.g., exiting the program)
+	"sync" // Package for shutting down only once, on exit or on a signal
+	"time" // Package for time-related functions (e.g., auto-save interval)
+
+	"todo/pkg/todo" // Package for loading the todo list
 )
 
 const (
-	// configPath is the default path for the application's configuration file.
-	configPath = "config.json"
+	// defaultConfigPath is the default path for the application's configuration file.
+	defaultConfigPath = "config.json"
 )
 
 // main is the entry point of the CLI todo application.
 // It initializes the logger, manages the todo list lifecycle (load, auto-save, save),
 // and delegates command handling to the cli module.
 func main() {
+	// Parse command-line flags first, since global flags can override the config location and values.
+	flags := parseFlags()
+
+	// What is logged while the config is loaded is held until the logger is set up with the
+	// configured level, so that e.g. -log-level error silences it.
+	logger.Hold()
+
 	// Load application configuration.
-	config, err := LoadConfig(configPath)
+	config, err := loadSettings(flags)
 	if err != nil {
 		// If config loading fails, log the error and exit. No need to use SetupLogger yet,
 		// as it might depend on the config itself. Just print to stderr.
+		logger.Release()
 		fmt.Fprintf(os.Stderr, "ERROR: Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
 
-	SetupLogger(config.LogFilePath) // Initialize the custom logger with potential log file from config.
+	// Initialize the custom logger with potential log file from config. A log file that cannot be
+	// written is reported to the user once here, since the failure is otherwise only in the log itself.
+	// The reminder daemon logs to syslog instead, if daemon_log says so.
+	level, _ := parseLogLevel(config.LogLevel) // Checked by loadSettings.
+	if isDaemonCommand() && config.DaemonLog == daemonLogSyslog {
+		logger.SetLevel(level)
+		if err := logger.SetSyslog("todo"); err != nil {
+			PrintUserMessage(fmt.Sprintf("⚠️ Logging to stderr only: %v. Set daemon_log to file in %s to log to log_file_path.", err, *flags.configFile))
+		}
+	} else if err := SetupLogger(config.LogFilePath, level, config.LogRotation); err != nil {
+		warning := fmt.Sprintf("⚠️ Logging to stderr only: %v. Fix log_file_path in %s, or run with -no-log-file.", err, *flags.configFile)
+		if *flags.json || *flags.fields != "" {
+			fmt.Fprintln(os.Stderr, warning) // Keep standard output valid JSON.
+		} else {
+			PrintUserMessage(warning)
+		}
+	}
+	logger.Release()
+
+	// The reminder daemon only reads the data file, which other instances keep changing,
+	// so it neither loads the list here nor saves it on exit.
+	if isDaemonCommand() {
+		runDaemon(config)
+		return
+	}
+	// The MCP server loads and saves the list for each tool call, so that it works alongside
+	// other instances. Standard output only carries its protocol messages.
+	if isMCPCommand() {
+		applySettings(config, flags)
+		runMCPServer(config.DataFile, os.Stdin, os.Stdout)
+		waitForWebhooks(webhookTimeout)
+		CloseLogger()
+		return
+	}
 
 	// Load the todo list from the data file specified in config.
-	todoList, err := LoadFromFile(config.DataFile)
+	todoList, err := todo.LoadFromFile(config.DataFile)
 	if err != nil {
 		// Log the error if loading fails and exit the application.
-		LogError(err, "Failed to load todo list")
+		logger.Error(err, "Failed to load todo list")
 		PrintUserMessage("Error loading todo list. Exiting.")
 		os.Exit(1) // Exit with an error code.
 	}
+	// Restore the undo stack of earlier runs, so that undo reaches back past this one.
+	if err := loadUndoJournal(config.DataFile); err != nil {
+		logger.Error(err, "Failed to load the undo journal")
+	}
+
+	// Attachments are only removed for todos deleted after this point.
+	loadedTodoList = todoList.Clone()
+
+	// In dry-run mode, run the command against an in-memory copy and report what
+	// would change. Neither auto-save nor the final save touch the data file.
+	if *flags.dryRun {
+		workingCopy := todoList.Clone()
+		HandleCommands(workingCopy, flags, config)
+		printDryRunChanges(DiffTodoLists(todoList, workingCopy))
+		return
+	}
 
 	// Start a background goroutine for auto-saving the todo list periodically.
 	// This ensures that changes are saved even if the application isn't explicitly exited.
-	StartAutoSave(todoList, config.DataFile, time.Duration(config.AutoSaveInterval))
+	autoSave = startAutoSaver(todoList, config.DataFile, time.Duration(config.AutoSaveInterval))
+
+	// shutdown stops auto-save, waiting for a save in progress, saves the list a last time,
+	// waits for webhook deliveries, and flushes the log. It runs once, on a normal exit or when a shutdown signal arrives.
+	// The list is saved to the data file auto-save used last, which reload may have changed.
+	var shutdownOnce sync.Once
+	shutdown := func() {
+		shutdownOnce.Do(func() {
+			dataFile := autoSave.Stop()
+			todoListMu.Lock() // A shutdown signal may arrive while a command changes the list.
+			saveOnExit(todoList, dataFile)
+			todoListMu.Unlock()
+			waitForWebhooks(webhookTimeout)
+			CloseLogger()
+		})
+	}
+	stopSignals := handleShutdownSignals(func() {
+		rollbackOpenTransaction(todoList) // As on a normal exit, an uncommitted transaction is not saved.
+		shutdown()
+	})
 
 	// Delegate all command parsing and execution (both single command and interactive mode)
 	// to the HandleCommands function in the cli module.
-	HandleCommands(todoList)
+	HandleCommands(todoList, flags, config)
+	stopSignals()
+	shutdown()
+}
+
+// loadSettings loads the config file given with -config, and applies over it, from the lowest
+// precedence to the highest: the selected profile, the paths of a project config, environment
+// variables, and the -data-file, -no-log-file, and -log-level flags.
+func loadSettings(flags commandFlags) (Config, error) {
+	config, err := LoadConfig(*flags.configFile)
+	if err != nil {
+		return config, err
+	}
 
+	// A profile (e.g., -profile work) replaces the data file, log file, and defaults of the config.
+	profile := config.Profile
+	if *flags.profile != "" {
+		profile = *flags.profile
+	}
+	if err := applyProfile(&config, profile); err != nil {
+		return config, err
+	}
+
+	// A project config keeps its list next to it, wherever in the project the command is run.
+	resolveProjectPaths(&config, *flags.configFile)
+
+	// Environment variables override the config file, for containers and CI.
+	if err := applyEnvOverrides(&config, os.Getenv); err != nil {
+		return config, err
+	}
+
+	// A data file given on the command line takes precedence over the one from the config.
+	if *flags.dataFile != "" {
+		config.DataFile = *flags.dataFile
+	}
+	if *flags.noLogFile {
+		config.LogFilePath = ""
+	}
+	if *flags.logLevel != "" {
+		config.LogLevel = *flags.logLevel
+	}
+	if *flags.debug {
+		config.LogLevel = "debug"
+	}
+	if _, err := parseLogLevel(config.LogLevel); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// saveOnExit saves the todo list and its undo journal before the application exits, or before
+// reload switches to another data file. Returns the error if the list could not be saved.
+func saveOnExit(todoList *TodoList, dataFile string) error {
 	// Explicitly save the todo list to file before the application exits.
 	// This is important for ensuring the latest changes are saved immediately,
 	// especially for commands that don't trigger an auto-save shortly after.
 	// This will also catch any changes made in interactive mode before the program fully terminates.
-	err = todoList.SaveToFile(config.DataFile)
+	err := saveTodoList(todoList, dataFile)
 	if err != nil {
 		// Log an error if saving fails during application shutdown.
-		LogError(err, "Failed to save todo list on exit")
+		logger.Error(err, "Failed to save todo list on exit")
+		return err
+	}
+	if err := saveUndoJournal(dataFile); err != nil {
+		logger.Error(err, "Failed to save the undo journal")
+	}
+
+	// Remove the attachments of todos that were deleted, now that the deletion is saved.
+	if loadedTodoList == nil {
+		return nil
+	}
+	if pruned, err := attachments.Prune(loadedTodoList, todoList); err != nil {
+		logger.Error(err, "Failed to remove attachments of deleted todos")
+	} else if len(pruned) > 0 {
+		logger.Info(fmt.Sprintf("Removed attachments of %d deleted todos.", len(pruned)))
 	}
+	loadedTodoList = todoList.Clone() // What was pruned is saved, so the next save starts from here.
+	return nil
 }
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"         // Package for reading one JSON-RPC message per line
+	"encoding/json" // Package for encoding JSON-RPC messages
+	"errors"        // Package for tool errors
+	"flag"          // Package for recognizing the mcp command among the arguments
+	"fmt"           // Package for formatted I/O (e.g., tool results)
+	"io"            // Package for the input and output streams of the server
+	"strings"       // Package for joining list filters
+	"time"          // Package for scheduling the next occurrence of completed recurring todos
+	"todo/pkg/todo" // Package for loading the todo list
+)
+
+// mcpProtocolVersions are the Model Context Protocol versions the server speaks, the latest last.
+var mcpProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// isMCPCommand reports whether the program was started as an MCP server ("todo mcp").
+func isMCPCommand() bool {
+	return flag.NArg() == 1 && flag.Arg(0) == "mcp"
+}
+
+// rpcRequest is a JSON-RPC request, or a notification if it has no ID.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC response with either a result or an error.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// mcpTool describes a tool in the answer to tools/list. InputSchema is the JSON schema of its arguments.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// stringProperty returns the JSON schema of a string argument.
+func stringProperty(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// mcpTools are the todo operations the server offers.
+var mcpTools = []mcpTool{
+	{
+		Name:        "list_todos",
+		Description: "List todos, optionally filtered and sorted. Returns the todos as JSON.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"status":     stringProperty("all, incomplete, completed, overdue, expired, in-progress, waiting, blocked, or cancelled (default: all)"),
+			"priority":   stringProperty("high, medium, or low"),
+			"tags":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only todos with one of these tags"},
+			"project":    stringProperty("Only todos of this project, or none for todos without one"),
+			"query":      stringProperty("Filter query, e.g., priority:high AND due<2025-01-01"),
+			"sort_by":    stringProperty("id, task, priority, due_date, created_at, urgency, or order"),
+			"sort_order": stringProperty("asc or desc"),
+		}},
+	},
+	{
+		Name:        "add_todo",
+		Description: "Add a todo. Returns the added todo as JSON.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"task":     stringProperty("What is to be done"),
+			"priority": stringProperty("high, medium, or low"),
+			"due":      stringProperty("Due date as YYYY-MM-DD or in words, e.g., tomorrow, friday 14:00, or +3d"),
+			"tags":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"project":  stringProperty("Project of the todo"),
+		}, "required": []string{"task"}},
+	},
+	{
+		Name:        "complete_todo",
+		Description: "Mark a todo as complete. Returns the completed todo as JSON.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"id": map[string]any{"type": "integer", "description": "ID of the todo"},
+		}, "required": []string{"id"}},
+	},
+	{
+		Name:        "search_todos",
+		Description: "Search todos by task, tags, and subtasks, the most relevant first. Returns the matching todos as JSON.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"query": stringProperty("Words to search for"),
+			"fuzzy": map[string]any{"type": "boolean", "description": "Also match words with typos"},
+		}, "required": []string{"query"}},
+	},
+}
+
+// runMCPServer serves the todo list in dataFile to an MCP client (e.g., an AI assistant) over
+// stdio: it reads one JSON-RPC message per line from in, and writes the responses to out, until in
+// is closed. Each tool call loads the list, so that changes made by other instances are seen,
+// and saves it if the call changed it.
+func runMCPServer(dataFile string, in io.Reader, out io.Writer) {
+	logger.Info(fmt.Sprintf("MCP server started for %s.", dataFile))
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	encoder := json.NewEncoder(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var request rpcRequest
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		result, rpcErr := handleMCPRequest(dataFile, request)
+		if len(request.ID) == 0 {
+			continue // Notifications (e.g., notifications/initialized) get no response.
+		}
+		response := rpcResponse{JSONRPC: "2.0", ID: request.ID, Result: result, Error: rpcErr}
+		if err := encoder.Encode(response); err != nil {
+			logger.Error(err, "Failed to write the MCP response")
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Error(err, "Failed to read MCP requests")
+	}
+}
+
+// handleMCPRequest answers a JSON-RPC request of the MCP client.
+func handleMCPRequest(dataFile string, request rpcRequest) (any, *rpcError) {
+	switch request.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(request.Params, &params)
+		version := mcpProtocolVersions[len(mcpProtocolVersions)-1]
+		for _, supported := range mcpProtocolVersions {
+			if params.ProtocolVersion == supported {
+				version = supported // Answer with the client's version if the server speaks it.
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "todo", "version": "1.0.0"},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		output, err := callMCPTool(dataFile, params.Name, params.Arguments)
+		if errors.Is(err, errUnknownTool) {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		if err != nil {
+			// Tool errors are results, so that the assistant sees them and can correct the call.
+			return map[string]any{"content": []map[string]string{{"type": "text", "text": err.Error()}}, "isError": true}, nil
+		}
+		return map[string]any{"content": []map[string]string{{"type": "text", "text": output}}, "isError": false}, nil
+	}
+	if strings.HasPrefix(request.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q not found", request.Method)}
+}
+
+// errUnknownTool is returned for a call of a tool the server does not offer.
+var errUnknownTool = errors.New("unknown tool")
+
+// callMCPTool runs a tool on the todo list in dataFile, and returns its output as JSON text.
+// The list is saved, recorded in the audit log, and posted to webhooks if the tool changed it.
+func callMCPTool(dataFile string, name string, arguments json.RawMessage) (string, error) {
+	todoList, err := todo.LoadFromFile(dataFile)
+	if err != nil {
+		return "", err
+	}
+	var output any
+	failed := false
+	auditCommand(todoList, "mcp "+name+" "+string(arguments), func() bool {
+		before := todoList.Clone()
+		output, err = runMCPTool(todoList, name, arguments)
+		if err != nil {
+			commandFailures++ // Recorded as failed in the audit log.
+			failed = true
+			*todoList = *before
+			return true
+		}
+		diff := DiffTodoLists(before, todoList)
+		if diff.IsEmpty() || dryRun {
+			return true
+		}
+		if err = saveTodoList(todoList, dataFile); err != nil {
+			logger.Error(err, "Failed to save the todo list changed by an MCP tool")
+			commandFailures++
+			failed = true
+			return true
+		}
+		notifyWebhooks(diff)
+		return true
+	})
+	if failed {
+		return "", err
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the tool output: %w", err)
+	}
+	return string(data), nil
+}
+
+// runMCPTool runs a tool on the todo list and returns its output. The arguments are those
+// described by the tool's input schema.
+func runMCPTool(todoList *TodoList, name string, arguments json.RawMessage) (any, error) {
+	switch name {
+	case "list_todos":
+		var args struct {
+			Status    string   `json:"status"`
+			Priority  string   `json:"priority"`
+			Tags      []string `json:"tags"`
+			Project   string   `json:"project"`
+			Query     string   `json:"query"`
+			SortBy    string   `json:"sort_by"`
+			SortOrder string   `json:"sort_order"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		listArgs := []string{}
+		for _, option := range []struct{ flag, value string }{
+			{"-filter-status", args.Status}, {"-filter-priority", args.Priority}, {"-filter-tags", strings.Join(args.Tags, ",")},
+			{"-filter-project", args.Project}, {"-query", args.Query}, {"-sort-by", args.SortBy}, {"-sort-order", args.SortOrder},
+		} {
+			if option.value != "" {
+				listArgs = append(listArgs, option.flag, option.value)
+			}
+		}
+		options, err := parseListArgs(listArgs)
+		if err != nil {
+			return nil, err
+		}
+		return todoList.Filter(options), nil
+	case "add_todo":
+		var args struct {
+			Task     string   `json:"task"`
+			Priority string   `json:"priority"`
+			Due      string   `json:"due"`
+			Tags     []string `json:"tags"`
+			Project  string   `json:"project"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		if args.Priority != "" && PriorityLevel(args.Priority).Canonical() == "" {
+			return nil, fmt.Errorf("invalid priority %q: use high, medium, or low", args.Priority)
+		}
+		if args.Tags == nil {
+			args.Tags = []string{}
+		}
+		return addTodo(todoList, addArgs{Task: strings.TrimSpace(args.Task), Priority: args.Priority, DueDate: args.Due, Tags: args.Tags, Project: args.Project})
+	case "complete_todo":
+		var args struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		todo, err := todoList.Get(args.ID)
+		if err != nil {
+			return nil, err
+		}
+		if todoList.IsBlocked(todo) {
+			return nil, fmt.Errorf("todo #%d is blocked by open todos %s: complete them first", args.ID, formatTodoIDs(todoList.OpenDependencies(todo)))
+		}
+		if err := todoList.Complete(args.ID); err != nil {
+			return nil, err
+		}
+		if !todo.Completed && todo.Recurrence != "" {
+			if _, err := todoList.ScheduleNextOccurrence(args.ID, time.Now()); err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to schedule the next occurrence of todo with ID %d", args.ID))
+			}
+		}
+		return todoList.Get(args.ID)
+	case "search_todos":
+		var args struct {
+			Query string `json:"query"`
+			Fuzzy bool   `json:"fuzzy"`
+		}
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		if args.Fuzzy {
+			return todoList.FuzzySearch(args.Query), nil
+		}
+		return todoList.Search(args.Query), nil
+	}
+	return nil, fmt.Errorf("%w %q: use list_todos, add_todo, complete_todo, or search_todos", errUnknownTool, name)
+}
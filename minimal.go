@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., building todo lines)
+	"strings" // Package for string manipulation
+	"time"    // Package for checking whether a todo is overdue
+)
+
+// minimalOutput enables the minimal output profile, which strips emoji, colors, counts, and
+// banners, and prints only the essential line per todo. It is set from the -minimal flag or
+// the output_profile config setting.
+var minimalOutput bool
+
+// isEmoji reports whether r is an emoji or pictographic symbol, including the
+// invisible characters used to build emoji sequences.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji and pictographs
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats (e.g., ✅, ✨, ❌)
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Miscellaneous technical (e.g., ⌛, ⏰)
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Miscellaneous symbols and arrows (e.g., ⭐)
+		return true
+	case r == 0x21A9 || r == 0x21AA: // ↩️ and ↪️
+		return true
+	case r == 0xFE0F || r == 0x200D: // Emoji variation selector and zero-width joiner
+		return true
+	}
+	return false
+}
+
+// stripEmoji removes emoji from text, along with the space that separates an emoji from the following word.
+func stripEmoji(text string) string {
+	var result strings.Builder
+	skipSpace := false
+	for _, r := range text {
+		if isEmoji(r) {
+			skipSpace = true
+			continue
+		}
+		if r == ' ' && skipSpace {
+			skipSpace = false
+			continue
+		}
+		skipSpace = false
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// formatMinimalTodo renders the essential line for a todo in the minimal output profile:
+// its status, ID, the first line of its task, and due date.
+func formatMinimalTodo(todo Todo) string {
+	title, _ := taskLines(shortenLinks(todo.Task))
+	line := fmt.Sprintf("%s %d. %s", statusMarker(todo), todo.ID, title)
+	if todo.DueDate != nil && todo.IsOverdue(time.Now()) {
+		line += " (Due: " + formatDate(*todo.DueDate) + ", overdue)"
+	} else if todo.DueDate != nil {
+		line += " (Due: " + formatDate(*todo.DueDate) + ")"
+	}
+	return line
+}
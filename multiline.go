@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings" // Package for string manipulation
+)
+
+const (
+	// continuationPrompt is shown while reading the following lines of a multi-line command.
+	continuationPrompt = "... "
+	// defaultHeredocTerminator ends the text started with "<<" when no terminator is given.
+	defaultHeredocTerminator = "EOF"
+)
+
+// readMultiLineCommand splits an interactive command into fields, reading more lines if it spans several.
+// A line ending with a backslash continues on the next line, and a command ending with "<<" (or "<<END")
+// is followed by lines of text up to a line with just "EOF" (or "END"). The following lines are joined
+// with line breaks and appended to the fields of the first line as a single field starting with
+// a line break, so that joinText keeps them on their own lines (e.g., in the task of an add command).
+func readMultiLineCommand(console *lineEditor, firstLine string) []string {
+	firstLine = strings.TrimSpace(firstLine)
+	fields := strings.Fields(strings.TrimSuffix(firstLine, `\`))
+	lines := []string{}
+	for continued := strings.HasSuffix(firstLine, `\`); continued; {
+		next, err := console.Ask(continuationPrompt)
+		next = strings.TrimSpace(next)
+		continued = strings.HasSuffix(next, `\`)
+		lines = append(lines, strings.TrimSpace(strings.TrimSuffix(next, `\`)))
+		if err != nil {
+			break // The input was closed.
+		}
+	}
+
+	if len(lines) == 0 && len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "<<") {
+		terminator := strings.TrimPrefix(fields[len(fields)-1], "<<")
+		if terminator == "" {
+			terminator = defaultHeredocTerminator
+		}
+		fields = fields[:len(fields)-1]
+		for {
+			next, err := console.Ask(continuationPrompt)
+			if strings.TrimSpace(next) == terminator {
+				break
+			}
+			if next != "" || err == nil {
+				lines = append(lines, strings.TrimRight(next, " \t"))
+			}
+			if err != nil {
+				break // The input was closed before the terminator.
+			}
+		}
+	}
+
+	if text := strings.Join(lines, "\n"); strings.TrimSpace(text) != "" {
+		fields = append(fields, "\n"+text)
+	}
+	return fields
+}
+
+// joinText joins the words of a task description with spaces, except before parts that start
+// with a line break (see readMultiLineCommand), which keeps multi-line text intact.
+func joinText(parts []string) string {
+	var text strings.Builder
+	for _, part := range parts {
+		if text.Len() > 0 && !strings.HasPrefix(part, "\n") {
+			text.WriteString(" ")
+		}
+		text.WriteString(part)
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// taskLines splits a task description into its first line, shown as the title of the todo,
+// and the following lines, if it has several.
+func taskLines(task string) (string, []string) {
+	lines := strings.Split(task, "\n")
+	return lines[0], lines[1:]
+}
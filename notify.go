@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"os"      // Package for passing the notification text in the environment
+	"os/exec" // Package for running the system notifier
+	"runtime" // Package for picking the notifier of the operating system
+)
+
+// windowsToastScript shows a Windows toast notification with the title and message passed
+// in the environment, which avoids quoting them for PowerShell.
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:TODO_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:TODO_NOTIFY_MESSAGE)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('todo').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// sendNotification shows a desktop notification: with notify-send on Linux and BSD,
+// osascript on macOS, and a toast through PowerShell on Windows.
+func sendNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", `display notification (system attribute "TODO_NOTIFY_MESSAGE") with title (system attribute "TODO_NOTIFY_TITLE")`)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=todo", "--", title, message) // A task may start with "-".
+	}
+	cmd.Env = append(os.Environ(), "TODO_NOTIFY_TITLE="+title, "TODO_NOTIFY_MESSAGE="+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification with %s: %w (%s)", cmd.Path, err, output)
+	}
+	return nil
+}
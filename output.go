@@ -0,0 +1,640 @@
+package main
+
+import (
+	"bytes"         // Package for decoding marshaled output to select fields
+	"encoding/json" // Package for JSON encoding of command results
+	"fmt"           // Package for formatted I/O (e.g., printing to console)
+	"os"            // Package for writing to standard output
+	"strings"       // Package for string manipulation
+	"time"          // Package for the age of trashed todos
+	"todo/pkg/todo" // Package for where a search matched
+)
+
+// outputJSON controls whether command results are emitted as structured JSON
+// instead of human-readable text. It is set from the -json command-line flag.
+var outputJSON bool
+
+// outputFields lists the keys to keep in JSON output (e.g., ["id", "task", "due_date"]),
+// or is empty to emit all keys. It is set from the -fields command-line flag.
+var outputFields []string
+
+// parseFields splits a comma-separated list of JSON keys, ignoring case, spaces, and empty entries.
+func parseFields(list string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields keeps only the given keys of a JSON object, or of each object in a JSON array,
+// so scripts get small payloads that don't change when new keys are added.
+// Error objects are returned unchanged, so failures are still reported.
+func selectFields(v any, fields []string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep large numbers, like durations in nanoseconds, exact.
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	pick := func(object map[string]any) map[string]any {
+		if _, isError := object["error"]; isError && len(object) == 1 {
+			return object
+		}
+		selected := map[string]any{}
+		for _, field := range fields {
+			if value, ok := object[field]; ok {
+				selected[field] = value
+			}
+		}
+		return selected
+	}
+	switch value := decoded.(type) {
+	case map[string]any:
+		return pick(value), nil
+	case []any:
+		for i, element := range value {
+			if object, ok := element.(map[string]any); ok {
+				value[i] = pick(object)
+			}
+		}
+	}
+	return decoded, nil
+}
+
+// printJSON writes the given value to standard output as indented JSON,
+// limited to the keys in outputFields if any are set.
+func printJSON(v any) {
+	if len(outputFields) > 0 {
+		selected, err := selectFields(v, outputFields)
+		if err != nil {
+			logger.Error(err, "Failed to select fields of command output")
+			return
+		}
+		v = selected
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		logger.Error(err, "Failed to marshal command output to JSON")
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// printResult emits v as JSON when JSON output is enabled,
+// and prints the human-readable message otherwise.
+func printResult(v any, message string) {
+	if outputJSON {
+		printJSON(v)
+		return
+	}
+	PrintUserMessage(message)
+}
+
+// printError reports a failed command to the user, as a JSON object with an
+// "error" key when JSON output is enabled.
+func printError(err error) {
+	commandFailures++ // Stops a -transaction batch.
+	printResult(map[string]string{"error": err.Error()}, err.Error())
+}
+
+// logInputError logs a command the user got wrong (e.g., a missing or invalid ID), after its usage
+// or what was wrong has been printed. Like printError, it counts the command as failed.
+func logInputError(err error, message string) {
+	commandFailures++ // Stops a -transaction batch.
+	logger.InputError(err, message)
+}
+
+// printAdded reports a newly added todo.
+func printAdded(todo Todo) {
+	printResult(todo, fmt.Sprintf("✅ Added todo #%d: \"%s\"", todo.ID, todo.Task))
+}
+
+// printBulkAdded reports the todos added in a single bulk operation.
+func printBulkAdded(added []Todo) {
+	if outputJSON {
+		printJSON(added)
+		return
+	}
+	for _, todo := range added {
+		printAdded(todo)
+	}
+	if !minimalOutput {
+		PrintUserMessage(fmt.Sprintf("📥 Added %d todos.", len(added)))
+	}
+}
+
+// printCompleted reports the todo with the given ID as completed.
+func printCompleted(todoList *TodoList, id int) {
+	todo, _ := todoList.Get(id)
+	printResult(todo, fmt.Sprintf("🎉 Completed todo #%d: \"%s\"", todo.ID, todo.Task))
+}
+
+// reportNextOccurrence tells the user about the next occurrence added for a completed recurring todo.
+// In JSON mode the report is logged instead, so it does not mix with the command's JSON output.
+func reportNextOccurrence(todo Todo) {
+	message := fmt.Sprintf("🔁 Next occurrence: todo #%d: \"%s\" (Due: %s)", todo.ID, todo.Task, formatOptionalDate(todo.DueDate))
+	if outputJSON {
+		logger.Info(message)
+		return
+	}
+	PrintUserMessage(message)
+}
+
+// printUncompleted reports the todo with the given ID as incomplete.
+func printUncompleted(todoList *TodoList, id int) {
+	todo, _ := todoList.Get(id)
+	printResult(todo, fmt.Sprintf("🔄 Uncompleted todo #%d: \"%s\"", todo.ID, todo.Task))
+}
+
+// statusEmoji maps each status to the emoji used when reporting a status change.
+var statusEmoji = map[TodoStatus]string{
+	StatusTodo:       "🔄",
+	StatusInProgress: "▶️",
+	StatusWaiting:    "⏳",
+	StatusBlocked:    "⛔",
+	StatusDone:       "✅",
+	StatusCancelled:  "🚫",
+}
+
+// printStatusChanged reports the new status of the todo with the given ID.
+func printStatusChanged(todoList *TodoList, id int) {
+	todo, _ := todoList.Get(id)
+	printResult(todo, fmt.Sprintf("%s Todo #%d is now %s: \"%s\"", statusEmoji[todo.Status], todo.ID, todo.Status, todo.Task))
+}
+
+// printDeleted reports a deleted todo and the subtasks that were deleted with it.
+func printDeleted(todo Todo, subtasks []Todo) {
+	message := fmt.Sprintf("🗑️ Deleted todo #%d: \"%s\"", todo.ID, todo.Task)
+	if len(subtasks) > 0 {
+		message += fmt.Sprintf(" and its %d subtasks", len(subtasks))
+	}
+	printResult(todo, message)
+}
+
+// pluralize returns count followed by the singular or plural form of a noun (e.g., "1 todo" or "2 todos").
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+// printTransactionEnded reports a committed or rolled back transaction and the number of todos it changed.
+func printTransactionEnded(diff TodoListDiff, committed bool) {
+	message := fmt.Sprintf("⏪ Rolled back the transaction: the changes to %s were discarded.", pluralize(diff.changeCount(), "todo", "todos"))
+	if committed {
+		message = fmt.Sprintf("💾 Committed the transaction: %s changed.", pluralize(diff.changeCount(), "todo", "todos"))
+	}
+	printResult(map[string]any{"committed": committed, "changes": diff}, message)
+}
+
+// printRestored reports a todo restored from the trash and the subtasks restored with it.
+func printRestored(todo Todo, subtasks []Todo) {
+	message := fmt.Sprintf("♻️ Restored todo #%d: \"%s\"", todo.ID, todo.Task)
+	if len(subtasks) > 0 {
+		message += fmt.Sprintf(" and its %d subtasks", len(subtasks))
+	}
+	printResult(todo, message)
+}
+
+// printTrash displays the deleted todos in the trash, with how long each is kept.
+func printTrash(trash []TrashedTodo, now time.Time) {
+	if outputJSON {
+		printJSON(trash)
+		return
+	}
+	if len(trash) == 0 {
+		PrintUserMessage("🗑️ The trash is empty.")
+		return
+	}
+	PrintUserMessage("🗑️ Trash (restore a todo with restore <id>):")
+	for _, trashed := range trash {
+		line := fmt.Sprintf("  #%d: \"%s\" (deleted %s", trashed.Todo.ID, trashed.Todo.Task, trashed.DeletedAt.Format(dateFormat))
+		if len(trashed.Subtasks) > 0 {
+			line += fmt.Sprintf(", with %d subtasks", len(trashed.Subtasks))
+		}
+		if trashRetentionDays > 0 {
+			daysLeft := trashRetentionDays - int(now.Sub(trashed.DeletedAt).Hours()/24)
+			line += fmt.Sprintf(", purged in %d days", max(daysLeft, 0))
+		}
+		PrintUserMessage(line + ")")
+	}
+}
+
+// printTrashEmptied reports the todos permanently deleted by emptying the trash.
+func printTrashEmptied(purged []TrashedTodo) {
+	message := "🗑️ The trash is already empty."
+	if len(purged) > 0 {
+		message = fmt.Sprintf("🔥 Permanently deleted %d todos from the trash.", len(purged))
+	}
+	printResult(purged, message)
+}
+
+// printCleared reports the todos removed by clearing completed todos.
+func printCleared(cleared []Todo) {
+	message := "No completed todos to clear."
+	if len(cleared) > 0 {
+		message = fmt.Sprintf("🧹 Cleared %d completed todos.", len(cleared))
+	}
+	printResult(cleared, message)
+}
+
+// printTodos displays the todos matching the given options,
+// either as a JSON array or as the formatted list, paged if it is longer than the terminal.
+func printTodos(todoList *TodoList, options ListOptions) {
+	if outputJSON {
+		printJSON(todoList.Filter(options))
+		return
+	}
+	printPaged(formatList(todoList, options))
+}
+
+// printSearchResults displays the todos matching the given search query, the most relevant
+// first. A fuzzy search also finds todos that match despite typos.
+func printSearchResults(todoList *TodoList, query string, fuzzy bool) {
+	results := todoList.Search(query)
+	if fuzzy {
+		results = todoList.FuzzySearch(query)
+	}
+	if outputJSON {
+		printJSON(results)
+		return
+	}
+	if len(results) == 0 {
+		PrintUserMessage(fmt.Sprintf("🔍 No tasks found matching \"%s\".", query))
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("🔍 Tasks matching \"%s\", the most relevant first:", query))
+	for _, result := range results {
+		if minimalOutput {
+			PrintUserMessage(formatMinimalTodo(result.Todo))
+			continue
+		}
+		PrintUserMessage(strings.TrimRight("  "+formatTodo(highlightSearchMatch(result.Todo, query))+" "+describeSearchMatch(result), " "))
+	}
+}
+
+// describeSearchMatch tells where the query matched a search result, e.g., "(matched in subtask #7)".
+func describeSearchMatch(result SearchResult) string {
+	where := result.MatchedIn
+	switch {
+	case where == "":
+		return ""
+	case where == todo.MatchedInSubtask:
+		where = fmt.Sprintf("subtask #%d", result.MatchedSubtask)
+	}
+	if result.Fuzzy {
+		return "(approximately matched in " + where + ")"
+	}
+	return "(matched in " + where + ")"
+}
+
+// highlightSearchMatch returns a copy of a todo whose task text and tags have the first match
+// of the query highlighted, for showing search results. Nothing is highlighted without colors.
+func highlightSearchMatch(todo Todo, query string) Todo {
+	if !colorsEnabled {
+		return todo
+	}
+	todo.Task = highlightMatch(todo.Task, query)
+	tags := make([]string, len(todo.Tags))
+	for i, tag := range todo.Tags {
+		tags[i] = highlightMatch(tag, query)
+	}
+	todo.Tags = tags
+	return todo
+}
+
+// printPlan displays a daily plan as JSON, or as a "text" or "markdown" plan sheet.
+func printPlan(plan DailyPlan, format string) {
+	if outputJSON {
+		printJSON(plan)
+		return
+	}
+	if format == "markdown" {
+		plan.WriteMarkdown(os.Stdout)
+		return
+	}
+	plan.WriteText(os.Stdout)
+}
+
+// printTodoDetails displays a todo with its full task text, including the URLs that list views
+// shorten to a link marker, followed by all of its links.
+func printTodoDetails(todo Todo) {
+	if outputJSON {
+		printJSON(todo)
+		return
+	}
+	PrintUserMessage(formatTodo(todo))
+	if shortenLinks(todo.Task) != todo.Task {
+		PrintUserMessage("  Task: " + strings.ReplaceAll(todo.Task, "\n", "\n        "))
+	}
+	for _, url := range todoURLs(todo) {
+		PrintUserMessage("  🔗 " + url)
+	}
+}
+
+// printAttached reports a file or link attached to the todo with the given ID.
+func printAttached(id int, attachment Attachment) {
+	if attachment.URL != "" {
+		printResult(attachment, fmt.Sprintf("🔗 Linked %s to todo #%d.", attachment.URL, id))
+		return
+	}
+	printResult(attachment, fmt.Sprintf("📎 Attached %s (%d bytes) to todo #%d.", attachment.Name, attachment.Size, id))
+}
+
+// printAttachments displays the links and files attached to the todo with the given ID.
+func printAttachments(id int, list []Attachment) {
+	if outputJSON {
+		printJSON(list)
+		return
+	}
+	if len(list) == 0 {
+		PrintUserMessage(fmt.Sprintf("📎 Todo #%d has no attachments.", id))
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("📎 Attachments of todo #%d:", id))
+	for _, attachment := range list {
+		if attachment.URL != "" {
+			PrintUserMessage("  🔗 " + attachment.URL)
+			continue
+		}
+		PrintUserMessage(fmt.Sprintf("  %s (%d bytes): %s", attachment.Name, attachment.Size, attachment.Path))
+	}
+}
+
+// printSnoozed reports a snoozed todo, with a hint when it has been postponed chronically.
+func printSnoozed(todo Todo) {
+	message := fmt.Sprintf("😴 Snoozed todo #%d: \"%s\" (Due: %s)", todo.ID, todo.Task, formatOptionalDate(todo.DueDate))
+	if len(todo.Snoozes) >= chronicSnoozeThreshold {
+		message += fmt.Sprintf("\n💡 Snoozed %d times. Consider deleting it or breaking it down.", len(todo.Snoozes))
+	}
+	printResult(todo, message)
+}
+
+// printStats displays the summary of the todo list.
+func printStats(stats TodoStats) {
+	if outputJSON {
+		printJSON(stats)
+		return
+	}
+	PrintUserMessage("📊 Stats:")
+	PrintUserMessage(fmt.Sprintf("  Total: %d, open: %d, completed: %d, cancelled: %d, expired: %d", stats.Total, stats.Open, stats.Completed, stats.Cancelled, stats.Expired))
+	PrintUserMessage(fmt.Sprintf("  Overdue: %d, snoozes of open todos: %d", stats.Overdue, stats.Snoozes))
+	if len(stats.ChronicallySnoozed) == 0 {
+		return
+	}
+	PrintUserMessage("😴 Chronically snoozed (consider deleting or breaking them down):")
+	for _, todo := range stats.ChronicallySnoozed {
+		PrintUserMessage(fmt.Sprintf("  #%d: \"%s\" (snoozed %d times)", todo.ID, todo.Task, len(todo.Snoozes)))
+	}
+}
+
+// OverdueTodo is an overdue todo with how many days late it is, as listed by the overdue command.
+type OverdueTodo struct {
+	Todo
+	DaysOverdue int `json:"days_overdue"` // Days since the due date.
+}
+
+// printOverdue displays the overdue todos, each with how many days late it is.
+func printOverdue(todos []Todo, now time.Time) {
+	if outputJSON {
+		overdue := make([]OverdueTodo, len(todos))
+		for i, todo := range todos {
+			overdue[i] = OverdueTodo{Todo: todo, DaysOverdue: todo.DaysOverdue(now)}
+		}
+		printJSON(overdue)
+		return
+	}
+	if len(todos) == 0 {
+		PrintUserMessage("⏰ No overdue todos.")
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("⏰ %d overdue todos:", len(todos)))
+	for _, todo := range todos {
+		days := todo.DaysOverdue(now)
+		late := fmt.Sprintf("%d days late", days)
+		if days == 1 {
+			late = "1 day late"
+		}
+		PrintUserMessage(fmt.Sprintf("  %s (%s)", formatTodo(todo), late))
+	}
+}
+
+// printAgenda displays the open todos with a due date, grouped by when they are due.
+// Empty groups are left out, except in JSON output.
+func printAgenda(groups []AgendaGroup) {
+	if outputJSON {
+		printJSON(groups)
+		return
+	}
+	empty := true
+	for _, group := range groups {
+		if len(group.Todos) == 0 {
+			continue
+		}
+		if !empty {
+			PrintUserMessage("")
+		}
+		empty = false
+		PrintUserMessage(fmt.Sprintf("📆 %s (%d):", group.Name, len(group.Todos)))
+		for _, todo := range group.Todos {
+			PrintUserMessage("  " + formatTodo(todo))
+		}
+	}
+	if empty {
+		PrintUserMessage("📆 Nothing on the agenda: no open todos have a due date.")
+	}
+}
+
+// printDigest reports whether the email digest was sent, and to whom.
+func printDigest(digest Digest, sent bool, to []string) {
+	if outputJSON {
+		printJSON(struct {
+			Digest
+			Sent bool `json:"sent"`
+		}{digest, sent})
+		return
+	}
+	if !sent {
+		PrintUserMessage("📭 Nothing is overdue or due this week, so no digest was sent.")
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("📧 Sent the digest to %s: %d overdue, %d due today, %d due this week.",
+		strings.Join(to, ", "), len(digest.Overdue), len(digest.DueToday), len(digest.DueThisWeek)))
+}
+
+// printDashboard displays the summary of each list on one screen.
+func printDashboard(summaries []ListSummary) {
+	if outputJSON {
+		printJSON(summaries)
+		return
+	}
+	PrintUserMessage("🧭 Dashboard:")
+	for _, summary := range summaries {
+		if summary.Error != "" {
+			PrintUserMessage(fmt.Sprintf("  %s: could not be loaded (%s)", summary.List, summary.Error))
+			continue
+		}
+		PrintUserMessage(fmt.Sprintf("  %s: %d open, %d due today, %d overdue", summary.List, summary.Open, summary.DueToday, summary.Overdue))
+		if summary.Top != nil {
+			PrintUserMessage(fmt.Sprintf("    Most urgent: #%d \"%s\" (Urgency: %s)", summary.Top.ID, summary.Top.Task, formatUrgency(summary.Urgency)))
+		}
+	}
+}
+
+// printProjects displays the progress of each project.
+func printProjects(projects []ProjectSummary) {
+	if outputJSON {
+		printJSON(projects)
+		return
+	}
+	if len(projects) == 0 {
+		PrintUserMessage("📁 No projects yet. Add a todo to one with add <task> -project <name>.")
+		return
+	}
+	PrintUserMessage("📁 Projects:")
+	for _, project := range projects {
+		PrintUserMessage(fmt.Sprintf("  %s: %d/%d done (%d%%)", project.Name, project.Completed, project.Total, project.Completed*100/project.Total))
+	}
+}
+
+// printSplit reports the subtasks a todo was split into.
+func printSplit(todo Todo, subtasks []Todo) {
+	if outputJSON {
+		printJSON(subtasks)
+		return
+	}
+	if len(subtasks) == 0 {
+		PrintUserMessage(fmt.Sprintf("✂️ Todo #%d was not split: no subtasks were entered.", todo.ID))
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("✂️ Split todo #%d into %d subtasks:", todo.ID, len(subtasks)))
+	for _, subtask := range subtasks {
+		PrintUserMessage("  └ " + formatTodo(subtask))
+	}
+}
+
+// printTimeReport displays the estimated and actual time per project or tag.
+func printTimeReport(report []TimeReportRow, by string) {
+	if outputJSON {
+		printJSON(report)
+		return
+	}
+	if len(report) == 0 {
+		PrintUserMessage("⏱️ No estimates or tracked time yet. Use estimate <id> <duration> and track start <id>.")
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("⏱️ Estimated vs. actual time per %s:", by))
+	for _, row := range report {
+		line := fmt.Sprintf("  %s: %s estimated, %s spent (%d todos)", row.Name, formatDuration(row.Estimate), formatDuration(row.Spent), row.Todos)
+		if row.Estimate > 0 {
+			line += fmt.Sprintf(", %d%% of the estimate", int(row.Spent*100/row.Estimate))
+		}
+		PrintUserMessage(line)
+	}
+}
+
+// printDryRunChanges reports the changes a command would have made in dry-run mode.
+func printDryRunChanges(diff TodoListDiff) {
+	if outputJSON {
+		printJSON(diff)
+		return
+	}
+	PrintUserMessage("🧪 Dry run: no changes were saved.")
+	if diff.IsEmpty() {
+		PrintUserMessage("No todos would change.")
+		return
+	}
+	for _, todo := range diff.Added {
+		PrintUserMessage(fmt.Sprintf("  + would add #%d: \"%s\"", todo.ID, todo.Task))
+	}
+	for _, todo := range diff.Deleted {
+		PrintUserMessage(fmt.Sprintf("  - would delete #%d: \"%s\"", todo.ID, todo.Task))
+	}
+	for _, change := range diff.Modified {
+		PrintUserMessage(fmt.Sprintf("  ~ would modify #%d: %s", change.After.ID, strings.Join(change.Changes, "; ")))
+	}
+}
+
+// printSnapshotDiff displays the todos added, completed, deleted, and modified between two states of a list.
+func printSnapshotDiff(diff SnapshotDiff) {
+	if outputJSON {
+		printJSON(diff)
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("🔍 Changes from %s to %s:", diff.From, diff.To))
+	if diff.IsEmpty() {
+		PrintUserMessage("No todos changed.")
+		return
+	}
+	for _, todo := range diff.Added {
+		PrintUserMessage(fmt.Sprintf("  + added #%d: \"%s\"", todo.ID, todo.Task))
+	}
+	for _, todo := range diff.Completed {
+		PrintUserMessage(fmt.Sprintf("  ✓ completed #%d: \"%s\"", todo.ID, todo.Task))
+	}
+	for _, todo := range diff.Deleted {
+		PrintUserMessage(fmt.Sprintf("  - deleted #%d: \"%s\"", todo.ID, todo.Task))
+	}
+	for _, change := range diff.Modified {
+		// Completion is already reported above; other changes of a completed todo are still shown.
+		changes := []string{}
+		for _, description := range change.Changes {
+			if !strings.HasPrefix(description, "completed: ") {
+				changes = append(changes, description)
+			}
+		}
+		if len(changes) > 0 {
+			PrintUserMessage(fmt.Sprintf("  ~ modified #%d: %s", change.After.ID, strings.Join(changes, "; ")))
+		}
+	}
+}
+
+// printSaveStatus reports whether the last save of dataFile succeeded.
+func printSaveStatus(dataFile string) {
+	status := map[string]any{"data_file": dataFile, "save_failed": false}
+	message := fmt.Sprintf("💾 The last save of %s succeeded (or nothing was saved yet).", dataFile)
+	if failure := loadSaveFailure(dataFile); failure != nil {
+		status["save_failed"], status["failed_at"], status["save_error"] = true, failure.FailedAt, failure.Error
+		message = saveFailureWarning(dataFile)
+	}
+	printResult(status, message)
+}
+
+// reportSaveFailure warns the user, on startup, that the last save of dataFile failed.
+// In JSON mode the warning is logged instead, so it does not mix with the command's JSON output.
+func reportSaveFailure(dataFile string) {
+	warning := saveFailureWarning(dataFile)
+	if warning == "" {
+		return
+	}
+	if outputJSON {
+		logger.Warn(warning)
+		return
+	}
+	PrintUserMessage(warning)
+}
+
+// reportExpired tells the user which todos have just expired. In JSON mode the report
+// is logged instead, so it does not mix with the command's JSON output.
+func reportExpired(expired []Todo) {
+	if len(expired) == 0 {
+		return
+	}
+	if outputJSON {
+		logger.Info(fmt.Sprintf("%d todos expired.", len(expired)))
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("⌛ %d todos expired and were moved out of the default list (see -filter-status expired):", len(expired)))
+	for _, todo := range expired {
+		PrintUserMessage(fmt.Sprintf("  #%d: \"%s\" (expired %s)", todo.ID, todo.Task, formatOptionalDate(todo.ExpiresAt)))
+	}
+}
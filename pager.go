@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., printing to console)
+	"os"      // Package for accessing the environment and standard streams
+	"os/exec" // Package for running the pager
+	"strings" // Package for string manipulation
+)
+
+// defaultPager is the pager used when the PAGER environment variable is not set.
+const defaultPager = "less"
+
+// usePager controls whether output taller than the terminal is shown through a pager.
+// It is disabled by the -no-pager command-line flag.
+var usePager = true
+
+// printPaged prints the given text, piping it through the user's pager ($PAGER, or less)
+// when standard output is a terminal and the text does not fit on one screen.
+// If the pager cannot be started, the text is printed directly.
+func printPaged(text string) {
+	fd := int(os.Stdout.Fd())
+	if !usePager || !isTerminal(fd) {
+		PrintUserMessage(text)
+		return
+	}
+	height := terminalHeight(fd)
+	if height == 0 || strings.Count(text, "\n")+1 < height {
+		PrintUserMessage(text)
+		return
+	}
+
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = []string{defaultPager}
+	}
+	if minimalOutput || !showEmoji {
+		text = stripEmoji(text) // As PrintUserMessage does.
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Quit if the text fits after all, keep colors, and leave the text on screen after quitting.
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := cmd.Start(); err != nil {
+		logger.Warn(fmt.Sprintf("Failed to start pager %q, printing directly: %v", command[0], err))
+		PrintUserMessage(text)
+		return
+	}
+	if err := cmd.Wait(); err != nil {
+		logger.Error(err, fmt.Sprintf("Pager %q exited with an error", command[0]))
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., drawing the checklist)
+	"strconv" // Package for parsing todo IDs in the fallback picker
+	"strings" // Package for string manipulation
+)
+
+// pickTodos lets the user select several todos and returns the IDs of the selected ones,
+// or an empty slice if nothing was selected or the selection was cancelled.
+// On a terminal, todos are shown as a checklist: Up/Down (or k/j) move the cursor,
+// Space toggles a todo, 'a' toggles all, Enter confirms, and 'q' or Ctrl-C cancels.
+// Otherwise, the user is asked to type the IDs of the todos to select.
+func (e *lineEditor) pickTodos(todos []Todo) ([]int, error) {
+	if e.terminal {
+		state, err := makeRaw(e.fd)
+		if err == nil {
+			defer restoreTerminal(e.fd, state)
+			return e.pickTodosFromChecklist(todos)
+		}
+		logger.Error(err, "Failed to enable the checklist picker, falling back to typed IDs")
+	}
+	return e.pickTodosByID(todos)
+}
+
+// pickTodosFromChecklist runs the checklist picker. The terminal must already be in raw mode.
+func (e *lineEditor) pickTodosFromChecklist(todos []Todo) ([]int, error) {
+	selected := make([]bool, len(todos))
+	cursor := 0
+
+	fmt.Fprint(e.out, "Select todos (↑/↓ move, space toggle, a all, enter confirm, q cancel):\n")
+	draw := func() {
+		for i, todo := range todos {
+			pointer := "  "
+			if i == cursor {
+				pointer = "❯ "
+			}
+			mark := "( )"
+			if selected[i] {
+				mark = "(•)"
+			}
+			fmt.Fprintf(e.out, "\r\x1b[K%s\n", e.fitLine(pointer+mark+" "+formatTodo(todo)))
+		}
+	}
+	draw()
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return []int{}, err
+		}
+		if r == keyEscape {
+			r = e.readEscapeSequence()
+		}
+
+		switch r {
+		case '\r', '\n':
+			ids := []int{}
+			for i, todo := range todos {
+				if selected[i] {
+					ids = append(ids, todo.ID)
+				}
+			}
+			return ids, nil
+		case 'q', keyCtrlC:
+			return []int{}, nil
+		case ' ':
+			selected[cursor] = !selected[cursor]
+		case 'a':
+			all := true
+			for _, isSelected := range selected {
+				all = all && isSelected
+			}
+			for i := range selected {
+				selected[i] = !all
+			}
+		case 'k', keyCtrlP:
+			if cursor > 0 {
+				cursor--
+			}
+		case 'j', keyCtrlN:
+			if cursor < len(todos)-1 {
+				cursor++
+			}
+		default:
+			continue
+		}
+
+		// Move back to the first todo line and redraw the checklist in place.
+		fmt.Fprintf(e.out, "\x1b[%dA", len(todos))
+		draw()
+	}
+}
+
+// pickTodosByID lists the todos and reads the IDs to select from a single line of input.
+// Unknown IDs are reported and ignored.
+func (e *lineEditor) pickTodosByID(todos []Todo) ([]int, error) {
+	known := make(map[int]bool, len(todos))
+	for _, todo := range todos {
+		known[todo.ID] = true
+		fmt.Fprintln(e.out, formatTodo(todo))
+	}
+
+	line, err := e.Ask("Enter the IDs of the todos to select (separated by spaces or commas): ")
+	if err != nil && line == "" {
+		return []int{}, err
+	}
+
+	ids := []int{}
+	for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' }) {
+		id, err := strconv.Atoi(field)
+		if err != nil || !known[id] {
+			fmt.Fprintf(e.out, "⚠️ Ignoring unknown todo ID %q.\n", field)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// maxLiveSearchResults limits how many matches the live search shows at once.
+const maxLiveSearchResults = 10
+
+// liveSearch lets the user search todos interactively and returns the ID of the chosen todo.
+// On a terminal, the matches are filtered and highlighted as the user types: Up/Down move the
+// highlight, Enter chooses the highlighted todo, and Ctrl-C cancels. Otherwise, the query is
+// read as a line, the matches are listed, and the user is asked for the ID of a todo.
+// Returns false if no todo was chosen.
+func (e *lineEditor) liveSearch(todoList *TodoList, query string) (int, bool, error) {
+	if e.terminal {
+		state, err := makeRaw(e.fd)
+		if err == nil {
+			defer restoreTerminal(e.fd, state)
+			return e.liveSearchInTerminal(todoList, query)
+		}
+		logger.Error(err, "Failed to enable live search, falling back to typed queries")
+	}
+
+	if query == "" {
+		input, err := e.Ask("Search: ")
+		if err != nil && input == "" {
+			return 0, false, err
+		}
+		query = input
+	}
+	results := todoList.SearchTasks(query).Todos
+	if len(results) == 0 {
+		fmt.Fprintf(e.out, "🔍 No tasks found matching \"%s\".\n", query)
+		return 0, false, nil
+	}
+	ids, err := e.pickTodosByID(results)
+	if len(ids) == 0 {
+		return 0, false, err
+	}
+	return ids[0], true, err
+}
+
+// liveSearchInTerminal runs the live search UI. The terminal must already be in raw mode.
+func (e *lineEditor) liveSearchInTerminal(todoList *TodoList, query string) (int, bool, error) {
+	buf := []rune(query)
+	cursor := 0
+	results := []Todo{}
+
+	draw := func() {
+		results = todoList.SearchTasks(string(buf)).Todos
+		if len(results) > maxLiveSearchResults {
+			results = results[:maxLiveSearchResults]
+		}
+		if cursor >= len(results) {
+			cursor = max(len(results)-1, 0)
+		}
+
+		// Redraw the query line and the matches below it, then put the cursor back after the query.
+		fmt.Fprintf(e.out, "\r\x1b[J/ %s", string(buf))
+		for i, todo := range results {
+			pointer := "  "
+			if i == cursor {
+				pointer = "❯ "
+			}
+			fmt.Fprintf(e.out, "\n%s", e.fitLine(pointer+highlightTodoMatch(todo, string(buf))))
+		}
+		if len(results) == 0 {
+			fmt.Fprint(e.out, "\n  (no matches)")
+		}
+		fmt.Fprintf(e.out, "\x1b[%dA\r\x1b[%dC", max(len(results), 1), textWidth(string(buf))+2)
+	}
+	// clear erases the search UI, leaving the cursor at the start of the query line.
+	clear := func() {
+		fmt.Fprint(e.out, "\r\x1b[J")
+	}
+	draw()
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			clear()
+			return 0, false, err
+		}
+		if r == keyEscape {
+			r = e.readEscapeSequence()
+		}
+
+		switch r {
+		case '\r', '\n':
+			clear()
+			if len(results) == 0 {
+				return 0, false, nil
+			}
+			return results[cursor].ID, true, nil
+		case keyCtrlC, keyCtrlD:
+			clear()
+			return 0, false, nil
+		case keyBackspace, keyCtrlH:
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+				cursor = 0
+			}
+		case keyCtrlU:
+			buf = buf[:0]
+			cursor = 0
+		case keyCtrlP:
+			if cursor > 0 {
+				cursor--
+			}
+		case keyCtrlN:
+			if cursor < len(results)-1 {
+				cursor++
+			}
+		default:
+			if r < ' ' || r == keyBackspace || r == 0 {
+				continue
+			}
+			buf = append(buf, r)
+			cursor = 0
+		}
+		draw()
+	}
+}
+
+// fitLine truncates a line of the picker to the width of the terminal, so that it does not wrap:
+// redrawing moves the cursor up by one row per todo.
+func (e *lineEditor) fitLine(line string) string {
+	if width := terminalWidth(e.fd); width > 0 {
+		return truncateToWidth(line, width-1)
+	}
+	return line
+}
+
+// highlightTodoMatch renders a todo as a compact line with case-insensitive matches
+// of the query highlighted (in reverse video) in its task text and tags.
+func highlightTodoMatch(todo Todo, query string) string {
+	line := fmt.Sprintf("%s %d. %s", statusMarker(todo), todo.ID, highlightMatch(todo.Task, query))
+	if len(todo.Tags) > 0 {
+		tags := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			tags[i] = highlightMatch(tag, query)
+		}
+		line += fmt.Sprintf(" [Tags: %s]", strings.Join(tags, ", "))
+	}
+	return line
+}
+
+// highlightMatch wraps the first case-insensitive occurrence of query in text in reverse video.
+func highlightMatch(text, query string) string {
+	lowerText := strings.ToLower(text)
+	index := strings.Index(lowerText, strings.ToLower(query))
+	// Lowercasing can change the byte length of some characters; skip highlighting then.
+	if query == "" || index < 0 || len(lowerText) != len(text) {
+		return text
+	}
+	end := index + len(query)
+	return text[:index] + "\x1b[7m" + text[index:end] + "\x1b[0m" + text[end:]
+}
@@ -0,0 +1,67 @@
+package todo
+
+import (
+	"sort" // Package for ordering the todos of each agenda group
+	"time" // Package for working with due dates
+)
+
+// Agenda group names, in the order they are shown.
+const (
+	AgendaOverdue  = "Overdue"
+	AgendaToday    = "Today"
+	AgendaTomorrow = "Tomorrow"
+	AgendaThisWeek = "This Week"
+	AgendaLater    = "Later"
+)
+
+// AgendaGroup is a group of open todos in the agenda, by when they are due.
+type AgendaGroup struct {
+	Name  string `json:"name"`  // "Overdue", "Today", "Tomorrow", "This Week", or "Later".
+	Todos []Todo `json:"todos"` // The open todos due then, by due date and then priority.
+}
+
+// Agenda groups the open todos with a due date by when they are due, as of now: Overdue, Today,
+// Tomorrow, This Week (until Sunday, after tomorrow), and Later. Every group is included, even
+// if it is empty. Todos without a due date are left out.
+func (tl *TodoList) Agenda(now time.Time) []AgendaGroup {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC) // Due dates are dates at midnight UTC.
+	tomorrow := today.AddDate(0, 0, 1)
+	endOfWeek := today.AddDate(0, 0, (7-int(today.Weekday()))%7) // Weeks end on Sunday.
+
+	groups := []AgendaGroup{
+		{Name: AgendaOverdue, Todos: []Todo{}},
+		{Name: AgendaToday, Todos: []Todo{}},
+		{Name: AgendaTomorrow, Todos: []Todo{}},
+		{Name: AgendaThisWeek, Todos: []Todo{}},
+		{Name: AgendaLater, Todos: []Todo{}},
+	}
+	for _, todo := range tl.Todos {
+		if !todo.IsOpen() || todo.DueDate == nil {
+			continue
+		}
+		due := todo.DueDate.Truncate(24 * time.Hour) // Todos due at a time of day are grouped by their date.
+		group := 4
+		switch {
+		case todo.IsOverdue(now):
+			group = 0
+		case due.Equal(today):
+			group = 1
+		case due.Equal(tomorrow):
+			group = 2
+		case !due.After(endOfWeek):
+			group = 3
+		}
+		groups[group].Todos = append(groups[group].Todos, todo)
+	}
+
+	for _, group := range groups {
+		todos := group.Todos
+		sort.SliceStable(todos, func(i, j int) bool {
+			if !todos[i].DueDate.Equal(*todos[j].DueDate) {
+				return todos[i].DueDate.Before(*todos[j].DueDate)
+			}
+			return todos[i].Priority.Rank() > todos[j].Priority.Rank()
+		})
+	}
+	return groups
+}
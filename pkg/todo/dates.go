@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strconv" // Package for parsing day counts in relative dates
+	"strings" // Package for string manipulation
+	"time"    // Package for working with dates
+)
+
+// DueTimeLayout is the format of the time of day that may follow a due date (e.g., "14:00").
+const DueTimeLayout = "15:04"
+
+// ParseDueDate parses a date given in YYYY-MM-DD format or in words, relative to today:
+// "today", "tomorrow", a weekday (e.g., "friday" or "next friday"), "next week", "next month",
+// "in 3 days" (or weeks or months), "end of week", or "end of month", and for the past,
+// "yesterday", "last week", or "last month" (the same day a week or month ago). The compact forms
+// "+3d", "+2w", "+1m", and "+1y" (or "-3d" for the past), "eow", and "eom" are accepted too.
+// Like YYYY-MM-DD dates, the result is the date at midnight UTC, unless the date is followed
+// by a time of day (e.g., "2024-12-25 14:00" or "friday 9:30").
+func ParseDueDate(dateStr string) (time.Time, error) {
+	return ParseDueDateTime(dateStr, time.Now())
+}
+
+// ParseDueDateTime parses a date like ParseDate, optionally followed by a time of day in 24-hour
+// HH:MM format. The time is kept on the date in UTC, like the date itself: due times are wall
+// clock times rather than instants, so they are shown and compared as they were entered.
+func ParseDueDateTime(dateStr string, now time.Time) (time.Time, error) {
+	words := strings.Fields(dateStr)
+	if len(words) > 1 {
+		if clock, err := time.Parse(DueTimeLayout, words[len(words)-1]); err == nil {
+			date, err := ParseDate(strings.Join(words[:len(words)-1], " "), now)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return date.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute), nil
+		}
+	}
+	return ParseDate(dateStr, now)
+}
+
+// HasTimeOfDay reports whether a due date has a time of day. A due time of 00:00 is the same as none.
+func HasTimeOfDay(date time.Time) bool {
+	return date.Hour() != 0 || date.Minute() != 0
+}
+
+// ParseDate parses a date like ParseDueDate, relative to the day of now.
+func ParseDate(dateStr string, now time.Time) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", dateStr); err == nil {
+		return date, nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	words := strings.Fields(strings.ToLower(dateStr))
+	phrase := strings.Join(words, " ")
+	switch phrase {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "last week":
+		return today.AddDate(0, 0, -7), nil
+	case "last month":
+		return today.AddDate(0, -1, 0), nil
+	case "next week":
+		return today.AddDate(0, 0, 7), nil
+	case "next month":
+		return today.AddDate(0, 1, 0), nil
+	case "end of week", "eow":
+		return today.AddDate(0, 0, (7-int(today.Weekday()))%7), nil // Weeks end on Sunday.
+	case "end of month", "eom":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	if date, ok := parseDateOffset(phrase, today); ok {
+		return date, nil
+	}
+	if len(words) == 2 && words[0] == "next" {
+		words = words[1:] // "next friday" is the same as "friday".
+	}
+	if len(words) == 1 {
+		if weekday, ok := parseWeekday(words[0]); ok {
+			// A weekday is the next one after today, so "friday" on a Friday is a week away.
+			days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+	if len(words) == 3 && words[0] == "in" {
+		if n, err := strconv.Atoi(words[1]); err == nil && n >= 0 {
+			switch strings.TrimSuffix(words[2], "s") {
+			case "day":
+				return today.AddDate(0, 0, n), nil
+			case "week":
+				return today.AddDate(0, 0, 7*n), nil
+			case "month":
+				return today.AddDate(0, n, 0), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q: use YYYY-MM-DD, today, tomorrow, yesterday, a weekday, next week, last week, next month, in <n> days, end of week, end of month, or an offset like +3d or +2w", dateStr)
+}
+
+// parseDateOffset parses a compact offset from today, a signed number followed by a unit:
+// d (days), w (weeks), m (months), or y (years), e.g., "+3d" or "-1w".
+func parseDateOffset(offset string, today time.Time) (time.Time, bool) {
+	if len(offset) < 3 || (offset[0] != '+' && offset[0] != '-') {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(offset[1 : len(offset)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	if offset[0] == '-' {
+		n = -n
+	}
+	switch offset[len(offset)-1] {
+	case 'd':
+		return today.AddDate(0, 0, n), true
+	case 'w':
+		return today.AddDate(0, 0, 7*n), true
+	case 'm':
+		return today.AddDate(0, n, 0), true
+	case 'y':
+		return today.AddDate(n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// parseWeekday parses the full or abbreviated English name of a weekday (e.g., "friday" or "fri").
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"fmt" // Package for formatted I/O (e.g., error messages)
+)
+
+// AddDependency makes the todo with the given ID depend on (be blocked by) the todo with dependencyID.
+// Returns an error if either todo is not found, or if the dependency would be circular.
+func (tl *TodoList) AddDependency(id int, dependencyID int) error {
+	if _, err := tl.Get(id); err != nil {
+		return err
+	}
+	if _, err := tl.Get(dependencyID); err != nil {
+		return err
+	}
+	if id == dependencyID || tl.dependsOn(dependencyID, id, map[int]bool{}) {
+		return fmt.Errorf("todo with ID %d cannot depend on todo with ID %d: the dependency would be circular", id, dependencyID)
+	}
+
+	for i := range tl.Todos {
+		if tl.Todos[i].ID != id {
+			continue
+		}
+		for _, existing := range tl.Todos[i].DependsOn {
+			if existing == dependencyID {
+				return nil // Already a dependency.
+			}
+		}
+		tl.Todos[i].DependsOn = append(tl.Todos[i].DependsOn, dependencyID)
+	}
+	return nil
+}
+
+// RemoveDependency removes the dependency of the todo with the given ID on the todo with dependencyID.
+// Returns an error if the todo is not found or does not have that dependency.
+func (tl *TodoList) RemoveDependency(id int, dependencyID int) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID != id {
+			continue
+		}
+		for j, existing := range tl.Todos[i].DependsOn {
+			if existing == dependencyID {
+				tl.Todos[i].DependsOn = append(tl.Todos[i].DependsOn[:j], tl.Todos[i].DependsOn[j+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("todo with ID %d does not depend on todo with ID %d", id, dependencyID)
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// dependsOn reports whether the todo with the given ID depends on the todo with targetID,
+// directly or through other dependencies. Visited todos are skipped.
+func (tl *TodoList) dependsOn(id int, targetID int, visited map[int]bool) bool {
+	if visited[id] {
+		return false
+	}
+	visited[id] = true
+	todo, err := tl.Get(id)
+	if err != nil {
+		return false
+	}
+	for _, dependencyID := range todo.DependsOn {
+		if dependencyID == targetID || tl.dependsOn(dependencyID, targetID, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenDependencies returns the IDs of the todos the given todo depends on that are still open
+// (neither completed, cancelled, nor expired). Dependencies on deleted todos are ignored.
+func (tl *TodoList) OpenDependencies(todo Todo) []int {
+	open := []int{}
+	for _, dependencyID := range todo.DependsOn {
+		dependency, err := tl.Get(dependencyID)
+		if err == nil && dependency.IsOpen() {
+			open = append(open, dependencyID)
+		}
+	}
+	return open
+}
+
+// IsBlocked reports whether the given todo is open and has open dependencies.
+func (tl *TodoList) IsBlocked(todo Todo) bool {
+	return todo.IsOpen() && len(tl.OpenDependencies(todo)) > 0
+}
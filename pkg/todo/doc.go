@@ -0,0 +1,22 @@
+// Package todo is the todo engine of the todo CLI: todos and todo lists, filtering, searching,
+// and sorting them, recurring todos, projects, subtasks, and dependencies, and saving lists
+// as JSON files. It can be embedded in other applications.
+//
+// The package never prints anything. Failures are returned as errors, and what the package
+// does is logged through a Logger, which discards everything unless one is set with
+// SetDefaultLogger or TodoList.SetLogger.
+//
+//	list, err := todo.LoadFromFile("todos.json")
+//	if err != nil {
+//		return err
+//	}
+//	due, _ := todo.ParseDate("tomorrow", time.Now())
+//	list.Add("Write report", todo.PriorityHigh, &due, []string{"work"})
+//	open := list.Filter(todo.ListOptions{Query: "tag:work AND NOT status:done", SortBy: "due_date"})
+//	...
+//	return list.SaveToFile("todos.json")
+//
+// The settings of the package (SetDefaultLogger, SetUrgencyWeights, and
+// SetDefaultRecurrenceRules) apply to all lists, and are meant to be set once, before the
+// package is used. A TodoList is not safe for concurrent use.
+package todo
@@ -0,0 +1,38 @@
+package todo
+
+import (
+	"time" // Package for the start of timed operations
+)
+
+// Logger receives what the package does: warnings about input it corrected, failures it also
+// returns as errors, and, at debug level, how long filtering, sorting, and file access took.
+// The package never prints anything itself.
+type Logger interface {
+	Debug(message string)
+	Info(message string)
+	Warn(message string)
+	Error(err error, message string)
+	Timing(operation string, start time.Time) // Logs how long the operation started at start took.
+}
+
+// discardLogger is a Logger that drops everything.
+type discardLogger struct{}
+
+func (discardLogger) Debug(string)             {}
+func (discardLogger) Info(string)              {}
+func (discardLogger) Warn(string)              {}
+func (discardLogger) Error(error, string)      {}
+func (discardLogger) Timing(string, time.Time) {}
+
+// defaultLogger is the logger of LoadFromFile and of the TodoLists without a logger of their own.
+var defaultLogger Logger = discardLogger{}
+
+// SetDefaultLogger makes l the logger of LoadFromFile, sort expressions, and the TodoLists without
+// a logger of their own (see TodoList.SetLogger). Nothing is logged by default, or if l is nil.
+// Like the other package settings, it is meant to be set once, before the package is used.
+func SetDefaultLogger(l Logger) {
+	if l == nil {
+		l = discardLogger{}
+	}
+	defaultLogger = l
+}
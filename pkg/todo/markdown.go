@@ -0,0 +1,68 @@
+package todo
+
+import (
+	"bufio"   // Package for reading the Markdown file line by line
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"io"      // Package for I/O primitives
+	"regexp"  // Package for matching checklist items and headers
+	"strings" // Package for string manipulation
+)
+
+var (
+	// markdownChecklistItem matches checklist items such as "- [ ] Task" or "  * [x] Done task".
+	markdownChecklistItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+?)\s*$`)
+	// markdownHeader matches ATX headers such as "## Project Apollo".
+	markdownHeader = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+)
+
+// ImportMarkdown adds a todo for every checklist item ("- [ ]" or "- [x]") read from r.
+// Items checked with [x] are imported as completed. The headers above an item become its tags,
+// converted to lowercase with spaces replaced by dashes (e.g., "## Project Apollo" -> "project-apollo").
+// Nested items are imported as regular todos with the same tags as their parent.
+// Returns the imported todos.
+func (tl *TodoList) ImportMarkdown(r io.Reader) ([]Todo, error) {
+	imported := []Todo{}
+	headers := []string{} // Tags from the enclosing headers, indexed by header level - 1.
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := markdownHeader.FindStringSubmatch(line); match != nil {
+			level := len(match[1])
+			// A header replaces any header at the same or a deeper level.
+			for len(headers) < level {
+				headers = append(headers, "")
+			}
+			headers = append(headers[:level-1], headerToTag(match[2]))
+			continue
+		}
+
+		match := markdownChecklistItem.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		tags := []string{}
+		for _, tag := range headers {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+
+		todo := tl.Add(match[2], PriorityMedium, nil, tags)
+		if match[1] != " " {
+			tl.Complete(todo.ID)
+			todo, _ = tl.Get(todo.ID)
+		}
+		imported = append(imported, todo)
+	}
+	if err := scanner.Err(); err != nil {
+		return imported, fmt.Errorf("failed to read markdown: %w", err)
+	}
+	return imported, nil
+}
+
+// headerToTag converts a Markdown header text into a tag, e.g., "Project Apollo" -> "project-apollo".
+func headerToTag(header string) string {
+	return strings.Join(strings.Fields(strings.ToLower(header)), "-")
+}
@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., error messages)
+	"sort" // Package for sorting todos by their manual order
+)
+
+// Positions a todo can be moved to with TodoList.Move.
+const (
+	MoveUp     = "up"
+	MoveDown   = "down"
+	MoveTop    = "top"
+	MoveBottom = "bottom"
+	MoveAfter  = "after"
+)
+
+// nextOrder returns the manual order position for a new todo: after all existing todos.
+func (tl *TodoList) nextOrder() int {
+	order := 0
+	for _, todo := range tl.Todos {
+		order = max(order, todo.Order)
+	}
+	return order + 1
+}
+
+// normalizeOrder gives todos saved before manual ordering existed a position after
+// the ordered ones, in the order they are stored in.
+func (tl *TodoList) normalizeOrder() {
+	next := tl.nextOrder()
+	for i := range tl.Todos {
+		if tl.Todos[i].Order == 0 {
+			tl.Todos[i].Order = next
+			next++
+		}
+	}
+}
+
+// Move changes the manual order of the todo with the given ID among its siblings (the todos
+// with the same parent): "up" or "down" by one place, to the "top" or "bottom", or "after"
+// the sibling with ID afterID. Filter honors the new order with SortBy "order".
+// Returns an error if either todo is not found, the position is invalid, or afterID is not a sibling.
+func (tl *TodoList) Move(id int, position string, afterID int) error {
+	todo, err := tl.Get(id)
+	if err != nil {
+		return err
+	}
+	siblings := []*Todo{}
+	for i := range tl.Todos {
+		if tl.Todos[i].ParentID == todo.ParentID {
+			siblings = append(siblings, &tl.Todos[i])
+		}
+	}
+	sort.SliceStable(siblings, func(i, j int) bool { return siblings[i].Order < siblings[j].Order })
+
+	// The siblings keep the positions they hold among all todos, and swap them between each other.
+	slots := make([]int, len(siblings))
+	from := 0
+	for i, sibling := range siblings {
+		slots[i] = sibling.Order
+		if sibling.ID == id {
+			from = i
+		}
+	}
+	moved := siblings[from]
+	rest := append(append([]*Todo{}, siblings[:from]...), siblings[from+1:]...)
+
+	to := 0
+	switch position {
+	case MoveUp:
+		to = max(from-1, 0)
+	case MoveDown:
+		to = min(from+1, len(rest))
+	case MoveTop:
+		to = 0
+	case MoveBottom:
+		to = len(rest)
+	case MoveAfter:
+		if afterID == id {
+			return fmt.Errorf("todo #%d cannot be moved after itself", id)
+		}
+		to = -1
+		for i, sibling := range rest {
+			if sibling.ID == afterID {
+				to = i + 1
+			}
+		}
+		if to < 0 {
+			if _, err := tl.Get(afterID); err != nil {
+				return err
+			}
+			return fmt.Errorf("todo #%d can only be moved after a todo with the same parent, and #%d is not one", id, afterID)
+		}
+	default:
+		return fmt.Errorf("invalid position %q: use up, down, top, bottom, or after <id>", position)
+	}
+
+	reordered := append(append(append([]*Todo{}, rest[:to]...), moved), rest[to:]...)
+	for i, sibling := range reordered {
+		sibling.Order = slots[i]
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"sort"    // Package for ordering projects by name
+	"strings" // Package for string manipulation
+)
+
+// ProjectSummary describes the progress of a project.
+type ProjectSummary struct {
+	Name      string `json:"name"`      // The project name.
+	Total     int    `json:"total"`     // Number of todos in the project.
+	Completed int    `json:"completed"` // Number of completed todos in the project.
+}
+
+// SetProject moves the todo with the given ID to a project, or out of its project if the name is empty.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetProject(id int, project string) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Project = project
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// RenameProject moves all todos in the project oldName to newName.
+// Project names are matched case-insensitively. Returns the number of todos that were moved,
+// or an error if no todo is in the project.
+func (tl *TodoList) RenameProject(oldName string, newName string) (int, error) {
+	renamed := 0
+	for i := range tl.Todos {
+		if tl.Todos[i].Project != "" && strings.EqualFold(tl.Todos[i].Project, oldName) {
+			tl.Todos[i].Project = newName
+			renamed++
+		}
+	}
+	if renamed == 0 {
+		return 0, fmt.Errorf("project %q not found", oldName)
+	}
+	return renamed, nil
+}
+
+// Projects returns the progress of every project that has todos, sorted by name.
+// Expired and cancelled todos are not counted.
+func (tl *TodoList) Projects() []ProjectSummary {
+	byName := map[string]*ProjectSummary{}
+	for _, todo := range tl.Todos {
+		if todo.Project == "" || todo.Expired || todo.Status == StatusCancelled {
+			continue
+		}
+		summary, ok := byName[todo.Project]
+		if !ok {
+			summary = &ProjectSummary{Name: todo.Project}
+			byName[todo.Project] = summary
+		}
+		summary.Total++
+		if todo.Completed {
+			summary.Completed++
+		}
+	}
+
+	projects := []ProjectSummary{}
+	for _, summary := range byName {
+		projects = append(projects, *summary)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+	})
+	return projects
+}
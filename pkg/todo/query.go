@@ -0,0 +1,360 @@
+package todo
+
+import (
+	"cmp"     // Package for comparing IDs and priority ranks
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strconv" // Package for parsing IDs in queries
+	"strings" // Package for string manipulation
+	"time"    // Package for comparing dates in queries
+	"unicode" // Package for classifying characters while tokenizing
+)
+
+// queryNode reports whether a todo matches a parsed query (or part of one).
+type queryNode func(todo Todo) bool
+
+// Query is a compiled filter query, e.g., "priority:high AND tag:work AND due<2025-01-01 AND NOT status:done".
+//
+// A query is made of terms combined with AND, OR, and NOT (in capitals) and parentheses. Terms
+// next to each other must all match, as if joined with AND, which binds tighter than OR. A term is
+// a field, an operator, and a value, or a plain word, which matches the task or a tag like search.
+// Values with spaces are quoted (e.g., task:"weekly report" or due<"next friday").
+//
+//	priority  high, medium, low, or none; also with < > <= >= (e.g., priority>=medium)
+//	tag       a tag of the todo, or none for todos without tags
+//	project   the project of the todo, or none for todos without a project
+//	status    todo, in-progress, waiting, blocked, done, cancelled, completed, incomplete, overdue, or expired
+//	due       a date (YYYY-MM-DD, in words, or an offset like +3d) or none; also with < > <= >=
+//	created   a date, like due, compared to the day the todo was created
+//	task      text contained in the task
+//	id        a todo ID; also with < > <= >=
+//
+// ":" and "=" test for equality, and "!=" for inequality.
+type Query struct {
+	source  string
+	root    queryNode
+	expired bool // Whether the query refers to expired todos, which lists hide otherwise.
+}
+
+// queryOperators are the operators between the field and value of a query term, longest first.
+var queryOperators = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+// CompileQuery parses a filter query. Relative dates (e.g., "today" or "+3d") are resolved
+// against the day of now.
+func CompileQuery(source string, now time.Time) (*Query, error) {
+	tokens, err := tokenizeQuery(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	p := &queryParser{tokens: tokens, now: now}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", source, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid query %q: unexpected %q", source, p.tokens[p.pos])
+	}
+	return &Query{source: source, root: root, expired: p.expired}, nil
+}
+
+// Matches reports whether a todo matches the query.
+func (q *Query) Matches(todo Todo) bool {
+	return q.root(todo)
+}
+
+// tokenizeQuery splits a query into parentheses and words. Quoted parts of a word keep their
+// spaces and lose their quotes, so `task:"weekly report"` is the single word `task:weekly report`.
+func tokenizeQuery(source string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			var word strings.Builder
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] == '"' {
+					end := i + 1
+					for end < len(runes) && runes[end] != '"' {
+						end++
+					}
+					if end == len(runes) {
+						return nil, fmt.Errorf("unterminated quote in query %q", source)
+					}
+					word.WriteString(string(runes[i+1 : end]))
+					i = end + 1
+					continue
+				}
+				word.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, word.String())
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive-descent parser for filter queries.
+type queryParser struct {
+	tokens  []string
+	pos     int
+	now     time.Time
+	expired bool
+}
+
+// peek returns the current token, or an empty string at the end of the input.
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// parseOr parses terms joined with OR.
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "OR" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(todo Todo) bool { return a(todo) || b(todo) }
+	}
+	return left, nil
+}
+
+// parseAnd parses terms joined with AND, or simply written next to each other.
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for token := p.peek(); token != "" && token != "OR" && token != ")"; token = p.peek() {
+		if token == "AND" {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		a, b := left, right
+		left = func(todo Todo) bool { return a(todo) && b(todo) }
+	}
+	return left, nil
+}
+
+// parseNot parses a term, a parenthesized query, or either negated with NOT.
+func (p *queryParser) parseNot() (queryNode, error) {
+	switch token := p.peek(); token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of query")
+	case "NOT":
+		p.pos++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(todo Todo) bool { return !operand(todo) }, nil
+	case "(":
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("expected \")\", got %q", p.peek())
+		}
+		p.pos++
+		return inner, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("unexpected %q", token)
+	default:
+		p.pos++
+		return p.parseTerm(token)
+	}
+}
+
+// parseTerm parses a single term, a field compared to a value or a plain word.
+func (p *queryParser) parseTerm(token string) (queryNode, error) {
+	field, op, value, ok := splitQueryTerm(token)
+	if !ok {
+		word := strings.ToLower(token)
+		return func(todo Todo) bool { return matchesText(todo, word) }, nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing value in %q", token)
+	}
+	equality := op == ":" || op == "=" || op == "!="
+	var node queryNode
+	switch field {
+	case "priority":
+		priority := PriorityLevel(value).Canonical()
+		if priority == "" && !strings.EqualFold(value, "none") {
+			return nil, fmt.Errorf("invalid priority %q: use high, medium, low, or none", value)
+		}
+		rank := priority.Rank()
+		node = func(todo Todo) bool { return compareQuery(op, cmp.Compare(todo.Priority.Rank(), rank)) }
+	case "tag", "tags":
+		if !equality {
+			return nil, fmt.Errorf("%s only supports : and !=", field)
+		}
+		node = func(todo Todo) bool {
+			if strings.EqualFold(value, "none") {
+				return len(todo.Tags) == 0
+			}
+			for _, tag := range todo.Tags {
+				if strings.EqualFold(tag, value) {
+					return true
+				}
+			}
+			return false
+		}
+	case "project":
+		if !equality {
+			return nil, fmt.Errorf("project only supports : and !=")
+		}
+		node = func(todo Todo) bool {
+			return strings.EqualFold(todo.Project, value) || (todo.Project == "" && strings.EqualFold(value, "none"))
+		}
+	case "status":
+		if !equality {
+			return nil, fmt.Errorf("status only supports : and !=")
+		}
+		status := strings.ToLower(value)
+		switch status {
+		case "completed", "incomplete", "overdue":
+		case "expired":
+			p.expired = true
+		default:
+			parsed, err := ParseStatus(status)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status %q: use todo, in-progress, waiting, blocked, done, cancelled, completed, incomplete, overdue, or expired", value)
+			}
+			status = string(parsed)
+		}
+		now := p.now
+		node = func(todo Todo) bool {
+			if status == "expired" {
+				return todo.Expired
+			}
+			return matchesStatus(todo, status, now)
+		}
+	case "due", "created":
+		if strings.EqualFold(value, "none") {
+			if !equality || field == "created" {
+				return nil, fmt.Errorf("%s only supports : and != with none", field)
+			}
+			node = func(todo Todo) bool { return (todo.DueDate == nil) != (op == "!=") }
+			break
+		}
+		date, err := ParseDate(value, p.now)
+		if err != nil {
+			return nil, err
+		}
+		day := date.Format("2006-01-02")
+		node = func(todo Todo) bool {
+			if field == "created" {
+				return compareQuery(op, strings.Compare(todo.CreatedAt.Format("2006-01-02"), day))
+			}
+			return todo.DueDate != nil && compareQuery(op, strings.Compare(todo.DueDate.Format("2006-01-02"), day))
+		}
+		if op == "!=" {
+			// A todo without a due date is not due on the day either.
+			inner := node
+			node = func(todo Todo) bool { return todo.DueDate == nil || inner(todo) }
+		}
+	case "task":
+		if !equality {
+			return nil, fmt.Errorf("task only supports : and !=")
+		}
+		text := strings.ToLower(value)
+		node = func(todo Todo) bool { return strings.Contains(strings.ToLower(todo.Task), text) }
+	case "id":
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", value)
+		}
+		node = func(todo Todo) bool { return compareQuery(op, cmp.Compare(todo.ID, id)) }
+	default:
+		return nil, fmt.Errorf("unknown field %q: use priority, tag, project, status, due, created, task, or id", field)
+	}
+	if op == "!=" && field != "priority" && field != "id" && field != "due" && field != "created" {
+		matches := node
+		node = func(todo Todo) bool { return !matches(todo) }
+	}
+	return node, nil
+}
+
+// splitQueryTerm splits a term like "due<2025-01-01" into its field, operator, and value.
+// Returns false if the term is a plain word.
+func splitQueryTerm(token string) (field, op, value string, ok bool) {
+	start := strings.IndexAny(token, ":=!<>")
+	if start <= 0 {
+		return "", "", "", false
+	}
+	for _, candidate := range queryOperators {
+		if strings.HasPrefix(token[start:], candidate) {
+			return strings.ToLower(token[:start]), candidate, token[start+len(candidate):], true
+		}
+	}
+	return "", "", "", false
+}
+
+// compareQuery applies a comparison operator of a query to the result of comparing
+// the value of a todo to the value in the query (-1, 0, or +1).
+func compareQuery(op string, result int) bool {
+	switch op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "!=":
+		return result != 0
+	}
+	return result == 0
+}
+
+// matchesText reports whether the task or a tag of a todo contains the lowercase text, like search.
+func matchesText(todo Todo, text string) bool {
+	if strings.Contains(strings.ToLower(todo.Task), text) {
+		return true
+	}
+	for _, tag := range todo.Tags {
+		if strings.Contains(strings.ToLower(tag), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesStatus reports whether a todo has the status filtered for: "all", "completed",
+// "incomplete" (neither completed nor cancelled), "overdue", or a TodoStatus.
+func matchesStatus(todo Todo, filter string, now time.Time) bool {
+	switch filter {
+	case "", "all", "expired":
+		return true
+	case "completed":
+		return todo.Completed
+	case "incomplete":
+		return !todo.Completed && todo.Status != StatusCancelled
+	case "overdue":
+		return todo.IsOverdue(now)
+	}
+	status, err := ParseStatus(filter)
+	return err != nil || status == todo.Status || (status == StatusTodo && todo.Status == "")
+}
@@ -0,0 +1,239 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strconv" // Package for parsing recurrence intervals
+	"strings" // Package for string manipulation
+	"time"    // Package for computing the next occurrence
+)
+
+// Recurrence describes how often a recurring todo repeats:
+// every interval units (e.g., every 2 weeks), or every week on a given weekday.
+type Recurrence struct {
+	interval  int          // Number of units between occurrences.
+	unit      string       // "day", "week", "month", or "year".
+	weekday   time.Weekday // The weekday of each occurrence, if onWeekday is set.
+	onWeekday bool         // Whether the todo repeats on a weekday (e.g., "every monday").
+}
+
+// Recurrence advancement rules: what the next occurrence of a recurring todo is scheduled from,
+// and what happens to the occurrences that were missed when it is completed late.
+const (
+	RecurFromSchedule    = "schedule"   // The next occurrence follows the due date, keeping the schedule (e.g., always on Mondays).
+	RecurFromCompletion  = "completion" // The next occurrence is one interval after the day the todo was completed.
+	RecurOverduePileUp   = "pile-up"    // Missed occurrences are added one after the other, even if already overdue.
+	RecurOverdueCollapse = "collapse"   // Missed occurrences are skipped, so the next one is due today or later.
+)
+
+// recurrenceFrom and recurrenceOverdue are the advancement rules of recurring todos that
+// don't set their own.
+var (
+	recurrenceFrom    = RecurFromSchedule
+	recurrenceOverdue = RecurOverduePileUp
+)
+
+// SetDefaultRecurrenceRules sets the advancement rules of recurring todos that don't set their own
+// (see TodoList.SetRecurrenceRules). Empty rules fall back to "schedule" and "pile-up", the defaults.
+// Returns an error, and changes nothing, if a rule is invalid.
+func SetDefaultRecurrenceRules(from string, overdue string) error {
+	if err := ValidateRecurrenceRules(from, overdue); err != nil {
+		return err
+	}
+	recurrenceFrom, recurrenceOverdue = RecurFromSchedule, RecurOverduePileUp
+	if from != "" {
+		recurrenceFrom = from
+	}
+	if overdue != "" {
+		recurrenceOverdue = overdue
+	}
+	return nil
+}
+
+// ValidateRecurrenceRules checks the advancement rules of recurring todos. Empty rules are valid.
+func ValidateRecurrenceRules(from string, overdue string) error {
+	if from != "" && from != RecurFromSchedule && from != RecurFromCompletion {
+		return fmt.Errorf("invalid recurrence basis %q: use %s or %s", from, RecurFromSchedule, RecurFromCompletion)
+	}
+	if overdue != "" && overdue != RecurOverduePileUp && overdue != RecurOverdueCollapse {
+		return fmt.Errorf("invalid overdue recurrence rule %q: use %s or %s", overdue, RecurOverduePileUp, RecurOverdueCollapse)
+	}
+	return nil
+}
+
+// recurrenceAliases maps single-word recurrence rules to their canonical form.
+var recurrenceAliases = map[string]string{
+	"daily":    "every day",
+	"weekly":   "every week",
+	"monthly":  "every month",
+	"yearly":   "every year",
+	"annually": "every year",
+}
+
+// ParseRecurrence parses a recurrence rule such as "daily", "weekly", "monthly", "yearly",
+// "every day", "every 2 weeks", "every 3 months", or "every monday" (weekday names may be abbreviated).
+func ParseRecurrence(rule string) (Recurrence, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(rule)), " ")
+	if alias, ok := recurrenceAliases[normalized]; ok {
+		normalized = alias
+	}
+	fields := strings.Fields(strings.TrimPrefix(normalized, "every "))
+	if !strings.HasPrefix(normalized, "every ") || len(fields) == 0 || len(fields) > 2 {
+		return Recurrence{}, fmt.Errorf("invalid recurrence %q: use daily, weekly, monthly, yearly, every <n> <days|weeks|months|years>, or every <weekday>", rule)
+	}
+
+	r := Recurrence{interval: 1}
+	if len(fields) == 2 {
+		interval, err := strconv.Atoi(fields[0])
+		if err != nil || interval < 1 {
+			return Recurrence{}, fmt.Errorf("invalid recurrence interval %q in %q", fields[0], rule)
+		}
+		r.interval = interval
+	}
+	unit := strings.TrimSuffix(fields[len(fields)-1], "s")
+	switch unit {
+	case "day", "week", "month", "year":
+		r.unit = unit
+		return r, nil
+	}
+	if len(fields) == 1 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			name := strings.ToLower(day.String())
+			if fields[0] == name || fields[0] == name[:3] {
+				return Recurrence{interval: 1, unit: "week", weekday: day, onWeekday: true}, nil
+			}
+		}
+	}
+	return Recurrence{}, fmt.Errorf("invalid recurrence unit %q in %q", fields[len(fields)-1], rule)
+}
+
+// String returns the canonical form of the recurrence rule (e.g., "every 2 weeks" or "every monday").
+func (r Recurrence) String() string {
+	if r.onWeekday {
+		return "every " + strings.ToLower(r.weekday.String())
+	}
+	if r.interval == 1 {
+		return "every " + r.unit
+	}
+	return fmt.Sprintf("every %d %ss", r.interval, r.unit)
+}
+
+// Next returns the first occurrence after the given date.
+func (r Recurrence) Next(after time.Time) time.Time {
+	if r.onWeekday {
+		days := (int(r.weekday) - int(after.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return after.AddDate(0, 0, days)
+	}
+	switch r.unit {
+	case "week":
+		return after.AddDate(0, 0, 7*r.interval)
+	case "month":
+		return after.AddDate(0, r.interval, 0)
+	case "year":
+		return after.AddDate(r.interval, 0, 0)
+	}
+	return after.AddDate(0, 0, r.interval)
+}
+
+// SetRecurrence makes the todo with the given ID repeat according to the rule,
+// stored in its canonical form, or stops it from repeating if the rule is empty.
+// Returns an error if the rule is invalid or the todo is not found.
+func (tl *TodoList) SetRecurrence(id int, rule string) error {
+	if rule != "" {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			return err
+		}
+		rule = r.String()
+	}
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Recurrence = rule
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetRecurrenceRules sets how the next occurrence of the todo with the given ID is scheduled when it
+// is completed (from, "schedule" or "completion"), and what happens to missed occurrences (overdue,
+// "pile-up" or "collapse"). An empty rule makes the todo follow the default (see SetDefaultRecurrenceRules).
+// Returns an error if a rule is invalid or the todo is not found.
+func (tl *TodoList) SetRecurrenceRules(id int, from string, overdue string) error {
+	if err := ValidateRecurrenceRules(from, overdue); err != nil {
+		return err
+	}
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].RecurFrom = from
+			tl.Todos[i].RecurOverdue = overdue
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// ScheduleNextOccurrence adds the next occurrence of the recurring todo with the given ID,
+// with the same task, priority, tags, project, parent, recurrence, and advancement rules.
+// By default, it is due on the next date of the schedule after the todo's due date, even if
+// that date has already passed. With the "completion" rule it is due one interval after the
+// completion date instead, and with the "collapse" rule the missed dates of the schedule are
+// skipped. A todo without a due date always recurs from its completion date.
+// Returns the new todo, or an error if the todo is not found or not recurring.
+func (tl *TodoList) ScheduleNextOccurrence(id int, completedAt time.Time) (Todo, error) {
+	todo, err := tl.Get(id)
+	if err != nil {
+		return Todo{}, err
+	}
+	if todo.Recurrence == "" {
+		return Todo{}, fmt.Errorf("todo with ID %d is not recurring", id)
+	}
+	r, err := ParseRecurrence(todo.Recurrence)
+	if err != nil {
+		return Todo{}, err
+	}
+
+	// Due dates are stored as dates at midnight UTC.
+	completionDay := time.Date(completedAt.Year(), completedAt.Month(), completedAt.Day(), 0, 0, 0, 0, time.UTC)
+	from := completionDay
+	if todo.DueDate != nil && todo.recurFrom() == RecurFromSchedule {
+		from = *todo.DueDate
+	}
+	dueDate := r.Next(from)
+	if todo.recurOverdue() == RecurOverdueCollapse {
+		for dueDate.Before(completionDay) {
+			dueDate = r.Next(dueDate)
+		}
+	}
+
+	var tags []string
+	if todo.Tags != nil {
+		tags = append([]string{}, todo.Tags...)
+	}
+	next := tl.Add(todo.Task, todo.Priority, &dueDate, tags)
+	tl.SetRecurrence(next.ID, todo.Recurrence)
+	tl.SetProject(next.ID, todo.Project)
+	tl.SetRecurrenceRules(next.ID, todo.RecurFrom, todo.RecurOverdue)
+	if todo.ParentID != 0 {
+		tl.SetParent(next.ID, todo.ParentID)
+	}
+	return tl.Get(next.ID)
+}
+
+// recurFrom returns what the next occurrence of the todo is scheduled from: its own rule, or the default one.
+func (todo Todo) recurFrom() string {
+	if todo.RecurFrom != "" {
+		return todo.RecurFrom
+	}
+	return recurrenceFrom
+}
+
+// recurOverdue returns what happens to the missed occurrences of the todo: its own rule, or the default one.
+func (todo Todo) recurOverdue() string {
+	if todo.RecurOverdue != "" {
+		return todo.RecurOverdue
+	}
+	return recurrenceOverdue
+}
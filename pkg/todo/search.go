@@ -0,0 +1,183 @@
+package todo
+
+import (
+	"sort"    // Package for ranking search results
+	"strings" // Package for matching the query against task text and tags
+)
+
+// How well a field of a todo matches a search query, from best to worst.
+const (
+	matchExact     = 4 // The whole field is the query.
+	matchPrefix    = 3 // The field, or a word in it, starts with the query.
+	matchSubstring = 2 // The query appears somewhere in the field.
+	matchFuzzy     = 1 // The query matches a word of the field despite typos (fuzzy search only).
+)
+
+// Weights of the fields of a todo in the relevance score of a search, so that a match in
+// the task text ranks above the same kind of match in a tag or subtask.
+const (
+	taskMatchWeight    = 2
+	tagMatchWeight     = 1
+	subtaskMatchWeight = 1
+)
+
+// Where a search query matched a todo.
+const (
+	MatchedInTask    = "task"
+	MatchedInTag     = "tag"
+	MatchedInSubtask = "subtask"
+)
+
+// SearchResult is a todo found by a search, with its relevance score and where the query matched.
+type SearchResult struct {
+	Todo
+	Score          int    `json:"score"`                     // Relevance of the todo to the query; higher is better.
+	MatchedIn      string `json:"matched_in,omitempty"`      // "task", "tag", or "subtask"; empty for an empty query.
+	MatchedSubtask int    `json:"matched_subtask,omitempty"` // ID of the subtask the query matched, if it matched one.
+	Fuzzy          bool   `json:"fuzzy,omitempty"`           // Whether the query only matched approximately.
+}
+
+// matchRank returns how well text matches the lowercase query: matchExact, matchPrefix,
+// matchSubstring, or 0 if the query does not appear in text.
+func matchRank(text, query string) int {
+	text = strings.ToLower(text)
+	switch {
+	case text == query:
+		return matchExact
+	case strings.Contains(" "+text, " "+query): // At the start of the text or of a word.
+		return matchPrefix
+	case strings.Contains(text, query):
+		return matchSubstring
+	}
+	return 0
+}
+
+// fuzzyMatchRank ranks text like matchRank, but also returns matchFuzzy if every word of the query
+// is close to a word of the text (see fuzzyWordMatch), so that typos and partial words still match.
+func fuzzyMatchRank(text, query string) int {
+	if rank := matchRank(text, query); rank > 0 {
+		return rank
+	}
+	words := strings.Fields(strings.ToLower(text))
+	for _, queryWord := range strings.Fields(query) {
+		matched := false
+		for _, word := range words {
+			if fuzzyWordMatch(word, queryWord) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return 0
+		}
+	}
+	return matchFuzzy
+}
+
+// fuzzyWordMatch reports whether the query word is a misspelling or an abbreviation of word:
+// word, or its beginning, is at most a typo for every four letters of the query away from it
+// (e.g., "grcoeries" for "groceries"), or the query has at least three letters that appear in
+// word in the same order, starting with its first letter (e.g., "grcrs" for "groceries").
+func fuzzyWordMatch(word, query string) bool {
+	w, q := []rune(word), []rune(query)
+	if typos := len(q) / 4; typos > 0 {
+		if editDistance(w, q) <= typos || (len(w) > len(q) && editDistance(w[:len(q)], q) <= typos) {
+			return true
+		}
+	}
+	if len(q) < 3 || len(w) == 0 || w[0] != q[0] {
+		return false
+	}
+	next := 0
+	for _, r := range w {
+		if next < len(q) && r == q[next] {
+			next++
+		}
+	}
+	return next == len(q)
+}
+
+// editDistance returns the number of single-letter insertions, deletions, substitutions, and
+// swaps of neighboring letters that turn a into b (the optimal string alignment distance).
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// scoreSearchResult scores a todo for the lowercase query by its best weighted match in its task
+// text, its tags, or the task text of its direct subtasks, as ranked by rank (matchRank or
+// fuzzyMatchRank). The score is 0 if none of them matches.
+func scoreSearchResult(todo Todo, subtasks []Todo, query string, rank func(text, query string) int) SearchResult {
+	result := SearchResult{Todo: todo}
+	best := 0
+	consider := func(text string, weight int, matchedIn string, subtaskID int) {
+		if match := rank(text, query); match*weight > result.Score {
+			best, result.Score, result.MatchedIn, result.MatchedSubtask = match, match*weight, matchedIn, subtaskID
+		}
+	}
+	consider(todo.Task, taskMatchWeight, MatchedInTask, 0)
+	for _, tag := range todo.Tags {
+		consider(tag, tagMatchWeight, MatchedInTag, 0)
+	}
+	for _, subtask := range subtasks {
+		consider(subtask.Task, subtaskMatchWeight, MatchedInSubtask, subtask.ID)
+	}
+	result.Fuzzy = best == matchFuzzy
+	return result
+}
+
+// Search finds the todos whose task, tags, or subtasks contain the query (case-insensitive),
+// the most relevant first: exact matches rank above prefix matches, which rank above other
+// substring matches, and matches in the task text above matches in tags and subtasks. Todos that
+// are equally relevant keep their order in the list. An empty query finds all todos, in list order.
+func (tl *TodoList) Search(query string) []SearchResult {
+	return tl.search(query, matchRank)
+}
+
+// FuzzySearch finds todos like Search, and also those that only match the query despite typos or
+// abbreviated words (e.g., "grcoeries" or "grcrs" for "Buy groceries"), ranked below the others.
+func (tl *TodoList) FuzzySearch(query string) []SearchResult {
+	return tl.search(query, fuzzyMatchRank)
+}
+
+// search finds the todos that match the query as ranked by rank, the most relevant first.
+func (tl *TodoList) search(query string, rank func(text, query string) int) []SearchResult {
+	query = strings.ToLower(query)
+	subtasks := map[int][]Todo{}
+	for _, todo := range tl.Todos {
+		if todo.ParentID != 0 {
+			subtasks[todo.ParentID] = append(subtasks[todo.ParentID], todo)
+		}
+	}
+	results := []SearchResult{}
+	for _, todo := range tl.Todos {
+		if query == "" {
+			results = append(results, SearchResult{Todo: todo})
+			continue
+		}
+		if result := scoreSearchResult(todo, subtasks[todo.ID], query, rank); result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
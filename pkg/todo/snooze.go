@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., error messages)
+	"sort" // Package for ordering chronically snoozed todos
+	"time" // Package for working with due dates
+)
+
+// Snooze records one postponement of a todo's due date.
+type Snooze struct {
+	At   time.Time  `json:"at"`   // When the todo was snoozed.
+	From *time.Time `json:"from"` // The due date before snoozing, if any.
+	To   time.Time  `json:"to"`   // The due date after snoozing.
+}
+
+// SnoozeUntil postpones the todo with the given ID to the new due date and records the snooze
+// in the todo's history. Returns the updated todo, or an error if the todo is not found.
+func (tl *TodoList) SnoozeUntil(id int, dueDate time.Time, now time.Time) (Todo, error) {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Snoozes = append(tl.Todos[i].Snoozes, Snooze{At: now, From: tl.Todos[i].DueDate, To: dueDate})
+			tl.Todos[i].DueDate = &dueDate
+			return tl.Todos[i], nil
+		}
+	}
+	return Todo{}, fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SnoozeDate returns the due date that snoozing the todo by the given number of days results in:
+// that many days after its due date, or after the day of now if it has no due date or is already overdue.
+func (todo Todo) SnoozeDate(days int, now time.Time) time.Time {
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC) // Due dates are dates at midnight UTC.
+	if todo.DueDate != nil && todo.DueDate.After(from) {
+		from = *todo.DueDate
+	}
+	return from.AddDate(0, 0, days)
+}
+
+// ChronicallySnoozed returns the open todos that were snoozed at least threshold times,
+// the most snoozed first. Repeatedly postponed todos are often worth deleting or breaking down.
+func (tl *TodoList) ChronicallySnoozed(threshold int) []Todo {
+	snoozed := []Todo{}
+	for _, todo := range tl.Todos {
+		if todo.IsOpen() && len(todo.Snoozes) >= threshold {
+			snoozed = append(snoozed, todo)
+		}
+	}
+	sort.SliceStable(snoozed, func(i, j int) bool {
+		return len(snoozed[i].Snoozes) > len(snoozed[j].Snoozes)
+	})
+	return snoozed
+}
+
+// TodoStats summarizes the state of a todo list.
+type TodoStats struct {
+	Total              int    `json:"total"`               // Number of todos.
+	Open               int    `json:"open"`                // Number of todos that are neither completed, cancelled, nor expired.
+	Completed          int    `json:"completed"`           // Number of completed todos.
+	Expired            int    `json:"expired"`             // Number of expired todos.
+	Cancelled          int    `json:"cancelled"`           // Number of cancelled todos.
+	Overdue            int    `json:"overdue"`             // Number of open todos whose due date has passed.
+	Snoozes            int    `json:"snoozes"`             // Total number of times open todos were snoozed.
+	ChronicallySnoozed []Todo `json:"chronically_snoozed"` // Open todos snoozed at least the threshold passed to Stats.
+}
+
+// Stats summarizes the todo list as of now, reporting the open todos snoozed at least
+// chronicThreshold times as chronically snoozed.
+func (tl *TodoList) Stats(now time.Time, chronicThreshold int) TodoStats {
+	stats := TodoStats{Total: len(tl.Todos), ChronicallySnoozed: tl.ChronicallySnoozed(chronicThreshold)}
+	for _, todo := range tl.Todos {
+		switch {
+		case todo.Completed:
+			stats.Completed++
+		case todo.Status == StatusCancelled:
+			stats.Cancelled++
+		case todo.Expired:
+			stats.Expired++
+		default:
+			stats.Open++
+			stats.Snoozes += len(todo.Snoozes)
+			if todo.IsOverdue(now) {
+				stats.Overdue++
+			}
+		}
+	}
+	return stats
+}
@@ -0,0 +1,374 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"math"    // Package for math functions (e.g., infinity for missing due dates)
+	"sort"    // Package for sorting slices
+	"strconv" // Package for parsing number literals
+	"strings" // Package for string manipulation
+	"time"    // Package for date arithmetic on todo fields
+	"unicode" // Package for classifying characters while tokenizing
+)
+
+// SortExprPrefix marks a SortBy value as a computed sort expression, e.g., "expr: len(Tags)".
+const SortExprPrefix = "expr:"
+
+// exprKind identifies the type of a value produced by a sort expression.
+type exprKind int
+
+const (
+	exprNumber exprKind = iota
+	exprText
+	exprList
+)
+
+// exprValue is the result of evaluating a sort expression (or part of one) for a todo.
+type exprValue struct {
+	kind exprKind
+	num  float64
+	text string
+	list []string
+}
+
+// exprNode evaluates a parsed expression for a todo, relative to the given current time.
+type exprNode func(todo Todo, now time.Time) (exprValue, error)
+
+// SortExpression is a compiled sort expression that computes a sort key for each todo.
+//
+// The expression language supports numbers, the arithmetic operators + - * / with parentheses,
+// the functions len(x), lower(x), and abs(x), and these todo fields (case-insensitive):
+//
+//	ID         the todo's ID
+//	Task       the task description (text)
+//	Completed  1 if completed, 0 otherwise
+//	Priority   3 for high, 2 for medium, 1 for low, 0 if unset
+//	Tags       the list of tags (use len(Tags) to sort by tag count)
+//	DueDate    days from now until the due date (negative if past, +Inf if unset)
+//	CreatedAt  days from now until the creation time (always zero or negative)
+type SortExpression struct {
+	source string
+	root   exprNode
+}
+
+// CompileSortExpression parses a sort expression and checks that it yields a sortable
+// number or text value. The expression may include the "expr:" prefix.
+func CompileSortExpression(source string) (*SortExpression, error) {
+	source = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(source), SortExprPrefix))
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in sort expression %q", p.tokens[p.pos], source)
+	}
+
+	// Field types are fixed, so evaluating against an empty todo catches all type errors up front.
+	value, err := root(Todo{}, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid sort expression %q: %w", source, err)
+	}
+	if value.kind == exprList {
+		return nil, fmt.Errorf("invalid sort expression %q: cannot sort by a list, use len(...)", source)
+	}
+	return &SortExpression{source: source, root: root}, nil
+}
+
+// Sort orders the todos by the expression's value, in ascending order unless order is "desc".
+// Todos with equal values keep their relative order.
+func (e *SortExpression) Sort(todos []Todo, order string) {
+	e.sort(todos, order, defaultLogger)
+}
+
+// sort sorts like Sort, logging the todos the expression fails for to log.
+func (e *SortExpression) sort(todos []Todo, order string, log Logger) {
+	now := time.Now()
+	keys := make(map[int]exprValue, len(todos))
+	for _, todo := range todos {
+		value, err := e.root(todo, now)
+		if err != nil {
+			log.Error(err, fmt.Sprintf("Failed to evaluate sort expression %q for todo #%d", e.source, todo.ID))
+		}
+		keys[todo.ID] = value
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		a, b := keys[todos[i].ID], keys[todos[j].ID]
+		if order == "desc" {
+			a, b = b, a
+		}
+		if a.kind == exprText {
+			return a.text < b.text
+		}
+		return a.num < b.num
+	})
+}
+
+// tokenizeExpression splits a sort expression into identifiers, numbers, and operators.
+func tokenizeExpression(source string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/()", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected character %q in sort expression %q", r, source)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty sort expression")
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive-descent parser for sort expressions.
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+// peek returns the current token, or an empty string at the end of the input.
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// expect consumes the current token if it matches, and returns an error otherwise.
+func (p *exprParser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("expected %q in sort expression, got %q", token, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// parseSum parses additions and subtractions.
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.peek()
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticNode(op, left, right)
+	}
+	return left, nil
+}
+
+// parseProduct parses multiplications and divisions.
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		op := p.peek()
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = arithmeticNode(op, left, right)
+	}
+	return left, nil
+}
+
+// parseFactor parses numbers, fields, function calls, negation, and parenthesized expressions.
+func (p *exprParser) parseFactor() (exprNode, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of sort expression")
+	case token == "-":
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return arithmeticNode("-", constantNode(0), operand), nil
+	case token == "(":
+		p.pos++
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case unicode.IsDigit([]rune(token)[0]) || token[0] == '.':
+		p.pos++
+		num, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in sort expression", token)
+		}
+		return constantNode(num), nil
+	}
+
+	p.pos++
+	name := strings.ToLower(token)
+	if p.peek() == "(" {
+		p.pos++
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return functionNode(name, arg)
+	}
+	return fieldNode(name)
+}
+
+// constantNode returns a node that always evaluates to the given number.
+func constantNode(num float64) exprNode {
+	return func(Todo, time.Time) (exprValue, error) {
+		return exprValue{kind: exprNumber, num: num}, nil
+	}
+}
+
+// arithmeticNode returns a node applying a binary arithmetic operator to two numeric operands.
+func arithmeticNode(op string, left, right exprNode) exprNode {
+	return func(todo Todo, now time.Time) (exprValue, error) {
+		a, err := left(todo, now)
+		if err != nil {
+			return exprValue{}, err
+		}
+		b, err := right(todo, now)
+		if err != nil {
+			return exprValue{}, err
+		}
+		if a.kind != exprNumber || b.kind != exprNumber {
+			return exprValue{}, fmt.Errorf("operator %s needs numbers", op)
+		}
+		result := exprValue{kind: exprNumber}
+		switch op {
+		case "+":
+			result.num = a.num + b.num
+		case "-":
+			result.num = a.num - b.num
+		case "*":
+			result.num = a.num * b.num
+		case "/":
+			result.num = a.num / b.num
+		}
+		return result, nil
+	}
+}
+
+// functionNode returns a node calling one of the built-in functions len, lower, or abs.
+func functionNode(name string, arg exprNode) (exprNode, error) {
+	switch name {
+	case "len":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			value, err := arg(todo, now)
+			switch {
+			case err != nil:
+				return exprValue{}, err
+			case value.kind == exprText:
+				return exprValue{kind: exprNumber, num: float64(len([]rune(value.text)))}, nil
+			case value.kind == exprList:
+				return exprValue{kind: exprNumber, num: float64(len(value.list))}, nil
+			}
+			return exprValue{}, fmt.Errorf("len() needs text or a list")
+		}, nil
+	case "lower":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			value, err := arg(todo, now)
+			if err != nil {
+				return exprValue{}, err
+			}
+			if value.kind != exprText {
+				return exprValue{}, fmt.Errorf("lower() needs text")
+			}
+			return exprValue{kind: exprText, text: strings.ToLower(value.text)}, nil
+		}, nil
+	case "abs":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			value, err := arg(todo, now)
+			if err != nil {
+				return exprValue{}, err
+			}
+			if value.kind != exprNumber {
+				return exprValue{}, fmt.Errorf("abs() needs a number")
+			}
+			return exprValue{kind: exprNumber, num: math.Abs(value.num)}, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown function %q in sort expression", name)
+}
+
+// fieldNode returns a node reading one of the todo fields supported by sort expressions.
+func fieldNode(name string) (exprNode, error) {
+	switch name {
+	case "id":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprNumber, num: float64(todo.ID)}, nil
+		}, nil
+	case "task":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprText, text: todo.Task}, nil
+		}, nil
+	case "completed":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			if todo.Completed {
+				return exprValue{kind: exprNumber, num: 1}, nil
+			}
+			return exprValue{kind: exprNumber, num: 0}, nil
+		}, nil
+	case "priority":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprNumber, num: float64(todo.Priority.Rank())}, nil
+		}, nil
+	case "urgency":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprNumber, num: todo.Urgency(now)}, nil
+		}, nil
+	case "order":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprNumber, num: float64(todo.Order)}, nil
+		}, nil
+	case "tags":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprList, list: todo.Tags}, nil
+		}, nil
+	case "duedate":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			if todo.DueDate == nil {
+				return exprValue{kind: exprNumber, num: math.Inf(1)}, nil
+			}
+			return exprValue{kind: exprNumber, num: todo.DueDate.Sub(now).Hours() / 24}, nil
+		}, nil
+	case "createdat":
+		return func(todo Todo, now time.Time) (exprValue, error) {
+			return exprValue{kind: exprNumber, num: todo.CreatedAt.Sub(now).Hours() / 24}, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown field %q in sort expression", name)
+}
@@ -0,0 +1,80 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strings" // Package for string manipulation
+	"time"    // Package for recording completion times
+)
+
+// TodoStatus is the workflow status of a todo.
+type TodoStatus string
+
+// Constants for the statuses a todo can have.
+const (
+	StatusTodo       TodoStatus = "todo"
+	StatusInProgress TodoStatus = "in-progress"
+	StatusWaiting    TodoStatus = "waiting"
+	StatusBlocked    TodoStatus = "blocked"
+	StatusDone       TodoStatus = "done"
+	StatusCancelled  TodoStatus = "cancelled"
+)
+
+// todoStatuses lists all statuses, in workflow order.
+var todoStatuses = []TodoStatus{StatusTodo, StatusInProgress, StatusWaiting, StatusBlocked, StatusDone, StatusCancelled}
+
+// ParseStatus converts a case-insensitive status name to a TodoStatus.
+// "in progress" and "canceled" are accepted as well.
+func ParseStatus(name string) (TodoStatus, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+	if normalized == "canceled" {
+		normalized = string(StatusCancelled)
+	}
+	for _, status := range todoStatuses {
+		if normalized == string(status) {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q: use todo, in-progress, waiting, blocked, done, or cancelled", name)
+}
+
+// SetStatus changes the status of the todo with the given ID. The Completed field is kept in sync,
+// so that data files stay readable by older versions, and a todo that is done is no longer expired.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetStatus(id int, status TodoStatus) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			if status == StatusDone && !tl.Todos[i].Completed {
+				now := time.Now()
+				tl.Todos[i].CompletedAt = &now
+			} else if status != StatusDone {
+				tl.Todos[i].CompletedAt = nil
+			}
+			tl.Todos[i].Status = status
+			tl.Todos[i].Completed = status == StatusDone
+			if status == StatusDone {
+				tl.Todos[i].Expired = false
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// normalizeStatuses derives the status of todos saved before statuses existed from their Completed field,
+// and fixes statuses that no longer match it, e.g., after an older version completed or uncompleted a todo.
+func (tl *TodoList) normalizeStatuses() {
+	for i := range tl.Todos {
+		todo := &tl.Todos[i]
+		switch {
+		case todo.Completed:
+			todo.Status = StatusDone
+		case todo.Status == "" || todo.Status == StatusDone:
+			todo.Status = StatusTodo
+		}
+	}
+}
+
+// IsOpen reports whether the todo still needs work: it is neither completed, cancelled, nor expired.
+func (t Todo) IsOpen() bool {
+	return !t.Completed && !t.Expired && t.Status != StatusCancelled
+}
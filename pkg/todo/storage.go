@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"encoding/json" // Package for JSON encoding and decoding
+	"fmt"           // Package for formatted I/O (e.g., error messages)
+	"os"            // Package for reading and writing the data file
+	"time"          // Package for timing file access
+)
+
+// SaveToFile saves the current state of the TodoList to a JSON file.
+// It marshals the `TodoList` struct into a pretty-printed JSON format and writes it to the specified file.
+// Returns an error if marshaling or file writing fails.
+func (tl *TodoList) SaveToFile(filename string) error {
+	// Marshal the TodoList struct into JSON format with indentation.
+	start := time.Now()
+	data, err := json.MarshalIndent(tl, "", "  ")
+	if err != nil {
+		tl.log().Error(err, "Failed to marshal todo list to JSON")
+		return fmt.Errorf("failed to save todos: %w", err)
+	}
+	tl.log().Timing(fmt.Sprintf("Marshalling %d todos into %d bytes", len(tl.Todos), len(data)), start)
+
+	// Write the JSON data to a temporary file next to the specified file, then rename it over the file,
+	// so that an interrupted save never leaves a truncated list behind.
+	tempFile := filename + ".tmp"
+	start = time.Now()
+	err = os.WriteFile(tempFile, data, 0644)
+	if err == nil {
+		err = os.Rename(tempFile, filename)
+	}
+	if err != nil {
+		tl.log().Error(err, fmt.Sprintf("Failed to write todo list to file %s", filename))
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to save todos to file: %w", err)
+	}
+
+	tl.log().Timing(fmt.Sprintf("Writing %s", filename), start)
+	tl.log().Info(fmt.Sprintf("Todos saved to %s", filename))
+	return nil // Return nil on successful save.
+}
+
+// LoadFromFile loads a TodoList from a JSON file.
+// It reads the file, unmarshals the JSON data into a `TodoList` struct.
+// If the file does not exist, it returns a new empty `TodoList`.
+// Returns an error if file reading or JSON unmarshaling fails.
+func LoadFromFile(filename string) (*TodoList, error) {
+	// Read the content of the specified file.
+	start := time.Now()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		// If the file does not exist (e.g., on the first run), return a new empty TodoList without an error.
+		if os.IsNotExist(err) {
+			defaultLogger.Info(fmt.Sprintf("Todo file %s does not exist, starting with an empty list.", filename))
+			return NewTodoList(), nil
+		}
+		// For other file reading errors, return an error.
+		defaultLogger.Error(err, fmt.Sprintf("Failed to read todo list from file %s", filename))
+		return nil, fmt.Errorf("failed to load todos: %w", err)
+	}
+	defaultLogger.Timing(fmt.Sprintf("Reading %d bytes from %s", len(data), filename), start)
+
+	// Create a new TodoList to unmarshal the data into.
+	todoList := NewTodoList()
+	// Unmarshal the JSON data from the file into the todoList struct.
+	start = time.Now()
+	err = json.Unmarshal(data, todoList)
+	if err != nil {
+		defaultLogger.Error(err, fmt.Sprintf("Failed to parse todo list from file %s", filename))
+		return nil, fmt.Errorf("failed to parse todos: %w", err)
+	}
+	defaultLogger.Timing(fmt.Sprintf("Unmarshalling %d todos", len(todoList.Todos)), start)
+	todoList.normalizeStatuses()
+	todoList.normalizeOrder()
+
+	defaultLogger.Info(fmt.Sprintf("Todos loaded from %s", filename))
+	return todoList, nil // Return the loaded todo list and nil on success.
+}
@@ -0,0 +1,160 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strconv" // Package for parsing subtask references
+	"strings" // Package for string manipulation
+)
+
+// SetParent makes the todo with the given ID a subtask of the todo with parentID,
+// or a top-level todo if parentID is 0. Returns an error if either todo is not found,
+// or if the parent is the todo itself or one of its subtasks.
+func (tl *TodoList) SetParent(id int, parentID int) error {
+	if _, err := tl.Get(id); err != nil {
+		return err
+	}
+	for ancestor := parentID; ancestor != 0; {
+		if ancestor == id {
+			return fmt.Errorf("todo with ID %d cannot be a subtask of itself", id)
+		}
+		parent, err := tl.Get(ancestor)
+		if err != nil {
+			return err
+		}
+		ancestor = parent.ParentID
+	}
+
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].ParentID = parentID
+		}
+	}
+	return nil
+}
+
+// Subtasks returns the direct subtasks of the todo with the given ID, in list order.
+func (tl *TodoList) Subtasks(id int) []Todo {
+	subtasks := []Todo{}
+	for _, todo := range tl.Todos {
+		if todo.ParentID == id {
+			subtasks = append(subtasks, todo)
+		}
+	}
+	return subtasks
+}
+
+// Progress returns how many of the direct subtasks of the todo with the given ID are
+// completed, and how many subtasks it has in total. Cancelled subtasks are not counted.
+func (tl *TodoList) Progress(id int) (done int, total int) {
+	for _, subtask := range tl.Subtasks(id) {
+		if subtask.Status == StatusCancelled {
+			continue
+		}
+		total++
+		if subtask.Completed {
+			done++
+		}
+	}
+	return done, total
+}
+
+// Promote turns the subtask with the given ID into a top-level todo, for when its scope grows.
+// It inherits the tags and project of its former parent (keeping its own tags and project), and
+// records the parent's ID in PromotedFrom. Its own subtasks stay below it.
+// Returns the promoted todo, or an error if the todo is not found or is not a subtask.
+func (tl *TodoList) Promote(id int) (Todo, error) {
+	todo, err := tl.Get(id)
+	if err != nil {
+		return Todo{}, err
+	}
+	if todo.ParentID == 0 {
+		return Todo{}, fmt.Errorf("todo with ID %d is not a subtask", id)
+	}
+	parent, err := tl.Get(todo.ParentID)
+	if err != nil {
+		return Todo{}, err
+	}
+
+	for i := range tl.Todos {
+		if tl.Todos[i].ID != id {
+			continue
+		}
+		promoted := &tl.Todos[i]
+		for _, tag := range parent.Tags {
+			if !containsTag(promoted.Tags, tag) {
+				promoted.Tags = append(promoted.Tags, tag)
+			}
+		}
+		if promoted.Project == "" {
+			promoted.Project = parent.Project
+		}
+		promoted.ParentID = 0
+		promoted.PromotedFrom = parent.ID
+		return *promoted, nil
+	}
+	return Todo{}, fmt.Errorf("todo with ID %d not found", id)
+}
+
+// containsTag reports whether tags contains the tag, ignoring case.
+func containsTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveSubtaskRef resolves a reference to a subtask: either its ID (e.g., "7"),
+// or the ID of its parent and its 1-based position among the parent's subtasks (e.g., "3.2").
+func (tl *TodoList) ResolveSubtaskRef(ref string) (int, error) {
+	parentRef, position, hasPosition := strings.Cut(ref, ".")
+	id, err := strconv.Atoi(parentRef)
+	if err != nil {
+		return 0, fmt.Errorf("invalid todo reference %q: use <id> or <id>.<n>", ref)
+	}
+	if !hasPosition {
+		return id, nil
+	}
+	n, err := strconv.Atoi(position)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid subtask position %q in %q", position, ref)
+	}
+	if _, err := tl.Get(id); err != nil {
+		return 0, err
+	}
+	subtasks := tl.Subtasks(id)
+	if n > len(subtasks) {
+		return 0, fmt.Errorf("todo with ID %d has %d subtasks, not %d", id, len(subtasks), n)
+	}
+	return subtasks[n-1].ID, nil
+}
+
+// DeleteWithSubtasks removes the todo with the given ID along with all of its subtasks,
+// including nested ones. Returns the deleted todo and its deleted subtasks, or an error
+// if the todo with the given ID is not found.
+func (tl *TodoList) DeleteWithSubtasks(id int) (Todo, []Todo, error) {
+	deletedTodo, err := tl.Delete(id)
+	if err != nil {
+		return Todo{}, nil, err
+	}
+
+	deletedSubtasks := []Todo{}
+	deletedIDs := map[int]bool{id: true}
+	// Each pass removes the subtasks whose parent was removed in an earlier pass.
+	for removed := true; removed; {
+		removed = false
+		remaining := tl.Todos[:0]
+		for _, todo := range tl.Todos {
+			if deletedIDs[todo.ParentID] {
+				deletedSubtasks = append(deletedSubtasks, todo)
+				deletedIDs[todo.ID] = true
+				removed = true
+				continue
+			}
+			remaining = append(remaining, todo)
+		}
+		tl.Todos = remaining
+	}
+	return deletedTodo, deletedSubtasks, nil
+}
@@ -0,0 +1,96 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., the suggestion message)
+	"strings" // Package for splitting tasks into keywords
+	"time"    // Package for weekdays and dates
+	"unicode" // Package for trimming punctuation from keywords
+)
+
+const (
+	// minSuggestionMatches is the number of similar completed todos needed before a due date is suggested.
+	minSuggestionMatches = 3
+	// minSuggestionShare is the percentage of similar todos that must have been completed on the same weekday.
+	minSuggestionShare = 60
+)
+
+// DueDateSuggestion is a due date suggested from when similar todos were completed.
+type DueDateSuggestion struct {
+	DueDate time.Time    `json:"due_date"` // The suggested due date: the next such weekday, today included.
+	Weekday time.Weekday `json:"weekday"`  // The weekday similar todos are usually completed on.
+	Basis   string       `json:"basis"`    // The keyword or tag most similar todos share with the new one.
+	Count   int          `json:"count"`    // Number of similar todos completed on the weekday.
+	Total   int          `json:"total"`    // Number of similar completed todos.
+}
+
+// SuggestDueDate looks at the completed todos that share a keyword of the task or one of the tags,
+// and suggests a due date if most of them were completed on the same weekday
+// (e.g., "you usually finish reports on Fridays"). The analysis is local to the list.
+// Returns false if there are too few similar todos or no weekday stands out.
+func (tl *TodoList) SuggestDueDate(task string, tags []string, now time.Time) (DueDateSuggestion, bool) {
+	keywords := taskKeywords(task)
+	weekdays := map[time.Weekday]int{}
+	bases := map[string]int{}
+	total := 0
+	for _, todo := range tl.Todos {
+		if !todo.Completed || todo.CompletedAt == nil {
+			continue
+		}
+		shared := []string{}
+		for _, keyword := range taskKeywords(todo.Task) {
+			if containsTag(keywords, keyword) {
+				shared = append(shared, fmt.Sprintf("%q", keyword))
+			}
+		}
+		for _, tag := range todo.Tags {
+			if containsTag(tags, tag) {
+				shared = append(shared, "#"+strings.ToLower(tag))
+			}
+		}
+		if len(shared) == 0 {
+			continue
+		}
+		total++
+		weekdays[todo.CompletedAt.Weekday()]++
+		for _, basis := range shared {
+			bases[basis]++
+		}
+	}
+	if total < minSuggestionMatches {
+		return DueDateSuggestion{}, false
+	}
+
+	suggestion := DueDateSuggestion{Total: total}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if weekdays[day] > suggestion.Count {
+			suggestion.Weekday, suggestion.Count = day, weekdays[day]
+		}
+	}
+	if suggestion.Count*100 < total*minSuggestionShare {
+		return DueDateSuggestion{}, false
+	}
+	for basis, count := range bases {
+		if count > bases[suggestion.Basis] || (count == bases[suggestion.Basis] && basis < suggestion.Basis) {
+			suggestion.Basis = basis
+		}
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC) // Due dates are dates at midnight UTC.
+	suggestion.DueDate = today.AddDate(0, 0, (int(suggestion.Weekday)-int(today.Weekday())+7)%7)
+	return suggestion, true
+}
+
+// taskKeywords returns the distinctive words of a task: lowercase, without punctuation
+// or a plural "s", and at least four letters long (e.g., "Send the reports!" -> ["send", "report"]).
+func taskKeywords(task string) []string {
+	keywords := []string{}
+	for _, word := range strings.Fields(strings.ToLower(task)) {
+		word = strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if len(word) > 4 {
+			word = strings.TrimSuffix(word, "s")
+		}
+		if len(word) >= 4 {
+			keywords = append(keywords, word)
+		}
+	}
+	return keywords
+}
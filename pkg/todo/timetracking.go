@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"sort"    // Package for ordering the time report
+	"strings" // Package for string manipulation
+	"time"    // Package for measuring tracked time
+)
+
+// Duration is a custom type that allows time.Duration to be marshaled/unmarshaled
+// from JSON as a human-readable string (e.g., "30s", "1m").
+type Duration time.Duration
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// NoGroupName is the report group of todos without a tag or project.
+const NoGroupName = "(none)"
+
+// SetEstimate sets (or, with zero, clears) the estimated time needed for the todo with the given ID.
+// Returns an error if the todo is not found.
+func (tl *TodoList) SetEstimate(id int, estimate time.Duration) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Estimate = Duration(estimate)
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// StartTracking starts measuring the time spent on the todo with the given ID.
+// The start time is saved with the todo, so tracking continues across sessions.
+// Returns an error if the todo is not found or its time is already being tracked.
+func (tl *TodoList) StartTracking(id int, now time.Time) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			if tl.Todos[i].TrackingSince != nil {
+				return fmt.Errorf("time of todo with ID %d is already being tracked since %s", id, tl.Todos[i].TrackingSince.Format("15:04"))
+			}
+			tl.Todos[i].TrackingSince = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// StopTracking stops measuring the time spent on the todo with the given ID and adds the time
+// since tracking started to its time spent. Returns the tracked time, or an error if the todo
+// is not found or its time is not being tracked.
+func (tl *TodoList) StopTracking(id int, now time.Time) (time.Duration, error) {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			if tl.Todos[i].TrackingSince == nil {
+				return 0, fmt.Errorf("time of todo with ID %d is not being tracked", id)
+			}
+			tracked := now.Sub(*tl.Todos[i].TrackingSince)
+			tl.Todos[i].TimeSpent += Duration(tracked)
+			tl.Todos[i].TrackingSince = nil
+			return tracked, nil
+		}
+	}
+	return 0, fmt.Errorf("todo with ID %d not found", id)
+}
+
+// TimeSpentAt returns the time spent on the todo as of now, including the time tracked so far if tracking is running.
+func (todo Todo) TimeSpentAt(now time.Time) time.Duration {
+	spent := time.Duration(todo.TimeSpent)
+	if todo.TrackingSince != nil {
+		spent += now.Sub(*todo.TrackingSince)
+	}
+	return spent
+}
+
+// TimeReportRow compares the estimated and actual time of the todos in one tag or project.
+type TimeReportRow struct {
+	Name     string        `json:"name"`     // The tag or project, or "(none)".
+	Todos    int           `json:"todos"`    // Number of todos with an estimate or tracked time.
+	Estimate time.Duration `json:"estimate"` // Sum of the estimates, in nanoseconds.
+	Spent    time.Duration `json:"spent"`    // Sum of the time spent, in nanoseconds.
+}
+
+// TimeReport sums the estimated and actual time of the todos with an estimate or tracked time,
+// per project or per tag (by is "project" or "tag"). A todo with several tags counts toward each of them.
+// Rows are sorted by name, with todos without a project or tag last.
+func (tl *TodoList) TimeReport(by string, now time.Time) ([]TimeReportRow, error) {
+	if by != "project" && by != "tag" {
+		return nil, fmt.Errorf("invalid report grouping %q: use project or tag", by)
+	}
+	rows := map[string]*TimeReportRow{}
+	for _, todo := range tl.Todos {
+		spent := todo.TimeSpentAt(now)
+		if todo.Estimate == 0 && spent == 0 {
+			continue
+		}
+		names := []string{todo.Project}
+		if by == "tag" {
+			names = todo.Tags
+		}
+		if len(names) == 0 || names[0] == "" {
+			names = []string{NoGroupName}
+		}
+		for _, name := range names {
+			row, ok := rows[name]
+			if !ok {
+				row = &TimeReportRow{Name: name}
+				rows[name] = row
+			}
+			row.Todos++
+			row.Estimate += time.Duration(todo.Estimate)
+			row.Spent += spent
+		}
+	}
+
+	report := []TimeReportRow{}
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Name == NoGroupName || report[j].Name == NoGroupName {
+			return report[j].Name == NoGroupName && report[i].Name != NoGroupName
+		}
+		return strings.ToLower(report[i].Name) < strings.ToLower(report[j].Name)
+	})
+	return report, nil
+}
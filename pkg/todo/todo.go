@@ -0,0 +1,643 @@
+package todo
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"sort"    // Package for sorting slices
+	"strings" // Package for string manipulation (e.g., Contains, ToLower)
+	"time"    // Package for time-related functions (e.g., todo creation timestamp)
+)
+
+// PriorityLevel defines the type for todo priority, ensuring valid values.
+type PriorityLevel string
+
+// Constants for predefined priority levels.
+const (
+	PriorityHigh   PriorityLevel = "high"
+	PriorityMedium PriorityLevel = "medium"
+	PriorityLow    PriorityLevel = "low"
+)
+
+// priorityMap provides a case-insensitive lookup for canonical PriorityLevel values.
+var priorityMap = map[string]PriorityLevel{
+	"high":   PriorityHigh,
+	"medium": PriorityMedium,
+	"low":    PriorityLow,
+}
+
+// Todo represents a single task item in the todo list.
+// It includes fields for a unique identifier, the task description, its completion status,
+// and the timestamp of its creation.
+type Todo struct {
+	ID            int           `json:"id"`             // Unique identifier for the todo item.
+	Task          string        `json:"task"`           // The description of the task.
+	Completed     bool          `json:"completed"`      // A boolean indicating if the task is completed (true) or not (false). Kept in sync with Status.
+	Status        TodoStatus    `json:"status"`         // Workflow status (e.g., "in-progress" or "done").
+	CompletedAt   *time.Time    `json:"completed_at"`   // When the todo was completed, if it is.
+	CreatedAt     time.Time     `json:"created_at"`     // The timestamp when the todo item was created.
+	Priority      PriorityLevel `json:"priority"`       // Priority of the todo (e.g., "high", "medium", "low").
+	DueDate       *time.Time    `json:"due_date"`       // Optional due date for the todo item.
+	Tags          []string      `json:"tags"`           // Optional tags/categories for the todo item.
+	ExpiresAt     *time.Time    `json:"expires_at"`     // Optional date after which an open todo expires.
+	Expired       bool          `json:"expired"`        // Whether the todo expired before it was completed.
+	ParentID      int           `json:"parent_id"`      // ID of the parent todo if this is a subtask, or 0 for a top-level todo.
+	Recurrence    string        `json:"recurrence"`     // Optional recurrence rule (e.g., "every monday"); completing the todo adds the next occurrence.
+	Snoozes       []Snooze      `json:"snoozes"`        // History of the times the todo's due date was postponed.
+	DependsOn     []int         `json:"depends_on"`     // IDs of the todos that must be completed before this one.
+	Project       string        `json:"project"`        // Optional project the todo belongs to, distinct from its tags.
+	StartDate     *time.Time    `json:"start_date"`     // Optional "not before" date; the todo is hidden from the default list until then.
+	Estimate      Duration      `json:"estimate"`       // Optional estimate of the time the todo takes.
+	TimeSpent     Duration      `json:"time_spent"`     // Time tracked on the todo so far, excluding a running tracking session.
+	TrackingSince *time.Time    `json:"tracking_since"` // Start of the running tracking session, if any.
+	Links         []string      `json:"links"`          // URLs attached to the todo.
+	PromotedFrom  int           `json:"promoted_from"`  // ID of the todo this one was a subtask of before it was promoted, or 0.
+	Order         int           `json:"order"`          // Position in the manual order set with TodoList.Move (see SortBy "order").
+	RecurFrom     string        `json:"recur_from"`     // What the next occurrence is scheduled from ("schedule" or "completion"); empty uses the default (see SetDefaultRecurrenceRules).
+	RecurOverdue  string        `json:"recur_overdue"`  // Whether missed occurrences "pile-up" or "collapse"; empty uses the default.
+}
+
+// TodoList manages a collection of Todo items.
+// It holds a slice of `Todo` structs and keeps track of the next available ID
+// to ensure uniqueness for new todo items.
+type TodoList struct {
+	Todos  []Todo        `json:"todos"`   // A slice (dynamic array) of Todo items.
+	NextID int           `json:"next_id"` // The next ID to be assigned to a new todo item. This ensures unique IDs.
+	Trash  []TrashedTodo `json:"trash"`   // Deleted todos, kept until they are restored or purged.
+	logger Logger        // Logs what the list does; the default logger if nil.
+}
+
+// NewTodoList creates and returns a pointer to a new, empty TodoList.
+// The list is initialized with an empty slice of todos and a starting NextID of 1.
+func NewTodoList() *TodoList {
+	return &TodoList{
+		Todos:  []Todo{}, // Initialize with an empty slice.
+		NextID: 1,        // Start IDs from 1.
+	}
+}
+
+// SetLogger makes the list log to l instead of the default logger (see SetDefaultLogger).
+func (tl *TodoList) SetLogger(l Logger) {
+	tl.logger = l
+}
+
+// log returns the logger of the list.
+func (tl *TodoList) log() Logger {
+	if tl.logger != nil {
+		return tl.logger
+	}
+	return defaultLogger
+}
+
+// Clone returns a deep copy of the TodoList, so that changes to the copy
+// (including to due dates and tags) do not affect the original.
+func (tl *TodoList) Clone() *TodoList {
+	clone := &TodoList{
+		Todos:  make([]Todo, len(tl.Todos)),
+		NextID: tl.NextID,
+		logger: tl.logger,
+	}
+	for i, todo := range tl.Todos {
+		clone.Todos[i] = cloneTodo(todo)
+	}
+	if tl.Trash != nil {
+		clone.Trash = make([]TrashedTodo, len(tl.Trash))
+		for i, trashed := range tl.Trash {
+			trashed.Todo = cloneTodo(trashed.Todo)
+			subtasks := make([]Todo, len(trashed.Subtasks))
+			for j, subtask := range trashed.Subtasks {
+				subtasks[j] = cloneTodo(subtask)
+			}
+			trashed.Subtasks = subtasks
+			clone.Trash[i] = trashed
+		}
+	}
+	return clone
+}
+
+// cloneTodo returns a deep copy of the todo, with its own copies of its dates and slices.
+func cloneTodo(todo Todo) Todo {
+	if todo.DueDate != nil {
+		dueDate := *todo.DueDate
+		todo.DueDate = &dueDate
+	}
+	if todo.Tags != nil {
+		todo.Tags = append([]string{}, todo.Tags...)
+	}
+	if todo.ExpiresAt != nil {
+		expiresAt := *todo.ExpiresAt
+		todo.ExpiresAt = &expiresAt
+	}
+	if todo.StartDate != nil {
+		startDate := *todo.StartDate
+		todo.StartDate = &startDate
+	}
+	if todo.CompletedAt != nil {
+		completedAt := *todo.CompletedAt
+		todo.CompletedAt = &completedAt
+	}
+	if todo.TrackingSince != nil {
+		trackingSince := *todo.TrackingSince
+		todo.TrackingSince = &trackingSince
+	}
+	if todo.Links != nil {
+		todo.Links = append([]string{}, todo.Links...)
+	}
+	if todo.DependsOn != nil {
+		todo.DependsOn = append([]int{}, todo.DependsOn...)
+	}
+	if todo.Snoozes != nil {
+		todo.Snoozes = append([]Snooze{}, todo.Snoozes...) // The snoozes' dates are never modified in place.
+	}
+	return todo
+}
+
+// Add a new todo item to the TodoList.
+// It takes a task description as input, creates a new Todo struct with a unique ID,
+// sets its status to incomplete, records the creation time, and appends it to the list.
+// Returns the newly created Todo.
+func (tl *TodoList) Add(task string, priority PriorityLevel, dueDate *time.Time, tags []string) Todo {
+	// Normalize the priority input to a canonical form.
+	canonicalPriority := priority.Canonical()
+
+	// Validate canonical priority level. If invalid, default to medium and log a warning.
+	if !canonicalPriority.IsValid() {
+		tl.log().Warn(fmt.Sprintf("Invalid priority level '%s' for task '%s'. Defaulting to Medium.", priority, task))
+		canonicalPriority = PriorityMedium
+	}
+
+	// Create a new Todo instance.
+	todo := Todo{
+		ID:        tl.NextID, // Assign the next available ID.
+		Task:      task,      // Set the provided task description.
+		Completed: false,     // New tasks are incomplete by default.
+		Status:    StatusTodo,
+		CreatedAt: time.Now(), // Record the current time.
+		Priority:  canonicalPriority,
+		DueDate:   dueDate,
+		Tags:      tags,
+		Order:     tl.nextOrder(), // New todos go to the bottom of the manual order.
+	}
+	// Append the new todo to the existing slice of todos.
+	tl.Todos = append(tl.Todos, todo)
+	tl.NextID++ // Increment NextID for the next new todo.
+	return todo
+}
+
+// Duplicate adds a copy of the todo with the given ID as a new, open todo with a fresh ID and
+// creation time, for repeating similar work. The copy keeps the task, priority, due date, tags,
+// project, estimate, links, and parent, but not the completion state, history, or dependencies.
+// Returns the copy, or an error if the todo with the given ID is not found.
+func (tl *TodoList) Duplicate(id int) (Todo, error) {
+	original, err := tl.Get(id)
+	if err != nil {
+		return Todo{}, err
+	}
+	original = cloneTodo(original) // Don't share the due date or slices with the original.
+	tl.Add(original.Task, original.Priority, original.DueDate, original.Tags)
+	copied := &tl.Todos[len(tl.Todos)-1]
+	copied.Project = original.Project
+	copied.Estimate = original.Estimate
+	copied.Links = original.Links
+	copied.ParentID = original.ParentID // A copy of a subtask is a subtask of the same parent.
+	return *copied, nil
+}
+
+// IsValid reports whether the priority level is one of the predefined levels (in canonical form).
+func (p PriorityLevel) IsValid() bool {
+	switch p {
+	case PriorityHigh, PriorityMedium, PriorityLow:
+		return true
+	}
+	return false
+}
+
+// Rank returns a numeric rank for the priority level, where higher means more important.
+// Unknown or unset priorities rank lowest.
+func (p PriorityLevel) Rank() int {
+	switch p.Canonical() {
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 2
+	case PriorityLow:
+		return 1
+	}
+	return 0
+}
+
+// Canonical converts a case-insensitive priority (e.g., "High") to its canonical PriorityLevel.
+// Returns an empty string if it does not match any known priority.
+func (p PriorityLevel) Canonical() PriorityLevel {
+	if canonical, ok := priorityMap[strings.ToLower(string(p))]; ok {
+		return canonical
+	}
+	return ""
+}
+
+// Complete marks a todo item as completed by its ID.
+// It iterates through the list to find the matching todo and updates its `Completed` status.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) Complete(id int) error {
+	// Iterate over the slice of todos using index `i`.
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			// If the ID matches, mark the todo as completed. A completed todo is no longer expired.
+			now := time.Now()
+			tl.Todos[i].Completed = true
+			tl.Todos[i].Status = StatusDone
+			tl.Todos[i].CompletedAt = &now
+			tl.Todos[i].Expired = false
+			return nil // Return nil on success.
+		}
+	}
+	// If no todo with the given ID is found after iterating, return an error.
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// Uncomplete marks a todo item as incomplete by its ID.
+// It iterates through the list to find the matching todo and sets its status back to todo.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) Uncomplete(id int) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			// If the ID matches, mark the todo as incomplete. This also reopens a cancelled todo.
+			tl.Todos[i].Completed = false
+			tl.Todos[i].Status = StatusTodo
+			tl.Todos[i].CompletedAt = nil
+			return nil // Return nil on success.
+		}
+	}
+	// If no todo with the given ID is found after iterating, return an error.
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// Get returns a copy of the todo item with the given ID.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) Get(id int) (Todo, error) {
+	for _, todo := range tl.Todos {
+		if todo.ID == id {
+			return todo, nil
+		}
+	}
+	return Todo{}, fmt.Errorf("todo with ID %d not found", id)
+}
+
+// Delete removes a todo item from the TodoList by its ID.
+// It iterates through the list, finds the matching todo, and removes it by creating a new slice
+// that excludes the deleted item. Returns the deleted Todo and an error if not found.
+func (tl *TodoList) Delete(id int) (Todo, error) {
+	// Iterate over the slice of todos with both index `i` and `todo` value.
+	for i, todo := range tl.Todos {
+		if todo.ID == id {
+			// If the ID matches, remove the todo from the slice.
+			// This is done by appending the slice before the item to the slice after the item.
+			tl.Todos = append(tl.Todos[:i], tl.Todos[i+1:]...)
+			return todo, nil // Return the deleted todo and nil on success.
+		}
+	}
+	// If no todo with the given ID is found, return an error.
+	return Todo{}, fmt.Errorf("todo with ID %d not found", id)
+}
+
+// ListOptions defines parameters for filtering and sorting todos.
+type ListOptions struct {
+	FilterStatus    string        // "all", "completed", "incomplete", "expired", "overdue", or a TodoStatus (e.g., "in-progress")
+	FilterPriority  PriorityLevel // Specific priority (e.g., "high")
+	FilterTags      []string      // Tags to filter by
+	SortBy          string        // "id", "task", "created_at", "due_date", "priority", "order", "urgency", or "expr: <expression>"
+	SortOrder       string        // "asc" (ascending) or "desc" (descending)
+	Ready           bool          // Only incomplete todos that are not blocked by open dependencies
+	FilterProject   string        // Project to filter by (case-insensitive), or "none" for todos without a project
+	GroupBy         string        // "" (no grouping) or "project"
+	IncludeDeferred bool          // Also show open todos whose start date has not arrived yet
+	Verbose         bool          // Also show the urgency score of open todos
+	Query           string        // Filter query, e.g., "priority:high AND tag:work AND NOT status:done" (see Query)
+}
+
+// Filter returns the todo items in the TodoList that match the given options,
+// sorted according to the options' SortBy and SortOrder fields.
+func (tl *TodoList) Filter(options ListOptions) []Todo {
+	filteredTodos := []Todo{}
+	now := time.Now()
+	start := now
+	var query *Query
+	if options.Query != "" {
+		var err error
+		if query, err = CompileQuery(options.Query, now); err != nil {
+			// Leave the todos unfiltered by the query if it is invalid.
+			tl.log().Error(err, "Failed to compile filter query")
+		}
+	}
+	for _, todo := range tl.Todos {
+		match := true
+
+		// Filter by status. Expired todos are only shown when filtering for them.
+		if !matchesStatus(todo, options.FilterStatus, now) {
+			match = false
+		}
+		showExpired := options.FilterStatus == "expired" || (query != nil && query.expired)
+		if (todo.Expired && !showExpired) || (!todo.Expired && options.FilterStatus == "expired") {
+			match = false
+		}
+		if query != nil && !query.Matches(todo) {
+			match = false
+		}
+		// Deferred todos are hidden until their start date arrives.
+		if !options.IncludeDeferred && todo.IsDeferred(now) {
+			match = false
+		}
+		// Ready todos can be started now: they are open, not deferred, waiting, or blocked, and have no open dependencies.
+		if options.Ready && (!todo.IsOpen() || todo.IsDeferred(now) || todo.Status == StatusWaiting || todo.Status == StatusBlocked || tl.IsBlocked(todo)) {
+			match = false
+		}
+
+		// Filter by project
+		if options.FilterProject == "none" && todo.Project != "" {
+			match = false
+		} else if options.FilterProject != "" && options.FilterProject != "none" && !strings.EqualFold(todo.Project, options.FilterProject) {
+			match = false
+		}
+
+		// Filter by priority
+		// Normalize the filter priority for case-insensitive comparison
+		canonicalFilterPriority := options.FilterPriority.Canonical()
+		if canonicalFilterPriority != "" && todo.Priority != canonicalFilterPriority {
+			match = false
+		}
+
+		// Filter by tags
+		if len(options.FilterTags) > 0 {
+			hasTag := false
+			for _, filterTag := range options.FilterTags {
+				for _, todoTag := range todo.Tags {
+					if strings.ToLower(filterTag) == strings.ToLower(todoTag) {
+						hasTag = true
+						break
+					}
+				}
+				if hasTag {
+					break
+				}
+			}
+			if !hasTag {
+				match = false
+			}
+		}
+
+		if match {
+			filteredTodos = append(filteredTodos, todo)
+		}
+	}
+	tl.log().Timing(fmt.Sprintf("Filtering %d todos down to %d", len(tl.Todos), len(filteredTodos)), start)
+	if options.SortBy != "" {
+		defer tl.log().Timing(fmt.Sprintf("Sorting %d todos by %s", len(filteredTodos), options.SortBy), time.Now())
+	}
+
+	// Sort todos by a computed expression (e.g., "expr: len(Tags)") or by a field if sortBy is specified.
+	if strings.HasPrefix(options.SortBy, SortExprPrefix) {
+		expression, err := CompileSortExpression(options.SortBy)
+		if err != nil {
+			// Leave the todos in their original order if the expression is invalid.
+			tl.log().Error(err, "Failed to compile sort expression")
+		} else {
+			expression.sort(filteredTodos, options.SortOrder, tl.log())
+		}
+	} else if options.SortBy != "" {
+		sort.Slice(filteredTodos, func(i, j int) bool {
+			a, b := filteredTodos[i], filteredTodos[j]
+			var less bool
+
+			switch options.SortBy {
+			case "id":
+				less = a.ID < b.ID
+			case "task":
+				less = strings.ToLower(a.Task) < strings.ToLower(b.Task)
+			case "created_at":
+				less = a.CreatedAt.Before(b.CreatedAt)
+			case "due_date":
+				// Handle nil DueDate for sorting
+				if options.SortOrder == "desc" {
+					// Descending order: later dates first, nil dates last
+					if a.DueDate == nil && b.DueDate == nil {
+						return false // Treat as equal
+					} else if a.DueDate == nil {
+						return false // Nil due dates come after non-nil, so 'a' is not 'less' than 'b'
+					} else if b.DueDate == nil {
+						return true // Non-nil due dates come before nil, so 'a' is 'less' than 'b'
+					} else {
+						return a.DueDate.After(*b.DueDate) // For desc, 'a' comes before 'b' if 'a' is after 'b'
+					}
+				} else { // Ascending order
+					// Ascending order: earlier dates first, nil dates last
+					if a.DueDate == nil && b.DueDate == nil {
+						return false // Treat as equal
+					} else if a.DueDate == nil {
+						return false // Nil due dates come after non-nil, so 'a' is not 'less' than 'b'
+					} else if b.DueDate == nil {
+						return true // Non-nil due dates come before nil, so 'a' is 'less' than 'b'
+					} else {
+						return a.DueDate.Before(*b.DueDate) // For asc, 'a' comes before 'b' if 'a' is before 'b'
+					}
+				}
+			case "order":
+				less = a.Order < b.Order
+			case "urgency":
+				less = a.Urgency(now) < b.Urgency(now)
+			case "priority":
+				// Simple alphabetical sort for priority for now; can be enhanced with custom order.
+				less = strings.ToLower(string(a.Priority)) < strings.ToLower(string(b.Priority))
+			default:
+				// Default sort by ID if sortBy is unknown
+				less = a.ID < b.ID
+			}
+
+			if options.SortOrder == "desc" {
+				return !less
+			}
+			return less
+		})
+	}
+
+	return filteredTodos
+}
+
+// SearchTasks finds todo items whose task description, tags, or subtasks contain the given query string.
+// The search is case-insensitive, and the todos are ranked by relevance, as by Search.
+func (tl *TodoList) SearchTasks(query string) *TodoList {
+	matchedTodos := NewTodoList()
+	for _, result := range tl.Search(query) {
+		matchedTodos.Todos = append(matchedTodos.Todos, result.Todo)
+	}
+	return matchedTodos
+}
+
+// ClearCompleted removes all completed todo items from the list.
+// Returns the removed todos, which is empty if no todos were completed.
+func (tl *TodoList) ClearCompleted() []Todo {
+	activeTodos := []Todo{}
+	clearedTodos := []Todo{}
+	for _, todo := range tl.Todos {
+		if todo.Completed {
+			clearedTodos = append(clearedTodos, todo)
+		} else {
+			activeTodos = append(activeTodos, todo)
+		}
+	}
+	// Only replace the slice if any todos were actually removed.
+	if len(clearedTodos) > 0 {
+		tl.Todos = activeTodos
+	}
+	return clearedTodos
+}
+
+// Positions returns the index of each todo in the list by ID.
+func (tl *TodoList) Positions() map[int]int {
+	positions := make(map[int]int, len(tl.Todos))
+	for i, todo := range tl.Todos {
+		positions[todo.ID] = i
+	}
+	return positions
+}
+
+// InsertAt inserts todos back into the list at the given positions, which are their indexes
+// in the list they were removed from (see Positions), so that they regain their places and keep
+// their IDs, e.g., to undo deleting them. NextID is advanced past them if needed, so that their
+// IDs are not handed out again.
+func (tl *TodoList) InsertAt(todos []Todo, positions []int) {
+	order := make([]int, len(todos))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return positions[order[a]] < positions[order[b]] })
+	for _, i := range order {
+		pos := min(positions[i], len(tl.Todos))
+		tl.Todos = append(tl.Todos[:pos], append([]Todo{todos[i]}, tl.Todos[pos:]...)...)
+		if todos[i].ID >= tl.NextID {
+			tl.NextID = todos[i].ID + 1
+		}
+	}
+}
+
+// EditTask updates the task description of an existing todo item.
+// It takes the ID of the todo to edit and the new task description.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) EditTask(id int, newTask string) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			// Update the task description.
+			tl.Todos[i].Task = newTask
+			return nil // Return nil on success.
+		}
+	}
+	// If no todo with the given ID is found, return an error.
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetTags replaces the tags of an existing todo item.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetTags(id int, tags []string) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Tags = tags
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetExpiry sets (or, with a nil date, clears) the date after which an open todo expires.
+// Changing the expiry date of an expired todo makes it active again until the new date passes.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetExpiry(id int, expiresAt *time.Time) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].ExpiresAt = expiresAt
+			tl.Todos[i].Expired = false
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetPriority changes the priority of a todo. Returns an error if the priority is not
+// high, medium, or low, or if the todo with the given ID is not found.
+func (tl *TodoList) SetPriority(id int, priority PriorityLevel) error {
+	canonical := priority.Canonical()
+	if !canonical.IsValid() {
+		return fmt.Errorf("invalid priority %q", priority)
+	}
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].Priority = canonical
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetDueDate sets (or, with a nil date, clears) the due date of a todo, without recording a snooze.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetDueDate(id int, dueDate *time.Time) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].DueDate = dueDate
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// SetStartDate sets (or, with a nil date, clears) the date before which the todo is hidden from the default list.
+// Returns an error if the todo with the given ID is not found.
+func (tl *TodoList) SetStartDate(id int, startDate *time.Time) error {
+	for i := range tl.Todos {
+		if tl.Todos[i].ID == id {
+			tl.Todos[i].StartDate = startDate
+			return nil
+		}
+	}
+	return fmt.Errorf("todo with ID %d not found", id)
+}
+
+// IsOverdue reports whether the todo is open and its due date is before the day of now.
+func (todo Todo) IsOverdue(now time.Time) bool {
+	return todo.IsOpen() && todo.DueDate != nil && todo.DueDate.Format("2006-01-02") < now.Format("2006-01-02")
+}
+
+// DaysOverdue returns how many days have passed since the due date of an overdue todo.
+func (todo Todo) DaysOverdue(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC) // Due dates are dates at midnight UTC.
+	return int(today.Sub(todo.DueDate.Truncate(24*time.Hour)).Hours() / 24)
+}
+
+// Overdue returns the overdue todos, the earliest due date first.
+func (tl *TodoList) Overdue(now time.Time) []Todo {
+	overdue := []Todo{}
+	for _, todo := range tl.Todos {
+		if todo.IsOverdue(now) {
+			overdue = append(overdue, todo)
+		}
+	}
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].DueDate.Before(*overdue[j].DueDate) })
+	return overdue
+}
+
+// IsDeferred reports whether the todo is open and its start date is after the day of now.
+func (todo Todo) IsDeferred(now time.Time) bool {
+	return todo.IsOpen() && todo.StartDate != nil && todo.StartDate.Format("2006-01-02") > now.Format("2006-01-02")
+}
+
+// ExpireOverdue marks open (neither completed nor cancelled) todos as expired once their expiry date has fully passed
+// (a todo expiring on Friday is still active all of Friday).
+// Expired todos are distinct from completed ones and are hidden from the default list.
+// Returns the todos that expired during this call.
+func (tl *TodoList) ExpireOverdue(now time.Time) []Todo {
+	expired := []Todo{}
+	for i := range tl.Todos {
+		todo := &tl.Todos[i]
+		if todo.IsOpen() && todo.ExpiresAt != nil && !now.Before(todo.ExpiresAt.AddDate(0, 0, 1)) {
+			todo.Expired = true
+			expired = append(expired, *todo)
+		}
+	}
+	return expired
+}
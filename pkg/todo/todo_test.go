@@ -0,0 +1,155 @@
+package todo
+
+import (
+	"bytes"         // Package for capturing what a list logs
+	"math"          // Package for comparing floating-point scores
+	"os"            // Package for writing a corrupt data file
+	"path/filepath" // Package for building paths inside temporary test directories
+	"strings"       // Package for checking what was logged
+	"testing"       // Package for writing automated tests
+	"time"          // Package for time-related operations
+)
+
+// recordingLogger is a Logger that records the messages it receives.
+type recordingLogger struct {
+	bytes.Buffer
+}
+
+func (l *recordingLogger) Debug(message string) { l.WriteString("debug: " + message + "\n") }
+func (l *recordingLogger) Info(message string)  { l.WriteString("info: " + message + "\n") }
+func (l *recordingLogger) Warn(message string)  { l.WriteString("warn: " + message + "\n") }
+func (l *recordingLogger) Error(err error, message string) {
+	l.WriteString("error: " + message + ": " + err.Error() + "\n")
+}
+func (l *recordingLogger) Timing(operation string, start time.Time) {
+	l.WriteString("timing: " + operation + "\n")
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	tl := NewTodoList()
+	due := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	tl.Add("Write report", PriorityHigh, &due, []string{"work"})
+	tl.Add("Buy milk", PriorityLow, nil, nil)
+	if err := tl.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	loaded, err := LoadFromFile(path)
+	if err != nil || len(loaded.Todos) != 2 || loaded.NextID != 3 || !loaded.Todos[0].DueDate.Equal(due) {
+		t.Fatalf("LoadFromFile() = %+v, %v; expected the saved todos", loaded, err)
+	}
+
+	if missing, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(missing.Todos) != 0 {
+		t.Errorf("LoadFromFile() of a missing file expected an empty list, got %+v, %v", missing, err)
+	}
+	os.WriteFile(path, []byte("{not json"), 0644)
+	if _, err := LoadFromFile(path); err == nil {
+		t.Errorf("LoadFromFile() expected an error for a corrupt file")
+	}
+}
+
+func TestListLogger(t *testing.T) {
+	recorded := &recordingLogger{}
+	tl := NewTodoList()
+	tl.SetLogger(recorded)
+	tl.Add("Write report", PriorityHigh, nil, nil)
+	if todos := tl.Filter(ListOptions{Query: "priority:urgent"}); len(todos) != 1 {
+		t.Errorf("Filter() with an invalid query expected the todos unfiltered, got %+v", todos)
+	}
+	if !strings.Contains(recorded.String(), "error: Failed to compile filter query") {
+		t.Errorf("Filter() expected the invalid query to be logged, got:\n%s", recorded.String())
+	}
+
+	// Without a logger of its own, a list logs nothing unless a default logger is set.
+	tl = NewTodoList()
+	tl.Filter(ListOptions{Query: "priority:urgent"})
+	defer SetDefaultLogger(nil)
+	SetDefaultLogger(recorded)
+	recorded.Reset()
+	tl.Filter(ListOptions{Query: "priority:urgent"})
+	if !strings.Contains(recorded.String(), "error: Failed to compile filter query") {
+		t.Errorf("Filter() expected the default logger to be used, got:\n%s", recorded.String())
+	}
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	// Lists saved before manual ordering existed keep their stored order.
+	tl := &TodoList{Todos: []Todo{{ID: 2, Task: "Second"}, {ID: 1, Task: "First"}}, NextID: 3}
+	tl.normalizeOrder()
+	if tl.Todos[0].Order != 1 || tl.Todos[1].Order != 2 || tl.nextOrder() != 3 {
+		t.Errorf("normalizeOrder() expected the stored order, got %+v", tl.Todos)
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	r, err := ParseRecurrence("every 2 weeks")
+	if err != nil {
+		t.Fatalf("ParseRecurrence() error = %v", err)
+	}
+	wednesday := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+	if next := r.Next(wednesday).Format("2006-01-02"); next != "2025-03-26" {
+		t.Errorf("Next() expected 2025-03-26, got %s", next)
+	}
+
+	defer SetDefaultRecurrenceRules("", "")
+	if err := SetDefaultRecurrenceRules(RecurFromCompletion, RecurOverdueCollapse); err != nil ||
+		recurrenceFrom != RecurFromCompletion || recurrenceOverdue != RecurOverdueCollapse {
+		t.Errorf("SetDefaultRecurrenceRules() = %v; expected the rules to be set, got %q/%q", err, recurrenceFrom, recurrenceOverdue)
+	}
+	if err := SetDefaultRecurrenceRules("sometimes", ""); err == nil || recurrenceFrom != RecurFromCompletion {
+		t.Errorf("SetDefaultRecurrenceRules() expected an error and no change for an invalid basis, got %v", err)
+	}
+}
+
+func TestUrgencyScore(t *testing.T) {
+	defer SetUrgencyWeights(DefaultUrgencyWeights())
+	now := time.Now()
+	today := now.Truncate(time.Hour)
+	tl := NewTodoList()
+	tl.Add("Ship release", PriorityHigh, &today, []string{"Work"})
+	tl.Add("Tidy garage", PriorityLow, nil, nil)
+	tl.Todos[1].CreatedAt = now.AddDate(-2, 0, 0) // Age counts for at most a year.
+	tl.Add("Old news", PriorityHigh, nil, nil)
+	tl.Complete(3)
+
+	dueSoon := 6 + 12*dueFactor(today.Sub(now)) + 0.8
+	for _, tc := range []struct {
+		id       int
+		expected float64
+	}{{1, dueSoon}, {2, 1.8 + 2}, {3, 0}} {
+		todo, _ := tl.Get(tc.id)
+		if got := todo.Urgency(now); math.Abs(got-tc.expected) > 0.001 {
+			t.Errorf("Urgency() of todo %d expected %.3f, got %.3f", tc.id, tc.expected, got)
+		}
+	}
+	if dueFactor(-8*24*time.Hour) != 1 || dueFactor(20*24*time.Hour) != 0.2 || math.Abs(dueFactor(0)-0.7333) > 0.001 {
+		t.Errorf("dueFactor() expected 1 a week overdue, 0.2 in two weeks, and 0.733 today")
+	}
+
+	weights := DefaultUrgencyWeights()
+	weights.Tag = map[string]float64{"work": -20}
+	SetUrgencyWeights(weights)
+	if got := tl.Todos[0].Urgency(now); math.Abs(got-(dueSoon-20)) > 0.001 {
+		t.Errorf("Urgency() with a tag weight expected %.3f, got %.3f", dueSoon-20, got)
+	}
+}
+
+func TestSearchScores(t *testing.T) {
+	tl := NewTodoList()
+	tl.Add("Report", PriorityLow, nil, nil)
+	tl.Add("Call the printer repair", PriorityLow, nil, []string{"report"})
+	tl.Add("Buy groceries", PriorityLow, nil, nil)
+
+	results := tl.Search("report")
+	if len(results) != 2 || results[0].Score != matchExact*taskMatchWeight || results[1].Score != matchExact*tagMatchWeight {
+		t.Errorf("Search() expected an exact task and an exact tag match, got %+v", results)
+	}
+	if results := tl.FuzzySearch("grcoeries"); len(results) != 1 || results[0].Score != matchFuzzy*taskMatchWeight {
+		t.Errorf("FuzzySearch() expected an approximate task match, got %+v", results)
+	}
+	for a, b := range map[string]string{"grcoeries": "groceries", "dentsit": "dentist"} {
+		if distance := editDistance([]rune(a), []rune(b)); distance != 1 {
+			t.Errorf("editDistance(%q, %q) = %d, expected 1", a, b, distance)
+		}
+	}
+}
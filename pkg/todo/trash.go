@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., error messages)
+	"time" // Package for deletion dates and retention
+)
+
+// TrashedTodo is a deleted todo kept in the trash, along with the subtasks deleted with it.
+type TrashedTodo struct {
+	Todo      Todo      `json:"todo"`       // The deleted todo.
+	Subtasks  []Todo    `json:"subtasks"`   // Its subtasks, including nested ones, deleted with it.
+	DeletedAt time.Time `json:"deleted_at"` // When the todo was deleted.
+}
+
+// MoveToTrash deletes the todo with the given ID along with its subtasks, and keeps them in the trash
+// so they can be restored. Returns the deleted todo and subtasks, or an error if the todo is not found.
+func (tl *TodoList) MoveToTrash(id int, now time.Time) (Todo, []Todo, error) {
+	deletedTodo, deletedSubtasks, err := tl.DeleteWithSubtasks(id)
+	if err != nil {
+		return Todo{}, nil, err
+	}
+	tl.Trash = append(tl.Trash, TrashedTodo{Todo: deletedTodo, Subtasks: deletedSubtasks, DeletedAt: now})
+	return deletedTodo, deletedSubtasks, nil
+}
+
+// Restore moves the trashed todo with the given ID, and the subtasks deleted with it, back into the list.
+// If its parent is no longer in the list, it is restored as a top-level todo.
+// Returns the restored todo and subtasks, or an error if the todo is not in the trash.
+func (tl *TodoList) Restore(id int) (Todo, []Todo, error) {
+	for i, trashed := range tl.Trash {
+		if trashed.Todo.ID != id {
+			continue
+		}
+		tl.Trash = append(tl.Trash[:i], tl.Trash[i+1:]...)
+		todo := trashed.Todo
+		if _, err := tl.Get(todo.ParentID); err != nil {
+			todo.ParentID = 0
+		}
+		tl.Todos = append(tl.Todos, todo)
+		tl.Todos = append(tl.Todos, trashed.Subtasks...) // Subtasks keep their original IDs and parents.
+		return todo, trashed.Subtasks, nil
+	}
+	return Todo{}, nil, fmt.Errorf("todo with ID %d is not in the trash", id)
+}
+
+// RemoveFromTrash drops the trashed todo with the given ID without restoring it, e.g., after
+// an undone delete already put it back. Returns false if it is not in the trash.
+func (tl *TodoList) RemoveFromTrash(id int) bool {
+	for i, trashed := range tl.Trash {
+		if trashed.Todo.ID == id {
+			tl.Trash = append(tl.Trash[:i], tl.Trash[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// InTrash reports whether the todo with the given ID, or a subtask with that ID, is in the trash.
+func (tl *TodoList) InTrash(id int) bool {
+	for _, trashed := range tl.Trash {
+		if trashed.Todo.ID == id {
+			return true
+		}
+		for _, subtask := range trashed.Subtasks {
+			if subtask.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PurgeTrash permanently removes the todos that were deleted more than retentionDays days ago,
+// or all trashed todos if retentionDays is negative. Returns the purged todos.
+func (tl *TodoList) PurgeTrash(now time.Time, retentionDays int) []TrashedTodo {
+	purged := []TrashedTodo{}
+	kept := []TrashedTodo{}
+	for _, trashed := range tl.Trash {
+		if retentionDays < 0 || (retentionDays > 0 && now.Sub(trashed.DeletedAt) > time.Duration(retentionDays)*24*time.Hour) {
+			purged = append(purged, trashed)
+		} else {
+			kept = append(kept, trashed)
+		}
+	}
+	tl.Trash = kept
+	return purged
+}
@@ -0,0 +1,79 @@
+package todo
+
+import (
+	"strings" // Package for case-insensitive tag matching
+	"time"    // Package for due date proximity and age
+)
+
+// UrgencyWeights are the coefficients of the urgency score, similar to Taskwarrior's urgency model.
+type UrgencyWeights struct {
+	Priority map[string]float64 `json:"priority"` // Weight of each priority (e.g., "high": 6).
+	Due      float64            `json:"due"`      // Weight of a due date, scaled by how close it is.
+	Age      float64            `json:"age"`      // Weight of age, reached once a todo is a year old.
+	Tags     float64            `json:"tags"`     // Weight of having tags, reached with three or more.
+	Tag      map[string]float64 `json:"tag"`      // Extra weight of specific tags (e.g., "urgent": 5); may be negative.
+}
+
+// DefaultUrgencyWeights returns the default urgency coefficients, the same as Taskwarrior's.
+func DefaultUrgencyWeights() UrgencyWeights {
+	return UrgencyWeights{
+		Priority: map[string]float64{"high": 6, "medium": 3.9, "low": 1.8},
+		Due:      12,
+		Age:      2,
+		Tags:     1,
+		Tag:      map[string]float64{},
+	}
+}
+
+// urgencyWeights are the urgency coefficients in use.
+var urgencyWeights = DefaultUrgencyWeights()
+
+// SetUrgencyWeights sets the coefficients of the urgency scores of all todos, which are
+// DefaultUrgencyWeights until then.
+func SetUrgencyWeights(weights UrgencyWeights) {
+	urgencyWeights = weights
+}
+
+// Urgency returns the urgency score of the todo at the given time, weighted as set with
+// SetUrgencyWeights: the higher, the sooner it should be worked on. Todos that are no longer
+// open have no urgency.
+func (todo Todo) Urgency(now time.Time) float64 {
+	if !todo.IsOpen() {
+		return 0
+	}
+	score := urgencyWeights.Priority[string(todo.Priority.Canonical())]
+	if todo.DueDate != nil {
+		score += urgencyWeights.Due * dueFactor(todo.DueDate.Sub(now))
+	}
+	score += urgencyWeights.Age * min(now.Sub(todo.CreatedAt).Hours()/24/365, 1)
+	switch len(todo.Tags) {
+	case 0:
+	case 1:
+		score += urgencyWeights.Tags * 0.8
+	case 2:
+		score += urgencyWeights.Tags * 0.9
+	default:
+		score += urgencyWeights.Tags
+	}
+	for _, tag := range todo.Tags {
+		for name, weight := range urgencyWeights.Tag {
+			if strings.EqualFold(name, tag) {
+				score += weight
+			}
+		}
+	}
+	return score
+}
+
+// dueFactor scales the due date weight by how soon a todo is due: 1 once it is a week overdue,
+// falling linearly to 0.2 when it is due in two weeks or more.
+func dueFactor(untilDue time.Duration) float64 {
+	days := untilDue.Hours() / 24
+	switch {
+	case days <= -7:
+		return 1
+	case days >= 14:
+		return 0.2
+	}
+	return (14-days)*0.8/21 + 0.2
+}
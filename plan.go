@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"          // Package for parsing the plan command options
+	"fmt"           // Package for formatted I/O (e.g., building the plan sheet)
+	"io"            // Package for I/O primitives
+	"sort"          // Package for ordering the plan sections
+	"strings"       // Package for string manipulation
+	"time"          // Package for working with the plan date
+	"todo/pkg/todo" // Package for parsing the plan date
+)
+
+// maxPlanItems is the maximum number of todos in the top priorities and quick wins sections.
+const maxPlanItems = 5
+
+// planNoteLines is the number of blank lines left for notes at the end of a plan sheet.
+const planNoteLines = 5
+
+// DailyPlan is a plan for a single day, assembled from the open todos.
+type DailyPlan struct {
+	Date          time.Time `json:"date"`           // The day the plan is for.
+	Due           []Todo    `json:"due"`            // Open todos due on or before the day.
+	TopPriorities []Todo    `json:"top_priorities"` // Other open high-priority todos.
+	QuickWins     []Todo    `json:"quick_wins"`     // Open low-priority todos without a due date, shortest first.
+}
+
+// planDay assembles the daily plan of the list for the given day. Every open todo appears in at most one section:
+// todos that are due (or overdue) come first, then the remaining high-priority todos,
+// then low-priority todos without a due date as quick wins.
+func planDay(tl *TodoList, day time.Time) DailyPlan {
+	plan := DailyPlan{Date: day, Due: []Todo{}, TopPriorities: []Todo{}, QuickWins: []Todo{}}
+	endOfDay := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	for _, todo := range tl.Todos {
+		if !todo.IsOpen() || todo.IsDeferred(day) {
+			continue
+		}
+		switch {
+		case todo.DueDate != nil && todo.DueDate.Before(endOfDay):
+			plan.Due = append(plan.Due, todo)
+		case todo.Priority == "high":
+			plan.TopPriorities = append(plan.TopPriorities, todo)
+		case todo.Priority == "low" && todo.DueDate == nil:
+			plan.QuickWins = append(plan.QuickWins, todo)
+		}
+	}
+
+	// Due todos are ordered by due date, then by priority.
+	sort.SliceStable(plan.Due, func(i, j int) bool {
+		if !plan.Due[i].DueDate.Equal(*plan.Due[j].DueDate) {
+			return plan.Due[i].DueDate.Before(*plan.Due[j].DueDate)
+		}
+		return plan.Due[i].Priority.Rank() > plan.Due[j].Priority.Rank()
+	})
+	// Top priorities with the nearest due date come first; those without one come last.
+	sort.SliceStable(plan.TopPriorities, func(i, j int) bool {
+		a, b := plan.TopPriorities[i].DueDate, plan.TopPriorities[j].DueDate
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+	// Shorter tasks are assumed to be quicker.
+	sort.SliceStable(plan.QuickWins, func(i, j int) bool {
+		return len(plan.QuickWins[i].Task) < len(plan.QuickWins[j].Task)
+	})
+
+	if len(plan.TopPriorities) > maxPlanItems {
+		plan.TopPriorities = plan.TopPriorities[:maxPlanItems]
+	}
+	if len(plan.QuickWins) > maxPlanItems {
+		plan.QuickWins = plan.QuickWins[:maxPlanItems]
+	}
+	return plan
+}
+
+// planSection is a titled group of todos in a plan sheet.
+type planSection struct {
+	title string
+	todos []Todo
+}
+
+// sections returns the plan's sections in the order they are printed.
+func (p DailyPlan) sections() []planSection {
+	return []planSection{
+		{"Scheduled & Due", p.Due},
+		{"Top Priorities", p.TopPriorities},
+		{"Quick Wins", p.QuickWins},
+	}
+}
+
+// WriteText writes the plan as a plain text sheet, ready to print.
+func (p DailyPlan) WriteText(w io.Writer) {
+	title := "DAILY PLAN - " + p.Date.Format("Monday, January 2, 2006")
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, strings.Repeat("=", len(title)))
+	for _, section := range p.sections() {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, strings.ToUpper(section.title))
+		if len(section.todos) == 0 {
+			fmt.Fprintln(w, "  (nothing)")
+		}
+		for _, todo := range section.todos {
+			fmt.Fprintf(w, "  [ ] %s%s\n", todo.Task, planItemDetails(todo, p.Date))
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "NOTES")
+	for i := 0; i < planNoteLines; i++ {
+		fmt.Fprintln(w, "  "+strings.Repeat("_", 60))
+	}
+}
+
+// WriteMarkdown writes the plan as a Markdown document, ready to paste into a journal app.
+func (p DailyPlan) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Daily Plan - %s\n", p.Date.Format("Monday, January 2, 2006"))
+	for _, section := range p.sections() {
+		fmt.Fprintf(w, "\n## %s\n\n", section.title)
+		if len(section.todos) == 0 {
+			fmt.Fprintln(w, "_Nothing here._")
+		}
+		for _, todo := range section.todos {
+			fmt.Fprintf(w, "- [ ] %s%s\n", todo.Task, planItemDetails(todo, p.Date))
+		}
+	}
+	fmt.Fprint(w, "\n## Notes\n\n")
+	for i := 0; i < planNoteLines; i++ {
+		fmt.Fprintln(w, "- ")
+	}
+}
+
+// planItemDetails returns the ID, priority, and due date shown after a task in a plan sheet,
+// flagging due dates before the plan date as overdue.
+func planItemDetails(todo Todo, day time.Time) string {
+	details := []string{fmt.Sprintf("#%d", todo.ID), string(todo.Priority)}
+	if todo.DueDate != nil {
+		due := formatDate(*todo.DueDate)
+		if todo.DueDate.Format("2006-01-02") < day.Format("2006-01-02") {
+			details = append(details, "overdue since "+due)
+		} else {
+			details = append(details, "due "+due)
+		}
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// parsePlanArgs parses the arguments of the plan command: an optional day
+// ("today", "tomorrow", or any other date, e.g., "friday", defaulting to today) followed by an optional
+// -format flag ("text" or "markdown"). Returns the plan day and the format.
+func parsePlanArgs(args []string, now time.Time) (time.Time, string, error) {
+	day := now
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch strings.ToLower(args[0]) {
+		case "today":
+		case "tomorrow":
+			day = now.AddDate(0, 0, 1)
+		default:
+			date, last := dateArg(args, 0)
+			parsedDate, err := todo.ParseDate(date, now)
+			if err != nil {
+				return time.Time{}, "", fmt.Errorf("invalid plan day: %w", err)
+			}
+			day = parsedDate
+			args = args[last:]
+		}
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.SetOutput(io.Discard) // Errors are returned and reported by the caller.
+	format := fs.String("format", "text", "Plan format: text or markdown")
+	if err := fs.Parse(args); err != nil {
+		return time.Time{}, "", err
+	}
+	if fs.NArg() > 0 {
+		return time.Time{}, "", fmt.Errorf("unexpected argument %q for plan command", fs.Arg(0))
+	}
+	switch *format {
+	case "text", "markdown":
+	default:
+		return time.Time{}, "", fmt.Errorf("invalid plan format %q: use text or markdown", *format)
+	}
+	return day, *format, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag" // Package for the list flags the preferences stand in for
+	"fmt"  // Package for formatted I/O (e.g., validation errors)
+	"io"   // Package for discarding flag parsing output
+	"time" // Package for checking the date format
+)
+
+// defaultDateFormat is the layout dates are shown in unless the date_format config setting says otherwise.
+const defaultDateFormat = "2006-01-02"
+
+// dateFormat is the Go time layout dates are shown in (e.g., "Jan 2, 2006" or "02.01.2006").
+// It is set from the date_format config setting or the -date-format flag.
+var dateFormat = defaultDateFormat
+
+// showEmoji controls whether messages keep their emoji. It is set from the emoji config setting,
+// and turned off by the -no-emoji flag or the minimal output profile.
+var showEmoji = true
+
+// validateDateFormat reports whether a date format shows which day a date is, which
+// layouts without a day (e.g., "15:04") or with no layout elements at all do not.
+func validateDateFormat(layout string) error {
+	day := time.Date(2009, time.November, 17, 0, 0, 0, 0, time.UTC)
+	if day.Format(layout) == day.AddDate(0, 0, 1).Format(layout) {
+		return fmt.Errorf("date_format %q does not show the day: use a Go layout like 2006-01-02 or Jan 2, 2006", layout)
+	}
+	return nil
+}
+
+// ListPreferences are the sort order and filters of the list command, used for the list flags
+// that are not given (e.g., {"sort_by": "due_date", "filter_status": "incomplete"}).
+type ListPreferences struct {
+	SortBy         string `json:"sort_by"`         // Like -sort-by; id if empty.
+	SortOrder      string `json:"sort_order"`      // Like -sort-order; asc if empty.
+	FilterStatus   string `json:"filter_status"`   // Like -filter-status; all if empty.
+	FilterPriority string `json:"filter_priority"` // Like -filter-priority.
+	FilterTags     string `json:"filter_tags"`     // Like -filter-tags: comma-separated tags.
+	FilterProject  string `json:"filter_project"`  // Like -filter-project.
+	Query          string `json:"query"`           // Like -query.
+}
+
+// listPreferences are the preferences of the list command. They are set from the list config setting.
+var listPreferences ListPreferences
+
+// apply returns the list flags with the preferences in place of the flags that were not given,
+// so that flags on the command line override the preferences one by one.
+func (p ListPreferences) apply(f listFlags) listFlags {
+	given := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { given[fl.Name] = true })
+	for _, preference := range []struct {
+		flag   string
+		value  string
+		target **string
+	}{
+		{"sort-by", p.SortBy, &f.sortBy},
+		{"sort-order", p.SortOrder, &f.sortOrder},
+		{"filter-status", p.FilterStatus, &f.filterStatus},
+		{"filter-priority", p.FilterPriority, &f.filterPriority},
+		{"filter-tags", p.FilterTags, &f.filterTags},
+		{"filter-project", p.FilterProject, &f.filterProject},
+		{"query", p.Query, &f.query},
+	} {
+		if preference.value != "" && !given[preference.flag] {
+			value := preference.value
+			*preference.target = &value
+		}
+	}
+	return f
+}
+
+// validate reports a preference that the list command would reject.
+func (p ListPreferences) validate() error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := p.apply(defineListFlags(fs)).options(); err != nil {
+		return fmt.Errorf("invalid list setting: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., the profile list)
+	"sort"    // Package for listing profiles by name
+	"strings" // Package for joining profile names in messages
+)
+
+// noProfile selects no profile: the data file, log file, and defaults of the config itself.
+const noProfile = "none"
+
+// Profile is a named context, like "work" or "personal", with its own todo list, log, and defaults
+// for new todos. Settings left empty are those of the config.
+type Profile struct {
+	DataFile    string       `json:"data_file"`     // Data file of the profile's todo list.
+	LogFilePath string       `json:"log_file_path"` // Log file of the profile.
+	HistoryFile string       `json:"history_file"`  // Interactive mode command history of the profile.
+	Defaults    TodoDefaults `json:"defaults"`      // Defaults of new todos, each replacing that of the config if set.
+}
+
+// activeProfile is the name of the profile in use, or empty if none is.
+var activeProfile string
+
+// profileNames returns the names of the profiles of a config, sorted.
+func profileNames(config Config) []string {
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateProfile reports whether name is a profile of the config, or none.
+func validateProfile(config Config, name string) error {
+	if _, ok := config.Profiles[name]; !ok && name != "" && name != noProfile {
+		if len(config.Profiles) == 0 {
+			return fmt.Errorf("unknown profile %q: no profiles are set in the config", name)
+		}
+		return fmt.Errorf("unknown profile %q: use %s, or none", name, strings.Join(profileNames(config), ", "))
+	}
+	return nil
+}
+
+// applyProfile replaces the settings of a config with those of the named profile, and records it
+// as the profile in use. Returns an error if there is no such profile. An empty name or none
+// applies no profile.
+func applyProfile(config *Config, name string) error {
+	if err := validateProfile(*config, name); err != nil {
+		return err
+	}
+	if name == "" || name == noProfile {
+		config.Profile = ""
+		return nil
+	}
+	profile := config.Profiles[name]
+	if profile.DataFile != "" {
+		config.DataFile = profile.DataFile
+	}
+	if profile.LogFilePath != "" {
+		config.LogFilePath = profile.LogFilePath
+	}
+	if profile.HistoryFile != "" {
+		config.HistoryFile = profile.HistoryFile
+	}
+	if profile.Defaults.Priority != "" {
+		config.Defaults.Priority = profile.Defaults.Priority
+	}
+	if profile.Defaults.Tags != nil {
+		config.Defaults.Tags = profile.Defaults.Tags
+	}
+	if profile.Defaults.Due != "" {
+		config.Defaults.Due = profile.Defaults.Due
+	}
+	config.Profile = name
+	return nil
+}
+
+// manageProfiles runs the profile command: "profile" lists the profiles of the config file, and
+// "profile use <name>" selects the one later runs use (none for no profile).
+func manageProfiles(args []string) {
+	usage := "Usage: profile [list] | profile use <name|none>"
+	if (len(args) > 0 && args[0] != "list" && args[0] != "use") || (len(args) > 0 && args[0] == "use" && len(args) != 2) {
+		PrintUserMessage(usage)
+		logInputError(fmt.Errorf("invalid arguments for profile command"), "Interactive mode input error")
+		return
+	}
+	config, err := LoadConfig(activeConfigFile)
+	if err != nil {
+		logger.Error(err, "Failed to load the config")
+		printError(err)
+		return
+	}
+
+	if len(args) == 0 || args[0] == "list" {
+		printProfiles(config)
+		return
+	}
+
+	name := args[1]
+	if name == noProfile {
+		name = ""
+	}
+	updated, err := setConfigValue(config, "profile", name)
+	if err == nil && !dryRun {
+		err = SaveConfig(updated, activeConfigFile)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to switch profile")
+		printError(err)
+		return
+	}
+	dataFile := updated.DataFile
+	if profile := updated.Profiles[name]; profile.DataFile != "" {
+		dataFile = profile.DataFile
+	}
+	message := fmt.Sprintf("👤 Switched to profile %s, with the list in %s. It takes effect the next time todo starts.", args[1], dataFile)
+	if dryRun {
+		message = fmt.Sprintf("👤 Would switch to profile %s, with the list in %s (dry run).", args[1], dataFile)
+	}
+	printResult(map[string]string{"profile": args[1], "data_file": dataFile}, message)
+}
+
+// profileSummary is a profile as listed by the profile command.
+type profileSummary struct {
+	Name     string `json:"name"`
+	DataFile string `json:"data_file"`
+	Active   bool   `json:"active"`   // Whether the profile is in use.
+	Selected bool   `json:"selected"` // Whether the config file selects the profile for later runs.
+}
+
+// printProfiles lists the profiles of a config, marking the one in use.
+func printProfiles(config Config) {
+	summaries := []profileSummary{}
+	for _, name := range profileNames(config) {
+		dataFile := config.Profiles[name].DataFile
+		if dataFile == "" {
+			dataFile = config.DataFile
+		}
+		summaries = append(summaries, profileSummary{Name: name, DataFile: dataFile, Active: name == activeProfile, Selected: name == config.Profile})
+	}
+	if outputJSON {
+		printJSON(summaries)
+		return
+	}
+	if len(summaries) == 0 {
+		PrintUserMessage("👤 No profiles are set. Add them under profiles in " + activeConfigFile + ".")
+		return
+	}
+	PrintUserMessage("👤 Profiles:")
+	for _, summary := range summaries {
+		marker := " "
+		if summary.Active {
+			marker = "*"
+		}
+		line := fmt.Sprintf("  %s %s (%s)", marker, summary.Name, summary.DataFile)
+		if summary.Selected && !summary.Active {
+			line += " (selected in the config)"
+		}
+		PrintUserMessage(line)
+	}
+}
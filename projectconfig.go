@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"           // Package for formatted I/O (e.g., error messages)
+	"os"            // Package for finding and creating the project setup
+	"path/filepath" // Package for walking up the directory tree
+)
+
+// projectSetupDir is the directory holding the todo setup of a project, like .git for git.
+const projectSetupDir = ".todo"
+
+// projectConfigNames are the names of the config file in projectSetupDir, in the order they are looked for.
+var projectConfigNames = []string{"config", "config.yaml", "config.yml", "config.toml"}
+
+// defaultConfigFile returns the config file to use without the -config flag: the one set with
+// TODO_CONFIG, else the config of the project the working directory is in, else config.json.
+func defaultConfigFile() string {
+	if path := os.Getenv(envConfigFile); path != "" {
+		return path
+	}
+	if dir, err := os.Getwd(); err == nil {
+		if path, ok := findProjectConfig(dir); ok {
+			return path
+		}
+	}
+	return defaultConfigPath
+}
+
+// isFile reports whether path is an existing file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// findProjectConfig walks up from dir looking for a .todo directory with a config file, like git
+// looks for .git. Returns the path of the config file, or false if there is none.
+func findProjectConfig(dir string) (string, bool) {
+	for {
+		for _, name := range projectConfigNames {
+			if path := filepath.Join(dir, projectSetupDir, name); isFile(path) {
+				return path, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// resolveProjectPaths makes the relative paths of a project config (one in a .todo directory)
+// relative to that directory, so that the project's list is found from any of its subdirectories.
+// Other configs keep paths relative to the working directory.
+func resolveProjectPaths(config *Config, configPath string) {
+	dir := filepath.Dir(configPath)
+	if filepath.Base(dir) != projectSetupDir {
+		return
+	}
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	config.DataFile = resolve(config.DataFile)
+	config.LogFilePath = resolve(config.LogFilePath)
+	config.HistoryFile = resolve(config.HistoryFile)
+	config.AttachmentsDir = resolve(config.AttachmentsDir)
+	for name, file := range config.Lists {
+		config.Lists[name] = resolve(file)
+	}
+}
+
+// initProject creates a project setup in dir: a .todo directory with a config that keeps the
+// project's list, history, and attachments in that directory. Returns the path of the config,
+// or an error if the project already has one.
+func initProject(dir string) (string, error) {
+	setupDir := filepath.Join(dir, projectSetupDir)
+	for _, name := range projectConfigNames {
+		if path := filepath.Join(setupDir, name); isFile(path) {
+			return "", fmt.Errorf("%s already exists", path)
+		}
+	}
+	configPath := filepath.Join(setupDir, projectConfigNames[0])
+	if dryRun {
+		return configPath, nil
+	}
+	if err := os.MkdirAll(setupDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", setupDir, err)
+	}
+	config := DefaultConfig()
+	config.HistoryFile = "history" // Like the list and attachments, kept in .todo.
+	return configPath, SaveConfig(config, configPath)
+}
+
+// initProjectCommand runs the init command, which creates a project setup in the working directory.
+func initProjectCommand() {
+	dir, err := os.Getwd()
+	var configPath string
+	if err == nil {
+		configPath, err = initProject(dir)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to initialize a project")
+		printError(err)
+		return
+	}
+	message := fmt.Sprintf("📁 Created %s. Commands run in this directory or below now use the project's own list in %s.", configPath, filepath.Dir(configPath))
+	if dryRun {
+		message = fmt.Sprintf("📁 Would create %s (dry run).", configPath)
+	}
+	printResult(map[string]string{"config": configPath}, message)
+}
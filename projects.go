@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"sort"    // Package for ordering projects by name
+	"strings" // Package for string manipulation
+)
+
+// formatProjectGroups renders the given todos grouped by project, each group with a header showing
+// the project's progress. Groups are sorted by project name, followed by the todos without a project.
+func formatProjectGroups(tl *TodoList, todos []Todo, verbose bool) []string {
+	groups := map[string][]Todo{}
+	names := []string{}
+	for _, todo := range todos {
+		if _, ok := groups[todo.Project]; !ok {
+			names = append(names, todo.Project)
+		}
+		groups[todo.Project] = append(groups[todo.Project], todo)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == "" && names[i] != "" // Todos without a project come last.
+		}
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	progress := map[string]ProjectSummary{}
+	for _, summary := range tl.Projects() {
+		progress[summary.Name] = summary
+	}
+	lines := []string{}
+	for _, name := range names {
+		header := "📁 No project"
+		if name != "" {
+			header = "📁 " + name
+			if summary, ok := progress[name]; ok {
+				header += fmt.Sprintf(" (%d/%d done)", summary.Completed, summary.Total)
+			}
+		}
+		lines = append(lines, header)
+		lines = append(lines, formatTodoTree(tl, groups[name], verbose)...)
+	}
+	return lines
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath" // Package for deriving the list name from the data file path
+	"strings"       // Package for string manipulation
+	"text/template" // Package for rendering the configurable prompt
+	"time"          // Package for determining which todos are due today
+)
+
+// defaultPrompt is the interactive mode prompt used when none is configured.
+const defaultPrompt = "> "
+
+// promptData is the data available to the prompt template, e.g.,
+// "{{.List}} ({{.Pending}} open, {{.DueToday}} due today)> ".
+type promptData struct {
+	List     string // Name of the active list, derived from the data file name (e.g., "todos").
+	Total    int    // Number of todos in the list.
+	Pending  int    // Number of open (not completed and not expired) todos.
+	DueToday int    // Number of open todos due today.
+	Overdue  int    // Number of open todos whose due date has passed.
+	// SaveFailed is true while the last save of the list has failed. The prompt is then
+	// prefixed with a warning, whatever the template.
+	SaveFailed bool
+}
+
+// saveFailedPromptPrefix is put in front of the prompt while the last save has failed.
+const saveFailedPromptPrefix = "⚠️ SAVE FAILED (see status) "
+
+// interactivePrompt renders the interactive mode prompt from a template.
+type interactivePrompt struct {
+	template *template.Template
+	listName string
+	dataFile string
+}
+
+// newInteractivePrompt parses the prompt template for the list stored in dataFile.
+// An empty or invalid template falls back to the default prompt.
+func newInteractivePrompt(promptTemplate string, dataFile string) *interactivePrompt {
+	if promptTemplate == "" {
+		promptTemplate = defaultPrompt
+	}
+	tmpl, err := template.New("prompt").Parse(promptTemplate)
+	if err != nil {
+		logger.Error(err, "Failed to parse prompt template, using the default prompt")
+		tmpl = template.Must(template.New("prompt").Parse(defaultPrompt))
+	}
+	return &interactivePrompt{template: tmpl, listName: listNameOf(dataFile), dataFile: dataFile}
+}
+
+// listNameOf returns the name of the list stored in dataFile: its file name without the extension.
+func listNameOf(dataFile string) string {
+	return strings.TrimSuffix(filepath.Base(dataFile), filepath.Ext(dataFile))
+}
+
+// Render returns the prompt text for the current state of the todo list.
+func (p *interactivePrompt) Render(todoList *TodoList, now time.Time) string {
+	data := promptData{List: p.listName, Total: len(todoList.Todos), SaveFailed: loadSaveFailure(p.dataFile) != nil}
+	today := now.Format("2006-01-02")
+	for _, todo := range todoList.Todos {
+		if !todo.IsOpen() {
+			continue
+		}
+		data.Pending++
+		if todo.DueDate == nil {
+			continue
+		}
+		if due := todo.DueDate.Format("2006-01-02"); due == today {
+			data.DueToday++
+		} else if due < today {
+			data.Overdue++
+		}
+	}
+
+	var prompt strings.Builder
+	if err := p.template.Execute(&prompt, data); err != nil {
+		logger.Error(err, "Failed to render prompt template")
+		prompt.Reset()
+		prompt.WriteString(defaultPrompt)
+	}
+	if data.SaveFailed {
+		return saveFailedPromptPrefix + prompt.String()
+	}
+	return prompt.String()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"           // Package for formatted I/O (e.g., warnings)
+	"strings"       // Package for string manipulation
+	"todo/pkg/todo" // Package for the todo engine's recurrence rules
+)
+
+// setRecurrenceRules sets the global advancement rules of recurring todos, falling back to
+// "schedule" and "pile-up" for invalid values.
+func setRecurrenceRules(from string, overdue string) {
+	if err := todo.ValidateRecurrenceRules(from, ""); err != nil {
+		logger.Warn(fmt.Sprintf("%v, using %s", err, RecurFromSchedule))
+		from = ""
+	}
+	if err := todo.ValidateRecurrenceRules("", overdue); err != nil {
+		logger.Warn(fmt.Sprintf("%v, using %s", err, RecurOverduePileUp))
+		overdue = ""
+	}
+	todo.SetDefaultRecurrenceRules(from, overdue)
+}
+
+// describeRecurrenceRules describes the advancement rules a todo sets for itself (e.g.,
+// "from completion, collapsing missed occurrences"), or returns "" if it follows the global settings.
+func describeRecurrenceRules(todo Todo) string {
+	rules := []string{}
+	if todo.RecurFrom != "" {
+		rules = append(rules, "from "+todo.RecurFrom)
+	}
+	switch todo.RecurOverdue {
+	case RecurOverduePileUp:
+		rules = append(rules, "piling up missed occurrences")
+	case RecurOverdueCollapse:
+		rules = append(rules, "collapsing missed occurrences")
+	}
+	return strings.Join(rules, ", ")
+}
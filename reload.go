@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"        // Package for reporting why the config cannot be reloaded
+	"fmt"           // Package for formatted I/O (e.g., the reload message)
+	"reflect"       // Package for finding the settings that changed
+	"sort"          // Package for listing the changed settings in order
+	"strings"       // Package for joining the changed settings
+	"time"          // Package for the auto-save interval
+	"todo/pkg/todo" // Package for loading the todo list
+)
+
+// sessionFlags are the command-line flags the application was started with. They still
+// override the config file when it is reloaded.
+var sessionFlags commandFlags
+
+// changedSettings returns the keys of the top-level settings that differ between two configs, sorted.
+func changedSettings(before, after Config) []string {
+	beforeValues, _ := configValues(before) // A Config always marshals.
+	afterValues, _ := configValues(after)
+	changed := []string{}
+	for key, value := range afterValues {
+		if !reflect.DeepEqual(beforeValues[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// switchDataFile saves the todo list to its data file, as on exit, and replaces it with the list
+// in dataFile, along with the undo stack.
+func switchDataFile(todoList *TodoList, from, to string) error {
+	if err := saveOnExit(todoList, from); err != nil {
+		return fmt.Errorf("failed to save %s before switching to %s: %w", from, to, err)
+	}
+	loaded, err := todo.LoadFromFile(to)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", to, err)
+	}
+	*todoList = *loaded
+	loadedTodoList = loaded.Clone()
+	if err := loadUndoJournal(to); err != nil {
+		logger.Error(err, "Failed to load the undo journal")
+	}
+	return nil
+}
+
+// reloadConfig reads the config file again, with the same flags as on startup, and applies it
+// to the running session: the settings the commands read, and the data file, log file, and
+// auto-save interval. Returns the reloaded config, or the current one if it cannot be reloaded.
+func reloadConfig(todoList *TodoList, current Config) Config {
+	if inTransaction() {
+		err := errors.New("a transaction is open: commit or roll it back before reloading the config")
+		logger.Error(err, "Failed to reload the config")
+		printError(err)
+		return current
+	}
+	config, err := loadSettings(sessionFlags)
+	if err == nil {
+		err = validateConfig(config)
+	}
+	if err == nil && config.DataFile != current.DataFile {
+		if dryRun {
+			err = fmt.Errorf("cannot switch to %s in dry-run mode", config.DataFile)
+		} else {
+			if autoSave != nil {
+				autoSave.Stop() // So that the list is not auto-saved while it is replaced.
+			}
+			err = switchDataFile(todoList, current.DataFile, config.DataFile)
+			if err != nil {
+				config.DataFile = current.DataFile // Keep the list, and auto-save it where it was.
+			}
+		}
+	}
+	if autoSave != nil {
+		autoSave.Restart(config.DataFile, time.Duration(config.AutoSaveInterval))
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reload the config")
+		printError(err)
+		return current
+	}
+
+	if config.LogFilePath != current.LogFilePath || config.LogLevel != current.LogLevel || config.LogRotation != current.LogRotation {
+		level, _ := parseLogLevel(config.LogLevel) // Checked by loadSettings.
+		if err := SetupLogger(config.LogFilePath, level, config.LogRotation); err != nil {
+			PrintUserMessage(fmt.Sprintf("⚠️ Logging to stderr only: %v.", err))
+		}
+	}
+	applySettings(config, sessionFlags)
+
+	changed := changedSettings(current, config)
+	message := fmt.Sprintf("⚙️ Reloaded %s; nothing changed.", activeConfigFile)
+	if len(changed) > 0 {
+		message = fmt.Sprintf("⚙️ Reloaded %s: %s changed.", activeConfigFile, strings.Join(changed, ", "))
+	}
+	printResult(map[string]any{"config_file": activeConfigFile, "changed": changed, "data_file": config.DataFile}, message)
+	if config.DataFile != current.DataFile {
+		PrintUserMessage(fmt.Sprintf("📂 Switched to the list in %s (%d todos).", config.DataFile, len(todoList.Todos)))
+	}
+	if config.HistoryFile != current.HistoryFile {
+		PrintUserMessage("💡 The new history_file takes effect the next time todo starts.")
+	}
+	return config
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json" // Package for encoding the save-failed marker
+	"fmt"           // Package for formatted I/O (e.g., the warning message)
+	"os"            // Package for writing and removing the marker file
+	"sync"          // Package for guarding the last failure, which auto-save updates concurrently
+	"time"          // Package for recording when a save failed
+)
+
+// saveFailure describes the last failed save of a data file. It is kept in a marker file next to
+// the data file until a save succeeds, so a failing auto-save is noticed even after a restart.
+type saveFailure struct {
+	FailedAt time.Time `json:"failed_at"` // When the save failed.
+	Error    string    `json:"error"`     // Why it failed.
+}
+
+// lastSaveFailure is the last failed save in this session, or nil if the last save succeeded
+// (or nothing was saved yet). It is guarded by saveFailureMu.
+var (
+	lastSaveFailure *saveFailure
+	saveFailureMu   sync.Mutex
+)
+
+// activeDataFile is the data file the todo list is loaded from and saved to.
+// It is set from the data_file config setting or the -data-file flag.
+var activeDataFile string
+
+// saveFailedMarkerPath returns the path of the marker file recording a failed save of dataFile.
+func saveFailedMarkerPath(dataFile string) string {
+	return dataFile + ".save-failed"
+}
+
+// saveTodoList saves the todo list to dataFile and records whether the save failed.
+func saveTodoList(todoList *TodoList, dataFile string) error {
+	err := todoList.SaveToFile(dataFile)
+	recordSaveResult(dataFile, err, time.Now())
+	return err
+}
+
+// recordSaveResult writes the save-failed marker if err is not nil, and removes it otherwise.
+// The failure is also kept in memory, in case the marker cannot be written either
+// (e.g., because the data file's directory is read-only).
+func recordSaveResult(dataFile string, err error, now time.Time) {
+	saveFailureMu.Lock()
+	defer saveFailureMu.Unlock()
+	marker := saveFailedMarkerPath(dataFile)
+	if err == nil {
+		lastSaveFailure = nil
+		if removeErr := os.Remove(marker); removeErr != nil && !os.IsNotExist(removeErr) {
+			logger.Error(removeErr, fmt.Sprintf("Failed to remove save-failed marker %s", marker))
+		}
+		return
+	}
+
+	lastSaveFailure = &saveFailure{FailedAt: now, Error: err.Error()}
+	data, _ := json.Marshal(lastSaveFailure) // A struct of a time and a string always marshals.
+	if writeErr := os.WriteFile(marker, data, 0644); writeErr != nil {
+		logger.Error(writeErr, fmt.Sprintf("Failed to write save-failed marker %s", marker))
+	}
+}
+
+// loadSaveFailure returns the last failed save of dataFile, from this session or from the marker
+// left by an earlier one, or nil if the last save succeeded.
+func loadSaveFailure(dataFile string) *saveFailure {
+	saveFailureMu.Lock()
+	defer saveFailureMu.Unlock()
+	if lastSaveFailure != nil {
+		return lastSaveFailure
+	}
+	data, err := os.ReadFile(saveFailedMarkerPath(dataFile))
+	if err != nil {
+		return nil // No marker: the last save succeeded.
+	}
+	failure := &saveFailure{Error: "unknown error"}
+	if err := json.Unmarshal(data, failure); err != nil {
+		logger.Error(err, "Failed to read save-failed marker")
+	}
+	return failure
+}
+
+// saveFailureWarning returns the warning shown while the last save of dataFile has failed, or "".
+func saveFailureWarning(dataFile string) string {
+	failure := loadSaveFailure(dataFile)
+	if failure == nil {
+		return ""
+	}
+	return fmt.Sprintf("⚠️ The last save of %s failed on %s: %s. Recent changes may be lost until a save succeeds.",
+		dataFile, failure.FailedAt.Format("2006-01-02 15:04"), failure.Error)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"       // Package for formatted I/O (e.g., log messages)
+	"os"        // Package for receiving signals and exiting
+	"os/signal" // Package for catching Ctrl-C and SIGTERM
+	"syscall"   // Package for the SIGTERM signal
+)
+
+// handleShutdownSignals runs shutdown and exits when the program receives Ctrl-C (SIGINT) or SIGTERM,
+// so that the changes made since the last auto-save are saved rather than lost. While the line editor
+// reads a line, Ctrl-C only discards the line, as the terminal is in raw mode then; it interrupts
+// running commands and plain (e.g., piped) input. The returned function stops handling the signals.
+func handleShutdownSignals(shutdown func()) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Info(fmt.Sprintf("Received %s: saving the todo list and exiting.", sig))
+			shutdown()
+			os.Exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// signalExitCode returns the exit code for a program stopped by a signal, 128 plus the signal
+// number, as shells report it (e.g., 130 for Ctrl-C).
+func signalExitCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return 128 + int(number)
+	}
+	return 1
+}
@@ -0,0 +1,5 @@
+package main
+
+// chronicSnoozeThreshold is the number of snoozes after which an open todo is reported as
+// chronically postponed. It is set from the chronic_snooze_threshold config setting.
+var chronicSnoozeThreshold = 3
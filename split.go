@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"   // Package for reading the edited subtask lines
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"os"      // Package for temporary files and the editor's standard streams
+	"os/exec" // Package for running the user's editor
+	"strconv" // Package for passing the parent ID to the add syntax
+	"strings" // Package for string manipulation
+	"time"    // Package for dividing estimates
+)
+
+// defaultEditor is the editor used by split -editor when neither $VISUAL nor $EDITOR is set.
+const defaultEditor = "vi"
+
+// SplitTodo adds one subtask of the todo with the given ID per line, using the same inline syntax as
+// the add command (e.g., "Draft intro -d 2024-05-01"). Subtasks inherit the priority and project of
+// the todo unless a line sets its own. Lines that cannot be parsed are reported and skipped.
+// If distributeEstimate is set, the todo's estimate is divided evenly among the new subtasks that have
+// no estimate of their own, and removed from the todo so it is not counted twice.
+// Returns the added subtasks, or an error if the todo is not found.
+func SplitTodo(todoList *TodoList, id int, lines []string, distributeEstimate bool) ([]Todo, error) {
+	parent, err := todoList.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	inherited := []string{"-p", string(parent.Priority)}
+	if parent.Project != "" {
+		inherited = append(inherited, "-project", parent.Project)
+	}
+
+	subtasks := []Todo{}
+	for i, line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		// Options on the line come after the inherited ones, so they take precedence.
+		args := append(append(append([]string{}, inherited...), parts...), "-parent", strconv.Itoa(id))
+		subtask, err := addTodoFromArgs(todoList, args)
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Failed to add subtask from line %d", i+1))
+			PrintUserMessage(fmt.Sprintf("⚠️ Skipping line %d: %v", i+1, err))
+			continue
+		}
+		subtasks = append(subtasks, subtask)
+	}
+
+	if distributeEstimate && parent.Estimate != 0 {
+		unestimated := []int{}
+		for _, subtask := range subtasks {
+			if subtask.Estimate == 0 {
+				unestimated = append(unestimated, subtask.ID)
+			}
+		}
+		if len(unestimated) > 0 {
+			share := time.Duration(parent.Estimate) / time.Duration(len(unestimated))
+			for _, subtaskID := range unestimated {
+				todoList.SetEstimate(subtaskID, share)
+			}
+			todoList.SetEstimate(id, 0)
+		}
+	}
+	for i := range subtasks {
+		subtasks[i], _ = todoList.Get(subtasks[i].ID)
+	}
+	return subtasks, nil
+}
+
+// askSubtaskLines prompts for subtasks, one per line, until an empty line or the end of the input.
+func askSubtaskLines() []string {
+	PrintUserMessage("Enter one subtask per line (add options like -d or -est as needed). Finish with an empty line.")
+	lines := []string{}
+	for {
+		line, err := getConsole().Ask(fmt.Sprintf("Subtask %d: ", len(lines)+1))
+		if err != nil || strings.TrimSpace(line) == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+// editSubtaskLines opens the user's editor ($VISUAL, $EDITOR, or vi) on a temporary file and returns
+// the lines written to it, leaving out comment lines starting with "#".
+func editSubtaskLines(todo Todo) ([]string, error) {
+	file, err := os.CreateTemp("", "todo-split-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	fmt.Fprintf(file, "# Split todo #%d: %s\n# Write one subtask per line (add options like -d or -est as needed).\n# Lines starting with # are ignored.\n", todo.ID, todo.Task)
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	// The editor setting may include arguments (e.g., "code --wait").
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+
+	edited, err := os.Open(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer edited.Close()
+	lines := []string{}
+	scanner := bufio.NewScanner(edited)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
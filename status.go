@@ -0,0 +1,21 @@
+package main
+
+// statusMarker returns the checkbox shown in front of a todo in lists:
+// "[x]" done, "[-]" cancelled, "[~]" expired, "[>]" in progress, "[?]" waiting, "[!]" blocked, or "[ ]".
+func statusMarker(todo Todo) string {
+	switch {
+	case todo.Completed:
+		return "[x]"
+	case todo.Status == StatusCancelled:
+		return "[-]"
+	case todo.Expired:
+		return "[~]"
+	case todo.Status == StatusInProgress:
+		return "[>]"
+	case todo.Status == StatusWaiting:
+		return "[?]"
+	case todo.Status == StatusBlocked:
+		return "[!]"
+	}
+	return "[ ]"
+}
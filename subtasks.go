@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., error messages)
+	"strings" // Package for string manipulation
+	"time"    // Package for computing urgency scores
+)
+
+// deleteConfirmationPrompt returns the question asked before deleting the todo with the given ID,
+// mentioning how many subtasks would be deleted with it.
+func deleteConfirmationPrompt(todoList *TodoList, id int) string {
+	if count := countDescendants(todoList, id); count > 0 {
+		return fmt.Sprintf("Are you sure you want to delete todo with ID %d and its %d subtasks?", id, count)
+	}
+	return fmt.Sprintf("Are you sure you want to delete todo with ID %d?", id)
+}
+
+// countDescendants returns the number of subtasks of the todo with the given ID, including nested ones.
+func countDescendants(todoList *TodoList, id int) int {
+	count := 0
+	for _, subtask := range todoList.Subtasks(id) {
+		count += 1 + countDescendants(todoList, subtask.ID)
+	}
+	return count
+}
+
+// formatTodoTree renders the given todos as the lines of a tree, with each subtask indented
+// below its parent, parents showing their progress (e.g., "(2/5 done)"), and blocked todos
+// showing their open dependencies. The todos keep their order among siblings. A subtask whose
+// parent is not among the todos (e.g., because it was filtered out) is shown at the top level.
+func formatTodoTree(tl *TodoList, todos []Todo, verbose bool) []string {
+	included := make(map[int]bool, len(todos))
+	for _, todo := range todos {
+		included[todo.ID] = true
+	}
+
+	now := time.Now()
+	lines := []string{}
+	var addTodo func(todo Todo, depth int)
+	addTodo = func(todo Todo, depth int) {
+		line := formatTodo(todo)
+		if done, total := tl.Progress(todo.ID); total > 0 {
+			line += fmt.Sprintf(" (%d/%d done)", done, total)
+		}
+		if tl.IsBlocked(todo) {
+			line += fmt.Sprintf(" (Blocked by: %s)", formatTodoIDs(tl.OpenDependencies(todo)))
+		}
+		if verbose && todo.IsOpen() {
+			line += fmt.Sprintf(" (Urgency: %s)", formatUrgency(todo.Urgency(now)))
+		}
+		if depth > 0 {
+			line = strings.Repeat("    ", depth-1) + "  └ " + line
+		}
+		lines = append(lines, line)
+		for _, child := range todos {
+			if child.ParentID == todo.ID {
+				addTodo(child, depth+1)
+			}
+		}
+	}
+	for _, todo := range todos {
+		if !included[todo.ParentID] {
+			addTodo(todo, 0)
+		}
+	}
+	return lines
+}
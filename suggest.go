@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., the suggestion message)
+	"time" // Package for weekdays and dates
+)
+
+// suggestDueDates enables due date suggestions when adding todos without a due date.
+// It is set from the suggest_due_dates config setting, and is off by default.
+var suggestDueDates bool
+
+// offerDueDateSuggestion suggests a due date for a todo that was just added without one,
+// if suggestions are enabled, and sets it if the user accepts.
+func offerDueDateSuggestion(todoList *TodoList, todo Todo) {
+	if !suggestDueDates || outputJSON || todo.DueDate != nil {
+		return
+	}
+	suggestion, ok := todoList.SuggestDueDate(todo.Task, todo.Tags, time.Now())
+	if !ok {
+		return
+	}
+	PrintUserMessage(fmt.Sprintf("💡 You usually finish todos like %s on %ss (%d of %d).", suggestion.Basis, suggestion.Weekday, suggestion.Count, suggestion.Total))
+	if getConfirmation(fmt.Sprintf("Set the due date of todo #%d to %s?", todo.ID, formatDate(suggestion.DueDate)+suggestion.DueDate.Format(" (Monday)"))) {
+		todoList.SetDueDate(todo.ID, &suggestion.DueDate)
+		PrintUserMessage(fmt.Sprintf("📅 Todo #%d is now due %s.", todo.ID, formatDate(suggestion.DueDate)))
+	}
+}
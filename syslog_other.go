@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"fmt"     // Package for formatted I/O (e.g., the unsupported platform error)
+	"runtime" // Package for naming the platform in the error
+)
+
+// openSyslog reports that there is no system log to write to. The Windows event log is not
+// supported, so the daemon logs to its log file there.
+func openSyslog(tag string) (logSink, error) {
+	return nil, fmt.Errorf("syslog is not available on %s", runtime.GOOS)
+}
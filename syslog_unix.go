@@ -0,0 +1,37 @@
+//go:build !windows && !plan9
+
+package main
+
+import "log/syslog" // Package for writing to the system log
+
+// syslogSink writes log entries to the system log (e.g., the systemd journal or /var/log/syslog).
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// openSyslog connects to the system log, tagging the entries with tag.
+func openSyslog(tag string) (logSink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return syslogSink{writer: writer}, nil
+}
+
+// WriteEntry writes an entry with the syslog severity of its level.
+func (s syslogSink) WriteEntry(level LogLevel, message string) error {
+	switch level {
+	case LevelDebug:
+		return s.writer.Debug(message)
+	case LevelWarn:
+		return s.writer.Warning(message)
+	case LevelError:
+		return s.writer.Err(message)
+	}
+	return s.writer.Info(message)
+}
+
+// Close disconnects from the system log.
+func (s syslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,9 @@
+package main
+
+import "syscall"
+
+// ioctl requests for reading and writing terminal attributes on Linux.
+const (
+	ioctlReadTermios  = syscall.TCGETS
+	ioctlWriteTermios = syscall.TCSETS
+)
@@ -0,0 +1,33 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package main
+
+import "errors"
+
+// terminalState is unused on platforms without raw terminal support.
+type terminalState struct{}
+
+// isTerminal always reports false, so the line editor falls back to plain input.
+func isTerminal(fd int) bool {
+	return false
+}
+
+// terminalHeight always returns 0, as the terminal size is unknown on this platform.
+func terminalHeight(fd int) int {
+	return 0
+}
+
+// terminalWidth always returns 0, as the terminal size is unknown on this platform.
+func terminalWidth(fd int) int {
+	return 0
+}
+
+// makeRaw is not supported on this platform.
+func makeRaw(fd int) (*terminalState, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// restoreTerminal is a no-op on this platform.
+func restoreTerminal(fd int, state *terminalState) error {
+	return nil
+}
@@ -0,0 +1,93 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"syscall" // Package for low-level terminal ioctl calls
+	"unsafe"  // Package for passing the termios struct to ioctl
+)
+
+// terminalState holds the terminal attributes to restore after raw mode.
+type terminalState struct {
+	termios syscall.Termios
+}
+
+// getTermios reads the terminal attributes of the given file descriptor.
+func getTermios(fd int) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlReadTermios, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+// setTermios applies terminal attributes to the given file descriptor.
+func setTermios(fd int, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlWriteTermios, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal reports whether the given file descriptor refers to a terminal.
+func isTerminal(fd int) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// winsize mirrors the kernel's struct winsize, as filled in by the TIOCGWINSZ ioctl.
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// terminalSize returns the size of the terminal the given file descriptor refers to,
+// or zeros if it is not a terminal or the size is unknown.
+func terminalSize(fd int) winsize {
+	size := winsize{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return winsize{}
+	}
+	return size
+}
+
+// terminalHeight returns the number of rows of the terminal the given file descriptor refers to,
+// or 0 if it is not a terminal or the size is unknown.
+func terminalHeight(fd int) int {
+	return int(terminalSize(fd).rows)
+}
+
+// terminalWidth returns the number of columns of the terminal the given file descriptor refers to,
+// or 0 if it is not a terminal or the size is unknown.
+func terminalWidth(fd int) int {
+	return int(terminalSize(fd).cols)
+}
+
+// makeRaw puts the terminal into raw mode, so keystrokes are delivered one at a time
+// without echo or signal handling, and returns the previous state for restoreTerminal.
+// Output processing is left enabled, so newlines are still translated as usual.
+func makeRaw(fd int) (*terminalState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: *termios}
+
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restoreTerminal restores the terminal attributes saved by makeRaw.
+func restoreTerminal(fd int, state *terminalState) error {
+	return setTermios(fd, &state.termios)
+}
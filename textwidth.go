@@ -0,0 +1,181 @@
+package main
+
+import (
+	"strings" // Package for building truncated text
+	"unicode" // Package for classifying combining marks and control characters
+)
+
+// ellipsis marks text cut short by truncateToWidth.
+const ellipsis = "…"
+
+// wideRanges are the ranges of runes that terminals display two columns wide:
+// East Asian wide and fullwidth characters, and emoji.
+var wideRanges = []struct{ first, last rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initial consonants
+	{0x231A, 0x231B},   // ⌚ and ⌛
+	{0x23E9, 0x23EC},   // ⏩ to ⏬
+	{0x23F0, 0x23F0},   // ⏰
+	{0x23F3, 0x23F3},   // ⏳
+	{0x25FD, 0x25FE},   // Medium small squares
+	{0x2614, 0x2615},   // ☔ and ☕
+	{0x2648, 0x2653},   // Zodiac signs
+	{0x267F, 0x267F},   // ♿
+	{0x2693, 0x2693},   // ⚓
+	{0x26A1, 0x26A1},   // ⚡
+	{0x26AA, 0x26AB},   // Medium circles
+	{0x26BD, 0x26BE},   // ⚽ and ⚾
+	{0x26C4, 0x26C5},   // ⛄ and ⛅
+	{0x26CE, 0x26CE},   // ⛎
+	{0x26D4, 0x26D4},   // ⛔
+	{0x26EA, 0x26EA},   // ⛪
+	{0x26F2, 0x26F3},   // ⛲ and ⛳
+	{0x26F5, 0x26F5},   // ⛵
+	{0x26FA, 0x26FA},   // ⛺
+	{0x26FD, 0x26FD},   // ⛽
+	{0x2705, 0x2705},   // ✅
+	{0x270A, 0x270B},   // ✊ and ✋
+	{0x2728, 0x2728},   // ✨
+	{0x274C, 0x274C},   // ❌
+	{0x274E, 0x274E},   // ❎
+	{0x2753, 0x2755},   // ❓ to ❕
+	{0x2757, 0x2757},   // ❗
+	{0x2795, 0x2797},   // ➕ to ➗
+	{0x27B0, 0x27B0},   // ➰
+	{0x27BF, 0x27BF},   // ➿
+	{0x2B1B, 0x2B1C},   // Large squares
+	{0x2B50, 0x2B50},   // ⭐
+	{0x2B55, 0x2B55},   // ⭕
+	{0x2E80, 0x303E},   // CJK radicals, symbols, and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, and CJK compatibility
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi syllables and radicals
+	{0xA960, 0xA97F},   // Hangul Jamo extended A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // Vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms and small form variants
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F004, 0x1F004}, // 🀄
+	{0x1F0CF, 0x1F0CF}, // 🃏
+	{0x1F18E, 0x1F18E}, // 🆎
+	{0x1F191, 0x1F19A}, // Squared words (e.g., 🆗)
+	{0x1F200, 0x1F2FF}, // Enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F680, 0x1F6FF}, // Transport and map symbols
+	{0x1F7E0, 0x1F7EB}, // Large colored circles and squares
+	{0x1F90C, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // Symbols and pictographs extended A
+	{0x20000, 0x3FFFD}, // CJK unified ideographs extensions B and beyond
+}
+
+// runeWidth returns the number of terminal columns r takes up: 0 for combining marks,
+// control characters, and the invisible characters of emoji sequences, 2 for wide
+// characters such as CJK ideographs and emoji, and 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || r == 0x200B || r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F):
+		return 0 // Zero-width space, zero-width joiner, and variation selectors
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.first && r <= wide.last {
+			return 2
+		}
+	}
+	return 1
+}
+
+// textWidth returns the number of terminal columns text takes up on one line. ANSI escape
+// sequences (e.g., colors) take up no space. A symbol followed by the emoji variation selector
+// is shown as a two-column emoji (e.g., "⚠️"), and the emoji joined to the previous one by a
+// zero-width joiner are shown within its columns (e.g., "👩‍💻").
+func textWidth(text string) int {
+	width := 0
+	for _, span := range textSpans(text) {
+		width += span.width
+	}
+	return width
+}
+
+// truncateToWidth shortens text to at most width terminal columns, ending it with an ellipsis
+// if anything was cut. ANSI escape sequences are kept, and reset at the end if any were cut short.
+func truncateToWidth(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if textWidth(text) <= width {
+		return text
+	}
+	var result strings.Builder
+	used := 0
+	escaped := false
+	limit := width - textWidth(ellipsis)
+	for _, span := range textSpans(text) {
+		if span.escape {
+			result.WriteString(span.text)
+			escaped = true
+			continue
+		}
+		if used+span.width > limit {
+			break
+		}
+		result.WriteString(span.text)
+		used += span.width
+	}
+	result.WriteString(ellipsis)
+	if escaped {
+		result.WriteString("\x1b[0m")
+	}
+	return result.String()
+}
+
+// textSpan is a piece of text that is displayed as a unit: an ANSI escape sequence,
+// or a character with the combining marks and joined emoji that follow it.
+type textSpan struct {
+	text   string
+	width  int
+	escape bool
+}
+
+// textSpans splits text into the units it is displayed as, with the width of each.
+func textSpans(text string) []textSpan {
+	spans := []textSpan{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == keyEscape && i+1 < len(runes) && runes[i+1] == '[' {
+			// A CSI sequence ends with a letter, e.g., "\x1b[1;31m".
+			end := i + 2
+			for end < len(runes) && !unicode.IsLetter(runes[end]) {
+				end++
+			}
+			end = min(end, len(runes)-1)
+			spans = append(spans, textSpan{text: string(runes[i : end+1]), escape: true})
+			i = end
+			continue
+		}
+
+		start := i
+		width := runeWidth(runes[i])
+		for i+1 < len(runes) {
+			next := runes[i+1]
+			if next == 0xFE0F {
+				width = max(width, 2) // Emoji presentation of the preceding symbol.
+			} else if next == 0x200D && i+2 < len(runes) {
+				i++ // The joined emoji is drawn in the same columns.
+			} else if runeWidth(next) != 0 || next == keyEscape {
+				break
+			}
+			i++
+		}
+		spans = append(spans, textSpan{text: string(runes[start : i+1]), width: width})
+	}
+	return spans
+}
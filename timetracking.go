@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., error messages)
+	"time" // Package for measuring tracked time
+)
+
+// formatDuration formats a duration in hours and minutes (e.g., "1h30m" or "45m").
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"           // Package for formatted I/O (e.g., validation errors)
+	"todo/pkg/todo" // Package for parsing default due dates
+)
+
+// TodoDefaults are the values that new todos get when they are added without them,
+// e.g., {"priority": "medium", "tags": ["inbox"], "due": "+7d"} for quick adds.
+type TodoDefaults struct {
+	Priority string   `json:"priority"` // Priority of todos added without -p; medium if empty.
+	Tags     []string `json:"tags"`     // Tags of todos added without -t.
+	Due      string   `json:"due"`      // Due date of todos added without -d, usually an offset like "+7d"; none if empty.
+}
+
+// todoDefaults are the defaults of new todos used by the add command.
+// They are set from the defaults config setting.
+var todoDefaults TodoDefaults
+
+// fill returns the arguments of an add command with the defaults in place of the options that were not given.
+// The due date stays a date expression, so that an offset counts from the day the todo is added.
+func (d TodoDefaults) fill(args addArgs) addArgs {
+	if args.Priority == "" {
+		args.Priority = d.Priority
+	}
+	if args.Priority == "" {
+		args.Priority = string(PriorityMedium) // Not an invalid priority to warn about.
+	}
+	if len(args.Tags) == 0 && len(d.Tags) > 0 {
+		args.Tags = append([]string{}, d.Tags...) // Todos must not share the tags of the config.
+	}
+	if args.DueDate == "" {
+		args.DueDate = d.Due
+	}
+	return args
+}
+
+// validate reports a default priority or due date that the add command would reject.
+func (d TodoDefaults) validate() error {
+	if d.Priority != "" && PriorityLevel(d.Priority).Canonical() == "" {
+		return fmt.Errorf("defaults.priority must be high, medium, or low")
+	}
+	if _, err := todo.ParseDueDate(d.Due); d.Due != "" && err != nil {
+		return fmt.Errorf("defaults.due must be a date or an offset like +7d")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"  // Package for formatted I/O (e.g., error messages)
+	"io"   // Package for reading batch files
+	"sync" // Package for guarding the transaction, which auto-save checks concurrently
+)
+
+// transactionSnapshot is a copy of the todo list taken when a transaction began, or nil outside
+// a transaction. While a transaction is open, auto-save is paused, so that its changes reach
+// the data file together on commit, or not at all on rollback. It is guarded by transactionMu.
+var (
+	transactionSnapshot *TodoList
+	transactionMu       sync.Mutex
+)
+
+// dryRun is set from the -dry-run flag. Committing a transaction then saves nothing.
+var dryRun bool
+
+// commandFailures counts the commands that reported an error, so that a -transaction batch
+// can stop at the first failing command and roll back.
+var commandFailures int
+
+// stopOnFailure makes interactive mode stop at the first command that fails.
+// It is set while running a -transaction batch.
+var stopOnFailure bool
+
+// runAtomically runs a command against a working copy of the todo list, and applies its changes
+// only if it succeeds. If it reports an error, none of its changes are applied, so that a bulk
+// operation failing halfway (e.g., select complete with a missing ID) never leaves the list, or
+// the data file auto-save writes it to, half-changed. The undo actions it recorded are dropped too.
+//
+// Applied changes are recorded for undo with the todos they changed, unless the command recorded
+// its own undo action. description names the command, e.g., "priority 3 high".
+// Returns what run returns.
+func runAtomically(todoList *TodoList, description string, run func(working *TodoList) bool) bool {
+	working := todoList.Clone()
+	failuresBefore, undoBefore, pushesBefore := commandFailures, undoStack, undoPushes
+	keepGoing := run(working)
+	diff := DiffTodoLists(todoList, working)
+	if commandFailures > failuresBefore {
+		undoStack = undoBefore
+		if !diff.IsEmpty() && !outputJSON { // Keep JSON output valid.
+			PrintUserMessage(fmt.Sprintf("⏪ The command failed, so its changes to %s were rolled back.", pluralize(diff.changeCount(), "todo", "todos")))
+		}
+		return keepGoing
+	}
+	todoListMu.Lock()
+	before := *todoList // The working copy replaces the list, which stays as it was.
+	*todoList = *working
+	todoListMu.Unlock()
+	if undoPushes == pushesBefore && !diff.IsEmpty() {
+		pushUndo(lastAction{Type: ActionChange, Description: fmt.Sprintf("%q", description), Change: newListChange(&before, working)})
+	}
+	if !inTransaction() { // The changes of a transaction are posted when it is committed.
+		notifyWebhooks(diff)
+	}
+	return keepGoing
+}
+
+// inTransaction reports whether a transaction is open.
+func inTransaction() bool {
+	transactionMu.Lock()
+	defer transactionMu.Unlock()
+	return transactionSnapshot != nil
+}
+
+// beginTransaction opens a transaction on the todo list.
+// Returns an error if a transaction is already open.
+func beginTransaction(todoList *TodoList) error {
+	transactionMu.Lock()
+	defer transactionMu.Unlock()
+	if transactionSnapshot != nil {
+		return fmt.Errorf("a transaction is already open: commit or roll it back first")
+	}
+	transactionSnapshot = todoList.Clone()
+	return nil
+}
+
+// commitTransaction saves the todo list to dataFile and closes the transaction, returning the
+// changes it made. If saving fails, the transaction stays open, so it can be committed again
+// or rolled back. Returns an error if no transaction is open or the list cannot be saved.
+func commitTransaction(todoList *TodoList, dataFile string) (TodoListDiff, error) {
+	transactionMu.Lock()
+	defer transactionMu.Unlock()
+	if transactionSnapshot == nil {
+		return TodoListDiff{}, fmt.Errorf("no transaction is open: start one with begin")
+	}
+	if !dryRun {
+		if err := saveTodoList(todoList, dataFile); err != nil {
+			return TodoListDiff{}, err
+		}
+	}
+	diff := DiffTodoLists(transactionSnapshot, todoList)
+	transactionSnapshot = nil
+	notifyWebhooks(diff)
+	return diff, nil
+}
+
+// rollbackTransaction restores the todo list to its state when the transaction began and
+// closes the transaction, returning the changes that were discarded.
+// Returns an error if no transaction is open.
+func rollbackTransaction(todoList *TodoList) (TodoListDiff, error) {
+	transactionMu.Lock()
+	defer transactionMu.Unlock()
+	if transactionSnapshot == nil {
+		return TodoListDiff{}, fmt.Errorf("no transaction is open")
+	}
+	diff := DiffTodoLists(transactionSnapshot, todoList)
+	*todoList = *transactionSnapshot
+	transactionSnapshot = nil
+	undoStack = nil // The recorded actions may have been rolled back.
+	return diff, nil
+}
+
+// runTransactionBatch runs the commands read from in (e.g., a batch file piped to stdin) as a single
+// transaction: their changes are saved together if all of them succeed, and rolled back if one fails.
+// The batch stops at the first failing command.
+func runTransactionBatch(todoList *TodoList, config Config, in io.Reader) {
+	if err := beginTransaction(todoList); err != nil {
+		logger.Error(err, "Failed to begin the batch transaction")
+		printError(err)
+		return
+	}
+	stopOnFailure = true
+	defer func() { stopOnFailure = false }()
+	failuresBefore := commandFailures
+	runInteractiveMode(todoList, config, in)
+	if !inTransaction() {
+		return // The batch committed or rolled back explicitly.
+	}
+	if commandFailures > failuresBefore {
+		diff, _ := rollbackTransaction(todoList)
+		printTransactionEnded(diff, false)
+		return
+	}
+	diff, err := commitTransaction(todoList, config.DataFile)
+	if err != nil {
+		logger.Error(err, "Failed to commit the batch transaction")
+		printError(err)
+		rollbackTransaction(todoList) // Nothing was saved, so don't save it on exit either.
+		return
+	}
+	printTransactionEnded(diff, true)
+}
+
+// rollbackOpenTransaction rolls back a transaction that is still open when the program exits,
+// so that its changes are not saved on exit without a commit.
+func rollbackOpenTransaction(todoList *TodoList) {
+	if !inTransaction() {
+		return
+	}
+	todoListMu.Lock() // Runs on a shutdown signal too, while auto-save may be saving the list.
+	diff, _ := rollbackTransaction(todoList)
+	todoListMu.Unlock()
+	PrintUserMessage(fmt.Sprintf("⚠️ The transaction was not committed, so it was rolled back (%s changed).", pluralize(diff.changeCount(), "todo", "todos")))
+}
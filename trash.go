@@ -0,0 +1,5 @@
+package main
+
+// trashRetentionDays is the number of days deleted todos are kept in the trash, or 0 to keep them
+// until the trash is emptied. It is set from the trash_retention_days config setting.
+var trashRetentionDays = 30
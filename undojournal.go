@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256" // Package for fingerprinting the data file the journal belongs to
+	"encoding/hex"  // Package for formatting the fingerprint
+	"encoding/json" // Package for encoding the journal
+	"fmt"           // Package for formatted I/O (e.g., error messages)
+	"os"            // Package for reading and writing the journal file
+	"reflect"       // Package for finding the todos a command changed
+)
+
+// undoJournal is the undo stack as saved next to the data file, so that undo also works in
+// single-command mode and after a restart. It only applies to the data file it was saved with:
+// if the file changed since (e.g., it was edited by hand), the journal is discarded.
+type undoJournal struct {
+	DataChecksum string       `json:"data_checksum"` // SHA-256 of the data file the actions lead up to.
+	Actions      []lastAction `json:"actions"`       // The undo stack, the most recent action last.
+}
+
+// listChange is what a command changed in the todo list, recorded so that it can be undone
+// without keeping a copy of the whole list: the todos it changed or removed, as they were and
+// where they were, the todos it added, and the trash if it changed.
+type listChange struct {
+	Before       []Todo        `json:"before,omitempty"`        // The changed and removed todos, as they were.
+	Positions    []int         `json:"positions,omitempty"`     // Their positions in the list, in the same order.
+	Added        []int         `json:"added,omitempty"`         // The IDs of the todos that were added.
+	NextID       int           `json:"next_id"`                 // The next ID as it was.
+	TrashChanged bool          `json:"trash_changed,omitempty"` // Whether the trash changed, so Trash holds it as it was.
+	Trash        []TrashedTodo `json:"trash,omitempty"`
+}
+
+// newListChange returns the change from before to after. The change shares the todos of before,
+// which must not be changed afterwards (e.g., the list a working copy replaced).
+func newListChange(before, after *TodoList) *listChange {
+	change := &listChange{NextID: before.NextID}
+	afterByID := make(map[int]Todo, len(after.Todos))
+	for _, todo := range after.Todos {
+		afterByID[todo.ID] = todo
+	}
+	beforeIDs := make(map[int]bool, len(before.Todos))
+	for i, old := range before.Todos {
+		beforeIDs[old.ID] = true
+		if updated, ok := afterByID[old.ID]; !ok || !reflect.DeepEqual(old, updated) {
+			change.Before = append(change.Before, old)
+			change.Positions = append(change.Positions, i)
+		}
+	}
+	for _, todo := range after.Todos {
+		if !beforeIDs[todo.ID] {
+			change.Added = append(change.Added, todo.ID)
+		}
+	}
+	if !reflect.DeepEqual(before.Trash, after.Trash) {
+		change.TrashChanged = true
+		change.Trash = before.Trash
+	}
+	return change
+}
+
+// undo puts the todo list back as it was before the change.
+func (c *listChange) undo(todoList *TodoList) {
+	added := make(map[int]bool, len(c.Added))
+	for _, id := range c.Added {
+		added[id] = true
+	}
+	beforeByID := make(map[int]Todo, len(c.Before))
+	for _, todo := range c.Before {
+		beforeByID[todo.ID] = todo
+	}
+	todos := []Todo{}
+	for _, todo := range todoList.Todos {
+		if added[todo.ID] {
+			continue
+		}
+		if old, ok := beforeByID[todo.ID]; ok {
+			todo = old
+			delete(beforeByID, todo.ID)
+		}
+		todos = append(todos, todo)
+	}
+	todoList.Todos = todos
+
+	// The todos that were removed go back to their places.
+	removed, positions := []Todo{}, []int{}
+	for i, todo := range c.Before {
+		if _, ok := beforeByID[todo.ID]; ok {
+			removed = append(removed, todo)
+			positions = append(positions, c.Positions[i])
+		}
+	}
+	todoList.InsertAt(removed, positions)
+	todoList.NextID = c.NextID
+	if c.TrashChanged {
+		todoList.Trash = c.Trash
+	}
+}
+
+// undoJournalPath returns the path of the undo journal of dataFile.
+func undoJournalPath(dataFile string) string {
+	return dataFile + ".undo"
+}
+
+// fileChecksum returns the SHA-256 of the contents of a file, in hex.
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// saveUndoJournal writes the undo stack to the journal of dataFile, which must have just been saved.
+// The journal is removed when there is nothing to undo.
+func saveUndoJournal(dataFile string) error {
+	path := undoJournalPath(dataFile)
+	if len(undoStack) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the undo journal: %w", err)
+		}
+		return nil
+	}
+	checksum, err := fileChecksum(dataFile)
+	if err != nil {
+		return fmt.Errorf("failed to save the undo journal: %w", err)
+	}
+	data, err := json.Marshal(undoJournal{DataChecksum: checksum, Actions: undoStack})
+	if err != nil {
+		return fmt.Errorf("failed to encode the undo journal: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save the undo journal: %w", err)
+	}
+	return nil
+}
+
+// loadUndoJournal restores the undo stack from the journal of dataFile. Without a journal, or with
+// one saved for a different version of the data file, the undo stack is left empty.
+func loadUndoJournal(dataFile string) error {
+	undoStack = nil
+	data, err := os.ReadFile(undoJournalPath(dataFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the undo journal: %w", err)
+	}
+	var journal undoJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return fmt.Errorf("failed to parse the undo journal: %w", err)
+	}
+	if checksum, err := fileChecksum(dataFile); err != nil || checksum != journal.DataChecksum {
+		logger.Info("Discarded the undo journal, since the data file changed after it was saved.")
+		return nil
+	}
+	undoStack = journal.Actions
+	return nil
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"fmt" // Package for formatting urgency scores
+)
+
+// formatUrgency formats an urgency score with one decimal, as shown in verbose list output.
+func formatUrgency(score float64) string {
+	return fmt.Sprintf("%.1f", score)
+}
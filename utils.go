@@ -1,101 +1,138 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"log" // Package for logging functionality
-	"os"  // Package for operating system functionalities, used here for stderr
+	"os" // Package for operating system functionalities, used here for stderr
 	"time"
+	"todo/pkg/todo" // Package for the default urgency weights
 )
 
-// Duration is a custom type that allows time.Duration to be marshaled/unmarshaled
-// from JSON as a human-readable string (e.g., "30s", "1m").
-type Duration time.Duration
-
-// UnmarshalText implements the encoding.TextUnmarshaler interface.
-func (d *Duration) UnmarshalText(text []byte) error {
-	parsed, err := time.ParseDuration(string(text))
-	if err != nil {
-		return err
-	}
-	*d = Duration(parsed)
-	return nil
-}
-
-// MarshalText implements the encoding.TextMarshaler interface.
-func (d Duration) MarshalText() ([]byte, error) {
-	return []byte(time.Duration(d).String()), nil
-}
-
-// SetupLogger configures the application-wide logger.
-// It sets the output destination to standard error (os.Stderr) and defines
-// the logging flags to include date, time, and source file information.
-// Optionally, logs can also be directed to a specified file.
-func SetupLogger(logFilePath string) {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-
-	if logFilePath != "" {
-		file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			log.Printf("ERROR: Failed to open log file %s: %v\n", logFilePath, err)
-			log.SetOutput(os.Stderr) // Fallback to stderr if file logging fails
-			return
-		}
-		// Create a multi-writer to write to both stderr and the file.
-		mw := io.MultiWriter(os.Stderr, file)
-		log.SetOutput(mw)
-	} else {
-		log.SetOutput(os.Stderr) // Default to stderr
-	}
-}
-
-// LogError logs an error message with a specified context.
-// It takes an error object and a descriptive message. If the error is not nil,
-// it prints a formatted error message including the custom message and the error details.
-func LogError(err error, message string) {
-	if err != nil {
-		// Use Printf to format the error message, including the custom message and the error itself.
-		log.Printf("ERROR: %s: %v\n", message, err)
-	}
-}
-
-// LogInfo logs an informational message.
-// It takes a descriptive message and prints it as an informational log entry.
-func LogInfo(message string) {
-	// Use Printf to format the informational message.
-	log.Printf("INFO: %s\n", message)
-}
-
-// LogWarning logs a warning message.
-// It takes a descriptive message and prints it as a warning log entry.
-func LogWarning(message string) {
-	log.Printf("WARNING: %s\n", message)
-}
-
 // PrintUserMessage prints messages directly to standard output, without any logger prefixes.
 // This is intended for direct user feedback in the CLI.
 func PrintUserMessage(message string) {
+	if minimalOutput || !showEmoji {
+		message = stripEmoji(message)
+	}
 	fmt.Println(message)
 }
 
 // Config holds the application's configurable settings.
 type Config struct {
-	DataFile         string   `json:"data_file"`
-	AutoSaveInterval Duration `json:"auto_save_interval"` // Use custom Duration type
-	LogFilePath      string   `json:"log_file_path"`
+	DataFile               string              `json:"data_file"`
+	AutoSaveInterval       Duration            `json:"auto_save_interval"` // Use custom Duration type
+	LogFilePath            string              `json:"log_file_path"`
+	LogLevel               string              `json:"log_level"`                // Lowest level of the logged messages: "debug", "info", "warn", or "error"
+	LogRotation            LogRotation         `json:"log_rotation"`             // When the log file is rotated, and how many rotated files are kept
+	DaemonLog              string              `json:"daemon_log"`               // Where the reminder daemon logs to: "file" (log_file_path) or "syslog"
+	AssumeYes              bool                `json:"assume_yes"`               // Skip confirmation prompts for destructive actions
+	Aliases                map[string]string   `json:"aliases"`                  // User-defined command aliases (e.g., "a": "add -p high")
+	HistoryFile            string              `json:"history_file"`             // File where interactive mode command history is kept
+	Prompt                 string              `json:"prompt"`                   // Template for the interactive mode prompt
+	AttachmentsDir         string              `json:"attachments_dir"`          // Directory where files attached to todos are stored
+	Color                  string              `json:"color"`                    // When to color the output: "auto", "always", or "never"
+	Theme                  string              `json:"theme"`                    // Name of the color theme, built-in or from Themes
+	Themes                 map[string]Theme    `json:"themes"`                   // User-defined color themes
+	ChronicSnoozeThreshold int                 `json:"chronic_snooze_threshold"` // Number of snoozes after which a todo is reported as chronically postponed
+	OutputProfile          string              `json:"output_profile"`           // "default", or "minimal" for plain output without emoji, colors, counts, and banners
+	ConfirmationWord       string              `json:"confirmation_word"`        // Word to type to confirm irreversible operations; defaults to the list name
+	SuggestDueDates        bool                `json:"suggest_due_dates"`        // Suggest due dates from when similar todos were completed
+	WIPLimits              map[string]int      `json:"wip_limits"`               // Maximum number of open todos per priority (e.g., "high": 5)
+	WIPLimitMode           string              `json:"wip_limit_mode"`           // "warn" or "block" when a WIP limit would be exceeded
+	TrashRetentionDays     int                 `json:"trash_retention_days"`     // Days deleted todos are kept in the trash; 0 keeps them until the trash is emptied
+	UrgencyWeights         UrgencyWeights      `json:"urgency_weights"`          // Coefficients of the urgency score used by -sort-by urgency
+	Lists                  map[string]string   `json:"lists"`                    // Other todo lists shown by the dashboard, by name (e.g., "work": "work.json")
+	RecurrenceFrom         string              `json:"recurrence_from"`          // What the next occurrence of a recurring todo is scheduled from: "schedule" or "completion"
+	RecurrenceOverdue      string              `json:"recurrence_overdue"`       // Whether missed occurrences of a recurring todo "pile-up" or "collapse"
+	ReminderLeadTimes      map[string]Duration `json:"reminder_lead_times"`      // How long before a todo is due the daemon reminds of it, per priority or "default"
+	DaemonInterval         Duration            `json:"daemon_interval"`          // How often the reminder daemon checks for due todos
+	SMTP                   SMTPConfig          `json:"smtp"`                     // Mail server and addresses for the email digest
+	DigestTime             string              `json:"digest_time"`              // Time of day ("HH:MM") at which the daemon emails the digest; none if empty
+	Defaults               TodoDefaults        `json:"defaults"`                 // Priority, tags, and due date of todos added without them
+	List                   ListPreferences     `json:"list"`                     // Sort order and filters of the list command when their flags are not given
+	DateFormat             string              `json:"date_format"`              // Go time layout dates are shown in (e.g., "Jan 2, 2006")
+	Emoji                  bool                `json:"emoji"`                    // Whether messages show emoji
+	Profiles               map[string]Profile  `json:"profiles"`                 // Named contexts with their own data file, log file, and defaults
+	Profile                string              `json:"profile"`                  // Name of the profile to use; none if empty
+	AuditLog               bool                `json:"audit_log"`                // Record the commands run in <data file>.audit, for the history command
+	Webhooks               []Webhook           `json:"webhooks"`                 // URLs that receive todo events (added, completed, edited, deleted) as JSON
 }
 
 // DefaultConfig returns a new Config with default values.
 func DefaultConfig() Config {
 	return Config{
-		DataFile:         "todos.json",
-		AutoSaveInterval: Duration(1 * time.Minute), // Cast to custom Duration type
-		LogFilePath:      "",                        // Default to no log file (stdout/stderr only)
+		DataFile:               "todos.json",
+		AutoSaveInterval:       Duration(1 * time.Minute),                                // Cast to custom Duration type
+		LogFilePath:            "",                                                       // Default to no log file (stdout/stderr only)
+		LogLevel:               "info",                                                   // Log everything but debug messages
+		LogRotation:            LogRotation{MaxSizeMB: 10, MaxFiles: 3},                  // Keep up to 40 MB of logs, whatever their age
+		DaemonLog:              daemonLogFile,                                            // The daemon logs like any other command
+		AssumeYes:              false,                                                    // Always ask before destructive actions
+		Aliases:                map[string]string{},                                      // No aliases by default
+		HistoryFile:            ".todo_history",                                          // Persist interactive history in the working directory
+		Prompt:                 defaultPrompt,                                            // Plain "> " prompt
+		AttachmentsDir:         "attachments",                                            // Store attachments next to the data file in the working directory
+		Color:                  "auto",                                                   // Color only when writing to a terminal
+		Theme:                  "default",                                                // Built-in default theme
+		Themes:                 map[string]Theme{},                                       // No custom themes by default
+		ChronicSnoozeThreshold: 3,                                                        // Report todos postponed three or more times
+		WIPLimits:              map[string]int{},                                         // No WIP limits by default
+		WIPLimitMode:           "warn",                                                   // Warn rather than refuse when a limit is exceeded
+		TrashRetentionDays:     30,                                                       // Keep deleted todos restorable for a month
+		UrgencyWeights:         todo.DefaultUrgencyWeights(),                             // Taskwarrior's coefficients
+		Lists:                  map[string]string{},                                      // Only the active list on the dashboard
+		RecurrenceFrom:         RecurFromSchedule,                                        // Keep recurring todos on their schedule
+		RecurrenceOverdue:      RecurOverduePileUp,                                       // Bring back missed occurrences one after the other
+		ReminderLeadTimes:      map[string]Duration{"default": Duration(24 * time.Hour)}, // Remind a day before todos are due
+		DaemonInterval:         Duration(time.Minute),                                    // Check for due todos every minute
+		SMTP:                   SMTPConfig{Port: 587, To: []string{}},                    // No mail server; email-digest reports what to set
+		DigestTime:             "",                                                       // The daemon sends no digest
+		Defaults:               TodoDefaults{Tags: []string{}},                           // Medium priority, no tags, and no due date
+		List:                   ListPreferences{},                                        // List all todos by ID
+		DateFormat:             defaultDateFormat,                                        // YYYY-MM-DD
+		Emoji:                  true,                                                     // Show emoji
+		Profiles:               map[string]Profile{},                                     // No profiles by default
+		Profile:                "",                                                       // Use the settings above
+		AuditLog:               true,                                                     // Keep a record of the commands run
+		Webhooks:               []Webhook{},                                              // No webhooks by default
+	}
+}
+
+// Environment variables that override settings of the config file, for containers and CI, where
+// editing the file is awkward. Command-line flags (e.g., -config or -data-file) override them in turn.
+const (
+	envConfigFile       = "TODO_CONFIG"            // Path to the config file, instead of config.json.
+	envDataFile         = "TODO_DATA_FILE"         // Overrides data_file.
+	envLogFile          = "TODO_LOG_FILE"          // Overrides log_file_path.
+	envAutoSaveInterval = "TODO_AUTOSAVE_INTERVAL" // Overrides auto_save_interval (e.g., "30s").
+	envLogLevel         = "TODO_LOG_LEVEL"         // Overrides log_level (e.g., "debug").
+)
+
+// applyEnvOverrides overrides config settings with the environment variables that are set,
+// as read by getenv (os.Getenv). Returns an error if a value is invalid.
+func applyEnvOverrides(config *Config, getenv func(string) string) error {
+	if dataFile := getenv(envDataFile); dataFile != "" {
+		config.DataFile = dataFile
+	}
+	if logFile := getenv(envLogFile); logFile != "" {
+		config.LogFilePath = logFile
+	}
+	if interval := getenv(envAutoSaveInterval); interval != "" {
+		var parsed Duration
+		if err := parsed.UnmarshalText([]byte(interval)); err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid %s %q: use a positive duration like 30s or 5m", envAutoSaveInterval, interval)
+		}
+		config.AutoSaveInterval = parsed
+	}
+	if level := getenv(envLogLevel); level != "" {
+		if _, err := parseLogLevel(level); err != nil {
+			return fmt.Errorf("invalid %s: %w", envLogLevel, err)
+		}
+		config.LogLevel = level
 	}
+	return nil
 }
 
-// LoadConfig loads configuration from a JSON file. If the file does not exist,
+// LoadConfig loads configuration from a JSON, YAML, or TOML file (see configFormat). If the file does not exist,
 // it creates a default configuration file.
 func LoadConfig(configPath string) (Config, error) {
 	config := DefaultConfig()
@@ -104,42 +141,42 @@ func LoadConfig(configPath string) (Config, error) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// If config file doesn't exist, create a default one.
-			LogInfo(fmt.Sprintf("Config file %s not found, creating default.", configPath))
+			logger.Info(fmt.Sprintf("Config file %s not found, creating default.", configPath))
 			err = SaveConfig(config, configPath)
 			if err != nil {
-				LogError(err, fmt.Sprintf("Failed to save default config to %s", configPath))
+				logger.Error(err, fmt.Sprintf("Failed to save default config to %s", configPath))
 				return config, fmt.Errorf("failed to create default config: %w", err)
 			}
 			return config, nil
 		}
-		LogError(err, fmt.Sprintf("Failed to read config file %s", configPath))
+		logger.Error(err, fmt.Sprintf("Failed to read config file %s", configPath))
 		return config, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	err = json.Unmarshal(data, &config)
+	err = unmarshalConfig(data, configFormat(configPath), &config)
 	if err != nil {
-		LogError(err, fmt.Sprintf("Failed to parse config file %s", configPath))
+		logger.Error(err, fmt.Sprintf("Failed to parse config file %s", configPath))
 		return config, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	LogInfo(fmt.Sprintf("Config loaded from %s.", configPath))
+	logger.Info(fmt.Sprintf("Config loaded from %s.", configPath))
 	return config, nil
 }
 
-// SaveConfig saves the given Config to a JSON file.
+// SaveConfig saves the given Config to a file in the format of its extension (see configFormat).
 func SaveConfig(config Config, configPath string) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := marshalConfig(config, configFormat(configPath))
 	if err != nil {
-		LogError(err, "Failed to marshal config to JSON")
+		logger.Error(err, "Failed to marshal config")
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
 	err = os.WriteFile(configPath, data, 0644)
 	if err != nil {
-		LogError(err, fmt.Sprintf("Failed to write config to file %s", configPath))
+		logger.Error(err, fmt.Sprintf("Failed to write config to file %s", configPath))
 		return fmt.Errorf("failed to save config to file: %w", err)
 	}
 
-	LogInfo(fmt.Sprintf("Config saved to %s.", configPath))
+	logger.Info(fmt.Sprintf("Config saved to %s.", configPath))
 	return nil
 }
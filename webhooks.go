@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"         // Package for sending the JSON payload
+	"encoding/json" // Package for encoding webhook payloads
+	"fmt"           // Package for formatted I/O (e.g., delivery errors)
+	"net/http"      // Package for posting events to webhook URLs
+	"net/url"       // Package for checking webhook URLs
+	"sync"          // Package for waiting for deliveries on exit
+	"time"          // Package for the retry backoff and event times
+)
+
+// The events a webhook can receive.
+const (
+	webhookAdded     = "added"
+	webhookCompleted = "completed"
+	webhookEdited    = "edited"
+	webhookDeleted   = "deleted"
+)
+
+// Webhook is a URL that receives a JSON payload (see WebhookEvent) by POST whenever a todo
+// is added, completed, edited, or deleted, e.g., to trigger an n8n or Zapier workflow.
+type Webhook struct {
+	URL    string   `json:"url"`    // http or https URL the events are posted to.
+	Events []string `json:"events"` // Events posted: added, completed, edited, or deleted; all of them if empty.
+}
+
+// webhooks are the registered webhooks. They are set from the webhooks config setting.
+var webhooks []Webhook
+
+// webhookAttempts is how often an event is posted before giving up, and webhookBackoff how long
+// the first retry waits. Each further retry waits twice as long as the one before.
+const webhookAttempts = 4
+
+var webhookBackoff = time.Second
+
+// webhookTimeout limits a single delivery attempt, and waiting for the deliveries on exit.
+const webhookTimeout = 10 * time.Second
+
+// webhookClient posts the events.
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// webhookDeliveries tracks the deliveries in progress, so that they can finish before the application exits.
+var webhookDeliveries sync.WaitGroup
+
+// WebhookEvent is the JSON payload posted to a webhook.
+type WebhookEvent struct {
+	Event   string    `json:"event"`             // added, completed, edited, or deleted.
+	Time    time.Time `json:"time"`              // When the change was made.
+	List    string    `json:"list"`              // Data file of the changed list.
+	Todo    Todo      `json:"todo"`              // The todo as it is after the change, or was before it was deleted.
+	Changes []string  `json:"changes,omitempty"` // Descriptions of the changed fields of an edited todo.
+}
+
+// validate reports a webhook with an invalid URL or event.
+func (w Webhook) validate() error {
+	if parsed, err := url.Parse(w.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL %q must be an http or https URL", w.URL)
+	}
+	for _, event := range w.Events {
+		switch event {
+		case webhookAdded, webhookCompleted, webhookEdited, webhookDeleted:
+		default:
+			return fmt.Errorf("webhook event %q must be added, completed, edited, or deleted", event)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the webhook receives an event.
+func (w Webhook) wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, wanted := range w.Events {
+		if wanted == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookEvents returns the events of the changes in a diff: a modified todo that was completed
+// is a completed event, and any other modification an edited one.
+func webhookEvents(diff TodoListDiff, list string, now time.Time) []WebhookEvent {
+	events := []WebhookEvent{}
+	for _, todo := range diff.Added {
+		events = append(events, WebhookEvent{Event: webhookAdded, Time: now, List: list, Todo: todo})
+	}
+	for _, change := range diff.Modified {
+		event := webhookEdited
+		if !change.Before.Completed && change.After.Completed {
+			event = webhookCompleted
+		}
+		events = append(events, WebhookEvent{Event: event, Time: now, List: list, Todo: change.After, Changes: change.Changes})
+	}
+	for _, todo := range diff.Deleted {
+		events = append(events, WebhookEvent{Event: webhookDeleted, Time: now, List: list, Todo: todo})
+	}
+	return events
+}
+
+// notifyWebhooks posts the events of the changes in a diff to the webhooks that want them, in the
+// background. Each webhook receives its events in order. Nothing is posted in dry-run mode.
+func notifyWebhooks(diff TodoListDiff) {
+	if len(webhooks) == 0 || dryRun || diff.IsEmpty() {
+		return
+	}
+	events := webhookEvents(diff, activeDataFile, time.Now())
+	for _, hook := range webhooks {
+		wanted := []WebhookEvent{}
+		for _, event := range events {
+			if hook.wants(event.Event) {
+				wanted = append(wanted, event)
+			}
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+		webhookDeliveries.Add(1)
+		go func(hook Webhook, events []WebhookEvent) {
+			defer webhookDeliveries.Done()
+			for _, event := range events {
+				if err := deliverWebhook(hook.URL, event); err != nil {
+					logger.Error(err, fmt.Sprintf("Failed to post the %s event of todo #%d to a webhook", event.Event, event.Todo.ID))
+				}
+			}
+		}(hook, wanted)
+	}
+}
+
+// deliverWebhook posts an event to a webhook URL, retrying with exponential backoff if the request
+// fails or the server answers with a server error or 429 Too Many Requests. Other client errors
+// (e.g., 404) are not retried. Returns the last error if no attempt succeeded.
+func deliverWebhook(hookURL string, event WebhookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode the webhook payload: %w", err)
+	}
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err = postWebhook(hookURL, event.Event, payload)
+		if err == nil {
+			return nil
+		}
+		if _, retry := err.(retryableError); !retry || attempt == webhookAttempts {
+			return fmt.Errorf("webhook %s: %w (after %d attempts)", hookURL, err, attempt)
+		}
+		logger.Debug(fmt.Sprintf("Retrying webhook %s in %s: %v", hookURL, backoff, err))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// retryableError is a failed delivery that may succeed when retried.
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+// postWebhook makes a single delivery attempt.
+func postWebhook(hookURL string, event string, payload []byte) error {
+	request, err := http.NewRequest(http.MethodPost, hookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Todo-Event", event)
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return retryableError{err}
+	}
+	response.Body.Close()
+	if response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests {
+		return retryableError{fmt.Errorf("server answered %s", response.Status)}
+	}
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("server answered %s", response.Status)
+	}
+	return nil
+}
+
+// waitForWebhooks waits for the deliveries in progress to finish, for at most timeout, so that
+// events of the last command are not lost when the application exits.
+func waitForWebhooks(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		webhookDeliveries.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warn("Gave up waiting for webhook deliveries to finish; some events were not posted.")
+	}
+}
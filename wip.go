@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt" // Package for formatted I/O (e.g., error messages)
+)
+
+// wipLimits maps a priority to the maximum number of open todos with that priority.
+// It is set from the wip_limits config setting; priorities without a limit are not checked.
+var wipLimits = map[PriorityLevel]int{}
+
+// wipLimitMode is what happens when adding or raising a todo would go beyond a WIP limit:
+// "warn" (the default) reports it, and "block" refuses the change.
+// It is set from the wip_limit_mode config setting.
+var wipLimitMode = "warn"
+
+// setWIPLimits sets the WIP limits from the wip_limits config setting, whose keys are
+// case-insensitive priorities. Invalid priorities and negative limits are logged and ignored.
+func setWIPLimits(limits map[string]int, mode string) {
+	wipLimits = map[PriorityLevel]int{}
+	for name, limit := range limits {
+		priority := PriorityLevel(name).Canonical()
+		if priority == "" || limit < 0 {
+			logger.Warn(fmt.Sprintf("Ignoring invalid WIP limit %q: %d", name, limit))
+			continue
+		}
+		wipLimits[priority] = limit
+	}
+	wipLimitMode = "warn"
+	if mode == "block" {
+		wipLimitMode = "block"
+	} else if mode != "" && mode != "warn" {
+		logger.Warn(fmt.Sprintf("Invalid WIP limit mode %q, using warn", mode))
+	}
+}
+
+// checkWIPLimit returns an error if the open todos of the list with the given priority, not counting
+// the todo with excludeID, already reach the WIP limit for that priority, so that one more would exceed it.
+func checkWIPLimit(tl *TodoList, priority PriorityLevel, excludeID int) error {
+	limit, ok := wipLimits[priority]
+	if !ok {
+		return nil
+	}
+	open := 0
+	for _, todo := range tl.Todos {
+		if todo.ID != excludeID && todo.Priority == priority && todo.IsOpen() {
+			open++
+		}
+	}
+	if open >= limit {
+		return fmt.Errorf("WIP limit reached: there are already %d open %s-priority todos (the limit is %d)", open, priority, limit)
+	}
+	return nil
+}
+
+// warnWIPLimit reports that the todo puts its priority over the WIP limit, if it does.
+func warnWIPLimit(todoList *TodoList, todo Todo) {
+	if err := checkWIPLimit(todoList, todo.Priority, todo.ID); err != nil {
+		PrintUserMessage(fmt.Sprintf("⚠️ %v. Consider finishing or deprioritizing some of them.", err))
+	}
+}